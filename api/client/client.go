@@ -0,0 +1,339 @@
+// Package client is a Go client for the Lema API. It wraps every endpoint
+// in a typed method, retries rate limited and unavailable requests, and
+// reports failures as *Error values.
+//
+//	c, err := client.New("https://api.example.com", client.WithAPIKey(key))
+//	user, err := c.GetUser(ctx, id)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/princecee/lema-ai/pkg/response"
+)
+
+const (
+	defaultTimeout      = 30 * time.Second
+	defaultRetries      = 3
+	defaultRetryWait    = 500 * time.Millisecond
+	defaultMaxRetryWait = time.Minute
+)
+
+// Client calls the API. It is safe for concurrent use.
+type Client struct {
+	baseURL      *url.URL
+	httpClient   *http.Client
+	header       http.Header
+	timeout      time.Duration
+	retries      int
+	retryWait    time.Duration
+	maxRetryWait time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithAPIKey authenticates requests with key in the X-API-Key header.
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.header.Set("X-API-Key", key)
+	}
+}
+
+// WithBearerToken authenticates requests with an Authorization bearer
+// token.
+func WithBearerToken(token string) Option {
+	return func(c *Client) {
+		c.header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// WithLocale asks for error messages in locale, e.g. "fr".
+func WithLocale(locale string) Option {
+	return func(c *Client) {
+		c.header.Set("Accept-Language", locale)
+	}
+}
+
+// WithHTTPClient sends requests with hc instead of http.DefaultClient.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithTimeout bounds each call, retries included. Streams, WebSocket
+// subscriptions and export downloads are only bounded while connecting.
+// A timeout of 0 disables it.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetries sets how many times a request answered with 429 Too Many
+// Requests or 503 Service Unavailable is sent again. 0 disables retries.
+func WithRetries(retries int) Option {
+	return func(c *Client) {
+		c.retries = retries
+	}
+}
+
+// WithRetryWait sets the wait before the first retry when the response has
+// no Retry-After header, doubled on every retry up to max. A response
+// asking to wait longer than max is returned instead of retried.
+func WithRetryWait(wait, max time.Duration) Option {
+	return func(c *Client) {
+		c.retryWait = wait
+		c.maxRetryWait = max
+	}
+}
+
+// New returns a client of the API served at baseURL, e.g.
+// "https://api.example.com".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("client: invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("client: invalid base URL %q: scheme must be http or https", baseURL)
+	}
+
+	c := &Client{
+		baseURL:      u,
+		httpClient:   http.DefaultClient,
+		header:       http.Header{},
+		timeout:      defaultTimeout,
+		retries:      defaultRetries,
+		retryWait:    defaultRetryWait,
+		maxRetryWait: defaultMaxRetryWait,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey returns a context whose POST requests carry key in the
+// Idempotency-Key header, so that sending them again after a network
+// failure does not apply them twice. Imports ignore the key.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// request describes a call. Its body is either body, encoded as JSON, or
+// upload, sent as is with contentType.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	header      http.Header
+	body        any
+	upload      io.Reader
+	contentType string
+	// noRetry returns 503 responses as they are; they are the answer of
+	// the readiness probe rather than a transient failure.
+	noRetry bool
+}
+
+// call sends r and decodes the data of the response envelope into a T.
+func call[T any](ctx context.Context, c *Client, r *request) (*T, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	resp, err := c.send(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, decodeError(resp)
+	}
+
+	var body response.Response[T]
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("client: decoding response of %s %s: %w", r.method, r.path, err)
+	}
+	return &body.Data, nil
+}
+
+// connect sends r for a response whose body is read after it returns, such
+// as a stream or a download. The timeout only bounds the wait for the
+// response; cancel releases the request once its body is closed.
+func (c *Client) connect(ctx context.Context, r *request) (*http.Response, context.CancelFunc, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	var timer *time.Timer
+	if c.timeout > 0 {
+		timer = time.AfterFunc(c.timeout, cancel)
+	}
+
+	resp, err := c.send(ctx, r)
+	if timer != nil && !timer.Stop() && err == nil {
+		resp.Body.Close()
+		err = context.DeadlineExceeded
+	}
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer cancel()
+		defer resp.Body.Close()
+		return nil, nil, decodeError(resp)
+	}
+	return resp, cancel, nil
+}
+
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// send sends r, retrying 429 and 503 responses. Uploads are only retried
+// when they can seek back to where they started.
+func (c *Client) send(ctx context.Context, r *request) (*http.Response, error) {
+	var payload []byte
+	if r.body != nil {
+		b, err := json.Marshal(r.body)
+		if err != nil {
+			return nil, fmt.Errorf("client: encoding request of %s %s: %w", r.method, r.path, err)
+		}
+		payload = b
+	}
+
+	seeker, _ := r.upload.(io.Seeker)
+	var start int64
+	if seeker != nil {
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			seeker = nil
+		}
+		start = offset
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := c.newRequest(ctx, r, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if r.noRetry || attempt >= c.retries || (r.upload != nil && seeker == nil) ||
+			(resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable) {
+			return resp, nil
+		}
+
+		wait, ok := c.retryDelay(resp.Header, attempt)
+		if !ok {
+			return resp, nil
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		if seeker != nil {
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) newRequest(ctx context.Context, r *request, payload []byte) (*http.Request, error) {
+	var body io.Reader
+	contentType := r.contentType
+	switch {
+	case payload != nil:
+		body = bytes.NewReader(payload)
+		contentType = response.JSONContentType
+	case r.upload != nil:
+		body = r.upload
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, c.url(r.path, r.query), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", response.JSONContentType)
+	for k, v := range r.header {
+		req.Header[k] = v
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if key, ok := ctx.Value(idempotencyKey{}).(string); ok && r.method == http.MethodPost {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	return req, nil
+}
+
+// url resolves path, whose segments are already escaped, against the base
+// URL.
+func (c *Client) url(path string, query url.Values) string {
+	u := *c.baseURL
+	u.RawPath = c.baseURL.EscapedPath() + path
+	if unescaped, err := url.PathUnescape(u.RawPath); err == nil {
+		u.Path = unescaped
+	}
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// retryDelay returns how long to wait before sending a request again, or
+// false if the server asks to wait longer than the maximum.
+func (c *Client) retryDelay(header http.Header, attempt int) (time.Duration, bool) {
+	if v := header.Get("Retry-After"); v != "" {
+		var wait time.Duration
+		if seconds, err := strconv.Atoi(v); err == nil {
+			wait = time.Duration(seconds) * time.Second
+		} else if t, err := http.ParseTime(v); err == nil {
+			wait = time.Until(t)
+		}
+		wait = max(wait, 0)
+		return wait, wait <= c.maxRetryWait
+	}
+
+	wait := c.retryWait << attempt
+	if wait <= 0 || wait > c.maxRetryWait {
+		wait = c.maxRetryWait
+	}
+	return wait, true
+}
+
+// escape escapes a path segment such as an ID.
+func escape(segment string) string {
+	return url.PathEscape(segment)
+}
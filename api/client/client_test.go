@@ -0,0 +1,423 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/princecee/lema-ai/client"
+	"github.com/princecee/lema-ai/config"
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/internal/db/repositories"
+	"github.com/princecee/lema-ai/internal/handlers"
+	"github.com/princecee/lema-ai/internal/routes"
+	"github.com/princecee/lema-ai/internal/services"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/store"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+const testAPIKey = "test-api-key"
+
+type ClientTestSuite struct {
+	suite.Suite
+	db     *gorm.DB
+	stream *services.EventStream
+	hub    *services.Hub
+	server *httptest.Server
+	client *client.Client
+	phones int
+
+	// rateLimited is the number of requests the server answers with 429
+	// before passing them to the router, and retryAfter their Retry-After.
+	rateLimited atomic.Int64
+	retryAfter  string
+	requests    atomic.Int64
+}
+
+func (s *ClientTestSuite) SetupSuite() {
+	cfg := config.NewConfig("test", "silent")
+	cfg.DSN = "file:client?mode=memory&cache=shared"
+	cfg.API_KEYS = []string{testAPIKey}
+	logger := zerolog.Nop()
+
+	db := database.GetDBConn(cfg.DSN, cfg.REPLICA_DSNS, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
+	err := database.Migrate(db)
+	if err != nil {
+		s.Fail(err.Error())
+	}
+	s.db = db
+
+	s.stream = services.NewEventStream(10)
+	s.hub = services.NewHub(s.stream, services.HubOptions{SendBuffer: 16}, logger)
+	s.hub.Start(context.Background())
+
+	health := handlers.NewHealthHandler(nil, cfg, logger)
+	jobQueue := services.NewJobQueue(repositories.NewJobRepository(db), services.JobOptions{}, logger)
+	exportService := services.NewExportService(database.NewTransactor(db), repositories.NewExportRepository(db), jobQueue, services.ExportOptions{}, logger)
+	webhookService := services.NewWebhookService(database.NewTransactor(db), repositories.NewWebhookRepository(db), jobQueue, services.WebhookOptions{}, logger)
+	router := routes.NewRouter(db, store.NewMemoryStore(), health, exportService, webhookService, s.stream, s.hub, cfg, logger)
+
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		if s.rateLimited.Add(-1) >= 0 {
+			w.Header().Set("Retry-After", s.retryAfter)
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+		router.ServeHTTP(w, r)
+	}))
+
+	s.client, err = client.New(s.server.URL, client.WithAPIKey(testAPIKey), client.WithTimeout(5*time.Second))
+	s.Require().NoError(err)
+}
+
+func (s *ClientTestSuite) TearDownSuite() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.NoError(s.hub.Shutdown(ctx))
+	s.stream.Close()
+	s.server.Close()
+
+	s.NoError(database.Close(s.db))
+}
+
+func (s *ClientTestSuite) SetupTest() {
+	s.rateLimited.Store(0)
+	s.requests.Store(0)
+}
+
+func (s *ClientTestSuite) createUser() *client.User {
+	id := uuid.NewString()
+	s.phones++
+	user, err := s.client.CreateUser(context.Background(), &client.CreateUserInput{
+		Name:     "Jane Doe",
+		Username: id,
+		Email:    id + "@example.com",
+		Phone:    fmt.Sprintf("+1555%07d", s.phones),
+		Address:  client.AddressInput{Street: "1 Main St", City: "Springfield", State: "IL", Zipcode: "62701"},
+	})
+	s.Require().NoError(err)
+	return user
+}
+
+func (s *ClientTestSuite) TestUsers() {
+	ctx := context.Background()
+	user := s.createUser()
+	s.NotEmpty(user.ID)
+
+	got, err := s.client.GetUser(ctx, user.ID)
+	s.Require().NoError(err)
+	s.Equal(user.Email, got.Email)
+	s.Equal("Springfield", got.Address.City)
+
+	updated, err := s.client.UpdateUser(ctx, user.ID, &client.UpdateUserInput{
+		Name:    client.String("John Doe"),
+		Address: &client.UpdateAddressInput{City: client.String("Chicago")},
+	})
+	s.Require().NoError(err)
+	s.Equal("John Doe", updated.Name)
+	s.Equal("Chicago", updated.Address.City)
+	s.Equal(user.Address.Street, updated.Address.Street)
+
+	s.createUser()
+	s.createUser()
+	count, err := s.client.CountUsers(ctx)
+	s.Require().NoError(err)
+
+	page, err := s.client.ListUsers(ctx, 1, 2)
+	s.Require().NoError(err)
+	s.Len(page.Users, 2)
+	s.Equal(count, page.Count)
+
+	ids := map[string]bool{}
+	it := s.client.Users(ctx, 2)
+	for it.Next() {
+		ids[it.User().ID] = true
+	}
+	s.Require().NoError(it.Err())
+	s.EqualValues(count, len(ids))
+	s.True(ids[user.ID])
+}
+
+func (s *ClientTestSuite) TestPosts() {
+	ctx := context.Background()
+	user := s.createUser()
+
+	post, err := s.client.CreatePost(ctx, &client.CreatePostInput{UserID: user.ID, Title: "Hello", Body: "World"})
+	s.Require().NoError(err)
+	s.Equal(user.ID, post.UserID)
+
+	updated, err := s.client.UpdatePost(ctx, post.ID, &client.UpdatePostInput{Body: client.String("Everyone")})
+	s.Require().NoError(err)
+	s.Equal("Hello", updated.Title)
+	s.Equal("Everyone", updated.Body)
+
+	posts, err := s.client.ListPosts(ctx, user.ID)
+	s.Require().NoError(err)
+	s.Require().Len(posts, 1)
+	s.Equal(post.ID, posts[0].ID)
+
+	s.Require().NoError(s.client.DeletePost(ctx, post.ID))
+
+	_, err = s.client.GetPost(ctx, post.ID)
+	s.ErrorIs(err, client.ErrNotFound)
+}
+
+func (s *ClientTestSuite) TestErrors() {
+	ctx := context.Background()
+
+	_, err := s.client.CreatePost(ctx, &client.CreatePostInput{UserID: "nope", Title: " ", Body: "World"})
+	var apiErr *client.Error
+	s.Require().ErrorAs(err, &apiErr)
+	s.Equal(http.StatusBadRequest, apiErr.StatusCode)
+	s.Contains(apiErr.Violations, "title")
+	s.Contains(apiErr.Violations, "userId")
+
+	fr, err := client.New(s.server.URL, client.WithLocale("fr"))
+	s.Require().NoError(err)
+	_, err = fr.GetUser(ctx, "nope")
+	s.Require().ErrorAs(err, &apiErr)
+	s.ErrorIs(err, client.ErrBadRequest)
+	s.Equal("Identifiant d'utilisateur invalide", apiErr.Message)
+
+	user := s.createUser()
+	_, err = s.client.UpdateUser(ctx, s.createUser().ID, &client.UpdateUserInput{Email: client.String(user.Email)})
+	s.ErrorIs(err, client.ErrConflict)
+}
+
+func (s *ClientTestSuite) TestRetries() {
+	ctx := context.Background()
+
+	s.retryAfter = "0"
+	s.rateLimited.Store(2)
+	_, err := s.client.CountUsers(ctx)
+	s.Require().NoError(err)
+	s.EqualValues(3, s.requests.Load())
+
+	// Retry-After is honored up to the maximum wait.
+	s.requests.Store(0)
+	s.rateLimited.Store(1)
+	impatient, err := client.New(s.server.URL, client.WithRetryWait(time.Millisecond, time.Second))
+	s.Require().NoError(err)
+	s.retryAfter = "1"
+	start := time.Now()
+	_, err = impatient.CountUsers(ctx)
+	s.Require().NoError(err)
+	s.GreaterOrEqual(time.Since(start), time.Second)
+	s.EqualValues(2, s.requests.Load())
+
+	s.requests.Store(0)
+	s.rateLimited.Store(1)
+	s.retryAfter = "60"
+	_, err = impatient.CountUsers(ctx)
+	s.ErrorIs(err, client.ErrTooManyRequests)
+	s.EqualValues(1, s.requests.Load())
+
+	// Without a Retry-After header, retries back off until they run out.
+	s.requests.Store(0)
+	s.rateLimited.Store(10)
+	s.retryAfter = ""
+	_, err = impatient.CountUsers(ctx)
+	s.ErrorIs(err, client.ErrTooManyRequests)
+	s.EqualValues(4, s.requests.Load())
+
+	// Creates sent again with the same idempotency key are applied once.
+	s.rateLimited.Store(0)
+	user := s.createUser()
+	ctx = client.WithIdempotencyKey(ctx, uuid.NewString())
+	input := &client.CreatePostInput{UserID: user.ID, Title: "Hello", Body: "World"}
+	first, err := s.client.CreatePost(ctx, input)
+	s.Require().NoError(err)
+	second, err := s.client.CreatePost(ctx, input)
+	s.Require().NoError(err)
+	s.Equal(first.ID, second.ID)
+}
+
+func (s *ClientTestSuite) TestExports() {
+	ctx := context.Background()
+
+	export, err := s.client.CreateExport(ctx, &client.CreateExportInput{Entity: models.ExportEntityUsers, Format: models.ExportFormatCSV})
+	s.Require().NoError(err)
+	s.Equal(models.ExportStatusPending, export.Status)
+
+	got, err := s.client.GetExport(ctx, export.ID)
+	s.Require().NoError(err)
+	s.Equal(export.ID, got.ID)
+
+	// No job worker runs, so the export is never completed.
+	_, err = s.client.DownloadExport(ctx, export.ID)
+	s.ErrorIs(err, client.ErrConflict)
+}
+
+func (s *ClientTestSuite) TestWebhooks() {
+	ctx := context.Background()
+
+	webhook, err := s.client.CreateWebhook(ctx, &client.CreateWebhookInput{
+		URL:    "https://example.com/hook",
+		Events: []string{models.EventPostCreated},
+	})
+	s.Require().NoError(err)
+	s.NotEmpty(webhook.Secret)
+
+	webhooks, err := s.client.ListWebhooks(ctx)
+	s.Require().NoError(err)
+	s.NotEmpty(webhooks)
+
+	got, err := s.client.GetWebhook(ctx, webhook.ID)
+	s.Require().NoError(err)
+	s.Equal(webhook.URL, got.URL)
+
+	deliveries, err := s.client.ListWebhookDeliveries(ctx, webhook.ID)
+	s.Require().NoError(err)
+	s.Empty(deliveries)
+
+	_, err = s.client.GetWebhookDelivery(ctx, webhook.ID, uuid.NewString())
+	s.ErrorIs(err, client.ErrNotFound)
+
+	s.Require().NoError(s.client.DeleteWebhook(ctx, webhook.ID))
+	_, err = s.client.GetWebhook(ctx, webhook.ID)
+	s.ErrorIs(err, client.ErrNotFound)
+}
+
+func (s *ClientTestSuite) TestImports() {
+	id := uuid.NewString()
+	file := `{"name":"Jane Doe","username":"` + id + `","email":"` + id + `@example.com","phone":"+15559999999",` +
+		`"address":{"street":"1 Main St","city":"Springfield","state":"IL","zipcode":"62701"}}` + "\n" +
+		`{"name":""}` + "\n"
+
+	report, err := s.client.ImportUsers(context.Background(), strings.NewReader(file), &client.ImportOptions{DryRun: true})
+	s.Require().NoError(err)
+	s.True(report.DryRun)
+	s.Equal(1, report.Created)
+	s.Equal(1, report.Failed)
+
+	// Readers that can seek are sent again when rate limited.
+	s.requests.Store(0)
+	s.rateLimited.Store(1)
+	s.retryAfter = "0"
+	report, err = s.client.ImportUsers(context.Background(), strings.NewReader(file), &client.ImportOptions{DryRun: true})
+	s.Require().NoError(err)
+	s.Equal(1, report.Created)
+	s.EqualValues(2, s.requests.Load())
+
+	_, err = s.client.ImportPosts(context.Background(), strings.NewReader(file), &client.ImportOptions{Format: "text/plain"})
+	s.ErrorIs(err, client.ErrUnsupportedMedia)
+}
+
+func (s *ClientTestSuite) TestHealth() {
+	ctx := context.Background()
+	s.NoError(s.client.Alive(ctx))
+
+	readiness, err := s.client.Ready(ctx)
+	s.Require().NoError(err)
+	s.Equal("up", readiness.Status)
+
+	doc, err := s.client.OpenAPI(ctx)
+	s.Require().NoError(err)
+	s.Contains(string(doc), `"openapi"`)
+}
+
+func (s *ClientTestSuite) TestGraphQL() {
+	ctx := context.Background()
+	user := s.createUser()
+
+	var data struct {
+		User struct {
+			Name string `json:"name"`
+		} `json:"user"`
+	}
+	err := s.client.GraphQL(ctx, `query($id: ID!) { user(id: $id) { name } }`, map[string]any{"id": user.ID}, &data)
+	s.Require().NoError(err)
+	s.Equal(user.Name, data.User.Name)
+
+	err = s.client.GraphQL(ctx, `{ users(limit: 1000) { count } }`, nil, nil)
+	var gqlErrs client.GraphQLErrors
+	s.Require().ErrorAs(err, &gqlErrs)
+	s.Require().Len(gqlErrs, 1)
+	s.Equal(apperror.CodeInvalidParameter, gqlErrs[0].Extensions.Code)
+}
+
+func (s *ClientTestSuite) TestStreamPosts() {
+	ctx := context.Background()
+	userId := uuid.NewString()
+
+	stream, err := s.client.StreamPosts(ctx, &client.StreamPostsOptions{UserID: userId})
+	s.Require().NoError(err)
+	defer stream.Close()
+
+	postId := uuid.NewString()
+	for _, uid := range []string{uuid.NewString(), userId} {
+		event := &models.OutboxEvent{
+			ID:      uuid.NewString(),
+			Event:   models.EventPostCreated,
+			Payload: `{"id":"` + postId + `","user_id":"` + uid + `"}`,
+		}
+		s.Require().NoError(s.stream.Publish(ctx, event))
+	}
+
+	s.Require().True(stream.Next(), stream.Err())
+	s.Equal(models.EventPostCreated, stream.Event().Event)
+	s.Equal(postId, stream.Event().Post.ID)
+	s.Equal(userId, stream.Event().Post.UserID)
+	s.NotEmpty(stream.Event().ID)
+
+	_, err = s.client.StreamPosts(ctx, &client.StreamPostsOptions{UserID: "nope"})
+	s.ErrorIs(err, client.ErrBadRequest)
+}
+
+func (s *ClientTestSuite) TestSubscribe() {
+	ctx := context.Background()
+
+	sub, err := s.client.Subscribe(ctx, client.TopicPosts)
+	s.Require().NoError(err)
+	defer sub.Close()
+
+	event := &models.OutboxEvent{ID: uuid.NewString(), Event: models.EventPostDeleted, Payload: `{"id":"1"}`}
+	s.Require().NoError(s.stream.Publish(ctx, event))
+
+	s.Require().True(sub.Next(), sub.Err())
+	s.Equal(client.TopicPosts, sub.Message().Topic)
+	s.Equal(event.ID, sub.Message().ID)
+	s.JSONEq(event.Payload, string(sub.Message().Data))
+
+	s.Require().NoError(sub.Close())
+	s.False(sub.Next())
+	s.NoError(sub.Err())
+
+	_, err = s.client.Subscribe(ctx, "nope")
+	var apiErr *client.Error
+	s.Require().ErrorAs(err, &apiErr)
+	s.Equal(apperror.CodeInvalidParameter, apiErr.Code)
+
+	anonymous, err := client.New(s.server.URL)
+	s.Require().NoError(err)
+	_, err = anonymous.Subscribe(ctx, client.TopicPosts)
+	s.Error(err)
+}
+
+func (s *ClientTestSuite) TestNew() {
+	_, err := client.New("ftp://example.com")
+	s.Error(err)
+
+	c, err := client.New("http://127.0.0.1:1/", client.WithRetries(0), client.WithTimeout(time.Second))
+	s.Require().NoError(err)
+	_, err = c.CountUsers(context.Background())
+	var apiErr *client.Error
+	s.False(errors.As(err, &apiErr))
+	s.Error(err)
+}
+
+func TestClient(t *testing.T) {
+	suite.Run(t, new(ClientTestSuite))
+}
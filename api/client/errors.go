@@ -0,0 +1,91 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/response"
+)
+
+// Error is an error response of the API.
+type Error struct {
+	StatusCode int
+	// Code is the machine-readable error code. It is only sent in
+	// application/problem+json responses and is empty otherwise.
+	Code    apperror.Code
+	Message string
+	// Violations maps the fields of a rejected request to the reason they
+	// were rejected.
+	Violations map[string]string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("client: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Is reports errors with the same status code as equal, so errors.Is(err,
+// client.ErrNotFound) matches any 404 response.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.StatusCode == e.StatusCode
+}
+
+var (
+	ErrBadRequest       = &Error{StatusCode: http.StatusBadRequest}
+	ErrUnauthorized     = &Error{StatusCode: http.StatusUnauthorized}
+	ErrNotFound         = &Error{StatusCode: http.StatusNotFound}
+	ErrConflict         = &Error{StatusCode: http.StatusConflict}
+	ErrPayloadTooLarge  = &Error{StatusCode: http.StatusRequestEntityTooLarge}
+	ErrUnsupportedMedia = &Error{StatusCode: http.StatusUnsupportedMediaType}
+	ErrUnprocessable    = &Error{StatusCode: http.StatusUnprocessableEntity}
+	ErrTooManyRequests  = &Error{StatusCode: http.StatusTooManyRequests}
+	ErrInternalServer   = &Error{StatusCode: http.StatusInternalServerError}
+	ErrUnavailable      = &Error{StatusCode: http.StatusServiceUnavailable}
+)
+
+// decodeError reads an error response, either a Response envelope or a
+// problem document. Bodies in neither format keep the status text as
+// their message.
+func decodeError(resp *http.Response) error {
+	apiErr := &Error{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return apiErr
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == response.ProblemContentType {
+		var problem response.Problem
+		if json.Unmarshal(body, &problem) == nil {
+			apiErr.Code = problem.Code
+			apiErr.Message = problem.Detail
+			for _, v := range problem.Errors {
+				if apiErr.Violations == nil {
+					apiErr.Violations = map[string]string{}
+				}
+				apiErr.Violations[v.Field] = v.Message
+			}
+		}
+		return apiErr
+	}
+
+	var envelope response.Response[json.RawMessage]
+	if json.Unmarshal(body, &envelope) != nil {
+		return apiErr
+	}
+	if envelope.Message != "" {
+		apiErr.Message = envelope.Message
+	}
+	// Validation errors list their violations in data; other errors have
+	// no data or data that is not a map of strings.
+	var violations map[string]string
+	if json.Unmarshal(envelope.Data, &violations) == nil && len(violations) > 0 {
+		apiErr.Violations = violations
+	}
+	return apiErr
+}
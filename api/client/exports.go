@@ -0,0 +1,41 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// CreateExport starts an export. It runs in the background; poll GetExport
+// until its status is completed, then download it.
+func (c *Client) CreateExport(ctx context.Context, input *CreateExportInput) (*Export, error) {
+	return call[Export](ctx, c, &request{method: http.MethodPost, path: "/api/v1/exports", body: input})
+}
+
+func (c *Client) GetExport(ctx context.Context, exportId string) (*Export, error) {
+	return call[Export](ctx, c, &request{method: http.MethodGet, path: "/api/v1/exports/" + escape(exportId)})
+}
+
+// DownloadExport returns the gzip file of a completed export. Exports that
+// are not completed yet are reported as ErrConflict. The caller must close
+// the file.
+func (c *Client) DownloadExport(ctx context.Context, exportId string) (io.ReadCloser, error) {
+	r := &request{method: http.MethodGet, path: "/api/v1/exports/" + escape(exportId) + "/download"}
+	resp, cancel, err := c.connect(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return &download{resp.Body, cancel}, nil
+}
+
+// download releases the request of a download once it is closed.
+type download struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (d *download) Close() error {
+	err := d.ReadCloser.Close()
+	d.cancel()
+	return err
+}
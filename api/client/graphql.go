@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	apperror "github.com/princecee/lema-ai/pkg/error"
+)
+
+// GraphQLError is an error of a GraphQL query. Errors raised while
+// resolving fields carry their error code and violations.
+type GraphQLError struct {
+	Message    string `json:"message"`
+	Path       []any  `json:"path"`
+	Extensions struct {
+		Code       apperror.Code        `json:"code"`
+		Violations []apperror.Violation `json:"violations"`
+	} `json:"extensions"`
+}
+
+// GraphQLErrors lists the errors of a GraphQL query.
+type GraphQLErrors []GraphQLError
+
+func (e GraphQLErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Message
+	}
+	return "client: graphql: " + strings.Join(messages, "; ")
+}
+
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+// GraphQL runs a query or mutation and decodes its data into data. When the
+// query fails, partly or entirely, the error is a GraphQLErrors; the data
+// that could be resolved is still decoded.
+func (c *Client) GraphQL(ctx context.Context, query string, variables map[string]any, data any) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	r := &request{method: http.MethodPost, path: "/api/v1/graphql", body: graphQLRequest{query, variables}}
+	resp, err := c.send(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return decodeError(resp)
+	}
+
+	var body struct {
+		Data   json.RawMessage `json:"data"`
+		Errors GraphQLErrors   `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("client: decoding response of POST /api/v1/graphql: %w", err)
+	}
+
+	if data != nil && len(body.Data) > 0 && string(body.Data) != "null" {
+		if err := json.Unmarshal(body.Data, data); err != nil {
+			return fmt.Errorf("client: decoding graphql data: %w", err)
+		}
+	}
+	if len(body.Errors) > 0 {
+		return body.Errors
+	}
+	return nil
+}
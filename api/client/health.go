@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/princecee/lema-ai/pkg/response"
+)
+
+// Alive checks that the service is running.
+func (c *Client) Alive(ctx context.Context) error {
+	_, err := call[json.RawMessage](ctx, c, &request{method: http.MethodGet, path: "/healthz"})
+	return err
+}
+
+// Ready runs the readiness checks of the service. A service that is not
+// ready is reported as ErrUnavailable, along with the result of its
+// checks.
+func (c *Client) Ready(ctx context.Context) (*Readiness, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	resp, err := c.send(ctx, &request{method: http.MethodGet, path: "/readyz", noRetry: true})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		return nil, decodeError(resp)
+	}
+
+	var body response.Response[*Readiness]
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("client: decoding response of GET /readyz: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return body.Data, &Error{StatusCode: resp.StatusCode, Message: body.Message}
+	}
+	return body.Data, nil
+}
+
+// OpenAPI returns the OpenAPI document of the API.
+func (c *Client) OpenAPI(ctx context.Context) (json.RawMessage, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	resp, err := c.send(ctx, &request{method: http.MethodGet, path: "/api/v1/openapi.json"})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+
+	var doc json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("client: decoding response of GET /api/v1/openapi.json: %w", err)
+	}
+	return doc, nil
+}
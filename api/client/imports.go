@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/princecee/lema-ai/pkg/response"
+)
+
+// Formats of the files imported by ImportUsers and ImportPosts.
+const (
+	FormatCSV    = response.CSVContentType
+	FormatNDJSON = response.NDJSONContentType
+)
+
+// ImportOptions configures an import.
+type ImportOptions struct {
+	// Format is FormatCSV or FormatNDJSON, the default.
+	Format string
+	// DryRun rolls the import back, so the report shows what it would do.
+	DryRun bool
+	// Upsert updates existing users instead of skipping them.
+	Upsert bool
+}
+
+// ImportUsers imports the users of a CSV or NDJSON file, whose rows have
+// the fields of CreateUserInput. The file is only sent again on 429 and
+// 503 responses when it is an io.Seeker, such as an *os.File.
+func (c *Client) ImportUsers(ctx context.Context, file io.Reader, opts *ImportOptions) (*ImportReport, error) {
+	return c.importFile(ctx, "/api/v1/import/users", file, opts)
+}
+
+// ImportPosts imports the posts of a CSV or NDJSON file, whose rows have
+// id, title, body and user_id fields. Posts with an existing id are
+// skipped.
+func (c *Client) ImportPosts(ctx context.Context, file io.Reader, opts *ImportOptions) (*ImportReport, error) {
+	return c.importFile(ctx, "/api/v1/import/posts", file, opts)
+}
+
+func (c *Client) importFile(ctx context.Context, path string, file io.Reader, opts *ImportOptions) (*ImportReport, error) {
+	if opts == nil {
+		opts = &ImportOptions{}
+	}
+	contentType := opts.Format
+	if contentType == "" {
+		contentType = FormatNDJSON
+	}
+
+	query := url.Values{}
+	if opts.DryRun {
+		query.Set("dry_run", strconv.FormatBool(true))
+	}
+	if opts.Upsert {
+		query.Set("upsert", strconv.FormatBool(true))
+	}
+
+	r := &request{method: http.MethodPost, path: path, query: query, upload: file, contentType: contentType}
+	return call[ImportReport](ctx, c, r)
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/princecee/lema-ai/pkg/response"
+)
+
+// ListPosts returns the posts of a user.
+func (c *Client) ListPosts(ctx context.Context, userId string) ([]*Post, error) {
+	query := url.Values{}
+	query.Set("user_id", userId)
+	posts, err := call[[]*Post](ctx, c, &request{method: http.MethodGet, path: "/api/v1/posts", query: query})
+	if err != nil {
+		return nil, err
+	}
+	return *posts, nil
+}
+
+func (c *Client) GetPost(ctx context.Context, postId string) (*Post, error) {
+	return call[Post](ctx, c, &request{method: http.MethodGet, path: "/api/v1/posts/" + escape(postId)})
+}
+
+func (c *Client) CreatePost(ctx context.Context, input *CreatePostInput) (*Post, error) {
+	return call[Post](ctx, c, &request{method: http.MethodPost, path: "/api/v1/posts", body: input})
+}
+
+func (c *Client) UpdatePost(ctx context.Context, postId string, input *UpdatePostInput) (*Post, error) {
+	return call[Post](ctx, c, &request{method: http.MethodPatch, path: "/api/v1/posts/" + escape(postId), body: input})
+}
+
+func (c *Client) DeletePost(ctx context.Context, postId string) error {
+	_, err := call[json.RawMessage](ctx, c, &request{method: http.MethodDelete, path: "/api/v1/posts/" + escape(postId)})
+	return err
+}
+
+// StreamPostsOptions filters the events of StreamPosts.
+type StreamPostsOptions struct {
+	// UserID only streams the events of this user's posts.
+	UserID string
+	// LastEventID resumes a stream after the event with this ID.
+	LastEventID string
+}
+
+// PostEvent is a post.created, post.updated or post.deleted event. ID is
+// the event's ID, to resume a stream from.
+type PostEvent struct {
+	ID    string
+	Event string
+	Post  Post
+}
+
+// StreamPosts opens the server-sent events stream of post events. The
+// stream lasts until it is closed, ctx is done or the server ends it; the
+// client should then open a new one with the ID of the last event it got.
+//
+//	stream, err := c.StreamPosts(ctx, nil)
+//	defer stream.Close()
+//	for stream.Next() {
+//		event := stream.Event()
+//	}
+func (c *Client) StreamPosts(ctx context.Context, opts *StreamPostsOptions) (*PostStream, error) {
+	if opts == nil {
+		opts = &StreamPostsOptions{}
+	}
+
+	r := &request{method: http.MethodGet, path: "/api/v1/posts/stream", header: http.Header{}}
+	if opts.UserID != "" {
+		r.query = url.Values{"user_id": {opts.UserID}}
+	}
+	if opts.LastEventID != "" {
+		r.header.Set("Last-Event-ID", opts.LastEventID)
+	}
+	r.header.Set("Accept", response.EventStreamContentType)
+
+	resp, cancel, err := c.connect(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return &PostStream{resp: resp, cancel: cancel, scanner: bufio.NewScanner(resp.Body)}, nil
+}
+
+// PostStream reads the events of StreamPosts.
+type PostStream struct {
+	resp    *http.Response
+	cancel  context.CancelFunc
+	scanner *bufio.Scanner
+	event   *PostEvent
+	err     error
+}
+
+// Next waits for the next event. It returns false once the stream has
+// ended or failed.
+func (s *PostStream) Next() bool {
+	if s.err != nil {
+		return false
+	}
+
+	var id, event string
+	var data []string
+	for s.scanner.Scan() {
+		line := s.scanner.Text()
+		if line == "" {
+			if data == nil {
+				continue
+			}
+
+			e := &PostEvent{ID: id, Event: event}
+			if err := json.Unmarshal([]byte(strings.Join(data, "\n")), &e.Post); err != nil {
+				s.err = fmt.Errorf("client: decoding event %s: %w", id, err)
+				return false
+			}
+			s.event = e
+			return true
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			id = value
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+
+	s.err = s.scanner.Err()
+	return false
+}
+
+// Event returns the current event.
+func (s *PostStream) Event() *PostEvent {
+	return s.event
+}
+
+// Err returns the error that ended the stream, if any. A stream closed by
+// the server ends without an error.
+func (s *PostStream) Err() error {
+	return s.err
+}
+
+func (s *PostStream) Close() error {
+	s.cancel()
+	return s.resp.Body.Close()
+}
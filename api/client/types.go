@@ -0,0 +1,110 @@
+package client
+
+import (
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/pkg/importer"
+	"github.com/princecee/lema-ai/pkg/pagination"
+)
+
+// The resources of the API are those the server encodes, so they cannot
+// drift apart.
+type (
+	User            = models.User
+	Address         = models.Address
+	Post            = models.Post
+	UsersPage       = pagination.GetUsersResult
+	Export          = models.Export
+	ExportFilters   = models.ExportFilters
+	Webhook         = models.Webhook
+	WebhookDelivery = models.WebhookDelivery
+	WebhookAttempt  = models.WebhookAttempt
+	ImportReport    = importer.Report
+	ImportResult    = importer.Result
+)
+
+// CreateUserInput is the body of CreateUser.
+type CreateUserInput struct {
+	Name     string       `json:"name"`
+	Username string       `json:"username"`
+	Email    string       `json:"email"`
+	Phone    string       `json:"phone"`
+	Address  AddressInput `json:"address"`
+}
+
+type AddressInput struct {
+	Street  string `json:"street"`
+	City    string `json:"city"`
+	State   string `json:"state"`
+	Zipcode string `json:"zipcode"`
+}
+
+// UpdateUserInput is the body of UpdateUser. Nil fields are left
+// unchanged.
+type UpdateUserInput struct {
+	Name     *string             `json:"name,omitempty"`
+	Username *string             `json:"username,omitempty"`
+	Email    *string             `json:"email,omitempty"`
+	Phone    *string             `json:"phone,omitempty"`
+	Address  *UpdateAddressInput `json:"address,omitempty"`
+}
+
+type UpdateAddressInput struct {
+	Street  *string `json:"street,omitempty"`
+	City    *string `json:"city,omitempty"`
+	State   *string `json:"state,omitempty"`
+	Zipcode *string `json:"zipcode,omitempty"`
+}
+
+// CreatePostInput is the body of CreatePost.
+type CreatePostInput struct {
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+	UserID string `json:"userId"`
+}
+
+// UpdatePostInput is the body of UpdatePost. Nil fields are left unchanged.
+type UpdatePostInput struct {
+	Title *string `json:"title,omitempty"`
+	Body  *string `json:"body,omitempty"`
+}
+
+// CreateExportInput is the body of CreateExport. Entity is "users" or
+// "posts" and Format is "json", "csv" or "ndjson".
+type CreateExportInput struct {
+	Entity  string        `json:"entity"`
+	Format  string        `json:"format"`
+	Filters ExportFilters `json:"filters"`
+}
+
+// CreateWebhookInput is the body of CreateWebhook. A secret is generated
+// when Secret is empty.
+type CreateWebhookInput struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret,omitempty"`
+}
+
+// CreatedWebhook is a new webhook with the secret signing its deliveries,
+// which is not returned again.
+type CreatedWebhook struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+// Readiness is the result of the readiness probe and of each of its
+// checks.
+type Readiness struct {
+	Status string                    `json:"status"`
+	Checks map[string]ReadinessCheck `json:"checks"`
+}
+
+type ReadinessCheck struct {
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// String returns a pointer to s, to set the fields of update inputs.
+func String(s string) *string {
+	return &s
+}
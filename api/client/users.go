@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ListUsers returns a page of users.
+func (c *Client) ListUsers(ctx context.Context, page, limit int) (*UsersPage, error) {
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(page))
+	query.Set("limit", strconv.Itoa(limit))
+	return call[UsersPage](ctx, c, &request{method: http.MethodGet, path: "/api/v1/users", query: query})
+}
+
+// CountUsers returns the number of users.
+func (c *Client) CountUsers(ctx context.Context) (int64, error) {
+	data, err := call[map[string]int64](ctx, c, &request{method: http.MethodGet, path: "/api/v1/users/count"})
+	if err != nil {
+		return 0, err
+	}
+	return (*data)["count"], nil
+}
+
+func (c *Client) GetUser(ctx context.Context, userId string) (*User, error) {
+	return call[User](ctx, c, &request{method: http.MethodGet, path: "/api/v1/users/" + escape(userId)})
+}
+
+func (c *Client) CreateUser(ctx context.Context, input *CreateUserInput) (*User, error) {
+	return call[User](ctx, c, &request{method: http.MethodPost, path: "/api/v1/users", body: input})
+}
+
+func (c *Client) UpdateUser(ctx context.Context, userId string, input *UpdateUserInput) (*User, error) {
+	return call[User](ctx, c, &request{method: http.MethodPatch, path: "/api/v1/users/" + escape(userId), body: input})
+}
+
+// Users returns an iterator over every user, fetched limit at a time.
+//
+//	it := c.Users(ctx, 100)
+//	for it.Next() {
+//		user := it.User()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+func (c *Client) Users(ctx context.Context, limit int) *UserIterator {
+	return &UserIterator{ctx: ctx, client: c, limit: limit, hasNext: true}
+}
+
+// UserIterator walks the pages of users. Users created or deleted while
+// iterating may be skipped or returned twice, as pages are fetched by
+// offset.
+type UserIterator struct {
+	ctx     context.Context
+	client  *Client
+	limit   int
+	page    int
+	hasNext bool
+	users   []*User
+	user    *User
+	err     error
+}
+
+// Next advances to the next user, fetching the next page when the current
+// one is exhausted. It returns false when there are no more users or a
+// page could not be fetched.
+func (it *UserIterator) Next() bool {
+	for len(it.users) == 0 {
+		if it.err != nil || !it.hasNext {
+			return false
+		}
+
+		page, err := it.client.ListUsers(it.ctx, it.page+1, it.limit)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page++
+		it.users = page.Users
+		it.hasNext = page.HasNext
+	}
+
+	it.user, it.users = it.users[0], it.users[1:]
+	return true
+}
+
+// User returns the current user.
+func (it *UserIterator) User() *User {
+	return it.user
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *UserIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// CreateWebhook subscribes a URL to events. The returned secret is not
+// shown again.
+func (c *Client) CreateWebhook(ctx context.Context, input *CreateWebhookInput) (*CreatedWebhook, error) {
+	return call[CreatedWebhook](ctx, c, &request{method: http.MethodPost, path: "/api/v1/webhooks", body: input})
+}
+
+func (c *Client) ListWebhooks(ctx context.Context) ([]*Webhook, error) {
+	webhooks, err := call[[]*Webhook](ctx, c, &request{method: http.MethodGet, path: "/api/v1/webhooks"})
+	if err != nil {
+		return nil, err
+	}
+	return *webhooks, nil
+}
+
+func (c *Client) GetWebhook(ctx context.Context, webhookId string) (*Webhook, error) {
+	return call[Webhook](ctx, c, &request{method: http.MethodGet, path: "/api/v1/webhooks/" + escape(webhookId)})
+}
+
+// DeleteWebhook deletes a webhook and its deliveries.
+func (c *Client) DeleteWebhook(ctx context.Context, webhookId string) error {
+	_, err := call[json.RawMessage](ctx, c, &request{method: http.MethodDelete, path: "/api/v1/webhooks/" + escape(webhookId)})
+	return err
+}
+
+// ListWebhookDeliveries returns the latest deliveries of a webhook with
+// their attempts.
+func (c *Client) ListWebhookDeliveries(ctx context.Context, webhookId string) ([]*WebhookDelivery, error) {
+	deliveries, err := call[[]*WebhookDelivery](ctx, c, &request{method: http.MethodGet, path: deliveriesPath(webhookId)})
+	if err != nil {
+		return nil, err
+	}
+	return *deliveries, nil
+}
+
+func (c *Client) GetWebhookDelivery(ctx context.Context, webhookId, deliveryId string) (*WebhookDelivery, error) {
+	return call[WebhookDelivery](ctx, c, &request{method: http.MethodGet, path: deliveriesPath(webhookId) + "/" + escape(deliveryId)})
+}
+
+// RedeliverWebhook queues a delivery to be sent again.
+func (c *Client) RedeliverWebhook(ctx context.Context, webhookId, deliveryId string) (*WebhookDelivery, error) {
+	path := deliveriesPath(webhookId) + "/" + escape(deliveryId) + "/redeliver"
+	return call[WebhookDelivery](ctx, c, &request{method: http.MethodPost, path: path})
+}
+
+func deliveriesPath(webhookId string) string {
+	return "/api/v1/webhooks/" + escape(webhookId) + "/deliveries"
+}
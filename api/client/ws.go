@@ -0,0 +1,185 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"golang.org/x/net/websocket"
+)
+
+// Topics of Subscribe. Besides these, "user:{id}" carries the events of a
+// user and "user:{id}:posts" those of the user's posts.
+const (
+	TopicPosts = "posts:all"
+	TopicUsers = "users:all"
+)
+
+// Message is an event received on one of the topics of a subscription.
+// Data is the user or post the event is about.
+type Message struct {
+	Topic string          `json:"topic"`
+	ID    string          `json:"id"`
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// wsFrame holds the fields of every frame sent or received.
+type wsFrame struct {
+	Type    string          `json:"type"`
+	Topic   string          `json:"topic,omitempty"`
+	ID      string          `json:"id,omitempty"`
+	Event   string          `json:"event,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Code    apperror.Code   `json:"code,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+// Subscribe opens a WebSocket receiving the events of topics. It returns
+// once every topic is subscribed to. The WebSocket requires an API key,
+// see WithAPIKey.
+//
+//	sub, err := c.Subscribe(ctx, client.TopicPosts)
+//	defer sub.Close()
+//	for sub.Next() {
+//		msg := sub.Message()
+//	}
+func (c *Client) Subscribe(ctx context.Context, topics ...string) (*Subscription, error) {
+	location := c.url("/api/v1/ws", nil)
+	location = "ws" + strings.TrimPrefix(location, "http")
+	config, err := websocket.NewConfig(location, c.baseURL.String())
+	if err != nil {
+		return nil, fmt.Errorf("client: invalid WebSocket URL: %w", err)
+	}
+	config.Header = c.header.Clone()
+
+	dialCtx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	conn, err := config.DialContext(dialCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Subscription{ctx: ctx, conn: conn}
+	// Closing the connection ends a Next waiting for a frame.
+	s.stop = context.AfterFunc(ctx, func() { conn.Close() })
+
+	if err := s.subscribe(dialCtx, topics); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Subscription receives the events of the topics it subscribed to.
+type Subscription struct {
+	ctx     context.Context
+	conn    *websocket.Conn
+	stop    func() bool
+	closed  atomic.Bool
+	pending []*Message
+	message *Message
+	err     error
+}
+
+// subscribe sends a subscribe frame for each topic and waits for their
+// replies. Events received in the meantime are kept for Next.
+func (s *Subscription) subscribe(ctx context.Context, topics []string) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		s.conn.SetDeadline(deadline)
+		defer s.conn.SetDeadline(time.Time{})
+	}
+
+	waiting := map[string]bool{}
+	for _, topic := range topics {
+		if err := websocket.JSON.Send(s.conn, wsFrame{Type: "subscribe", Topic: topic}); err != nil {
+			return err
+		}
+		waiting[topic] = true
+	}
+
+	for len(waiting) > 0 {
+		var frame wsFrame
+		if err := websocket.JSON.Receive(s.conn, &frame); err != nil {
+			return err
+		}
+
+		switch frame.Type {
+		case "subscribed":
+			delete(waiting, frame.Topic)
+		case "event":
+			s.pending = append(s.pending, frame.message())
+		case "error":
+			return frame.error()
+		}
+	}
+	return nil
+}
+
+// Next waits for the next event. It returns false once the subscription is
+// closed or failed.
+func (s *Subscription) Next() bool {
+	if len(s.pending) > 0 {
+		s.message, s.pending = s.pending[0], s.pending[1:]
+		return true
+	}
+	if s.err != nil || s.closed.Load() {
+		return false
+	}
+
+	for {
+		var frame wsFrame
+		if err := websocket.JSON.Receive(s.conn, &frame); err != nil {
+			switch {
+			case s.closed.Load() || errors.Is(err, io.EOF):
+			case s.ctx.Err() != nil:
+				s.err = s.ctx.Err()
+			default:
+				s.err = err
+			}
+			return false
+		}
+
+		switch frame.Type {
+		case "event":
+			s.message = frame.message()
+			return true
+		case "error":
+			s.err = frame.error()
+			return false
+		}
+	}
+}
+
+// Message returns the current event.
+func (s *Subscription) Message() *Message {
+	return s.message
+}
+
+// Err returns the error that ended the subscription, if any. Subscriptions
+// closed by Close or by the server end without an error.
+func (s *Subscription) Err() error {
+	return s.err
+}
+
+func (s *Subscription) Close() error {
+	s.closed.Store(true)
+	s.stop()
+	return s.conn.Close()
+}
+
+func (f *wsFrame) message() *Message {
+	return &Message{Topic: f.Topic, ID: f.ID, Event: f.Event, Data: f.Data}
+}
+
+// error reports an error frame, which answers a frame the client sent.
+func (f *wsFrame) error() error {
+	return &Error{StatusCode: http.StatusBadRequest, Code: f.Code, Message: f.Message}
+}
@@ -5,84 +5,134 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"github.com/go-chi/chi"
-	"github.com/go-chi/chi/middleware"
-	"github.com/go-chi/cors"
-	"github.com/go-chi/httprate"
 	"github.com/joho/godotenv"
 	"github.com/princecee/lema-ai/config"
 	database "github.com/princecee/lema-ai/internal/db"
-	"github.com/princecee/lema-ai/internal/db/models"
 	"github.com/princecee/lema-ai/internal/db/repositories"
+	"github.com/princecee/lema-ai/internal/handlers"
 	"github.com/princecee/lema-ai/internal/middlewares"
 	"github.com/princecee/lema-ai/internal/routes"
+	"github.com/princecee/lema-ai/internal/rpc"
 	"github.com/princecee/lema-ai/internal/services"
-	"github.com/princecee/lema-ai/pkg/response"
+	"github.com/princecee/lema-ai/pkg/broker"
+	"github.com/princecee/lema-ai/pkg/store"
+	"github.com/princecee/lema-ai/pkg/validator"
 	"github.com/rs/zerolog"
-	"gorm.io/gorm"
 )
 
-func addRoutes(db *gorm.DB, cfg *config.Config, l zerolog.Logger) chi.Router {
-	userRepo := repositories.NewUserRepository(db)
-	postRepo := repositories.NewPostRepository(db)
-
-	userService := services.NewUserService(userRepo)
-	postService := services.NewPostService(postRepo)
-
-	userRouter := routes.AddUserRoutes(db, userService, cfg, l)
-	postRouter := routes.AddPostRoutes(db, postService, cfg, l)
-	r := chi.NewRouter()
-
-	r.Use(httprate.LimitByIP(100, 1*time.Minute))
-	r.Use(middleware.CleanPath)
-	r.Use(middleware.Heartbeat("/ping"))
-	r.Use(middleware.Recoverer)
-	r.Use(cors.AllowAll().Handler)
-	r.Use(middlewares.RequestSize(1 << 20)) // 1mb body limit
-	r.Mount("/api/v1/users", userRouter)
-	r.Mount("/api/v1/posts", postRouter)
-
-	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
-		resp := response.Response[any]{
-			Message: fmt.Sprintf("%s %s not found", r.Method, r.URL.Path),
-		}
-		response.SendErrorResponse(w, resp, http.StatusNotFound)
-	})
-
-	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
-		resp := response.Response[any]{
-			Message: fmt.Sprintf("%s %s not allowed", r.Method, r.URL.Path),
-		}
-		response.SendErrorResponse(w, resp, http.StatusMethodNotAllowed)
-	})
-
-	return r
-}
+// shutdownTimeout bounds how long requests and background work in flight
+// get to finish once the server stops accepting connections.
+const shutdownTimeout = 5 * time.Second
 
 func main() {
-	var env, loglevel string
+	var env, loglevel, configFile string
 
 	flag.StringVar(&env, "env", config.EnvDevelopment, "Environment to run the server")
 	flag.StringVar(&loglevel, "loglevel", "silent", "Log level for the server")
+	flag.StringVar(&configFile, "config", ".env", "Path to a config file in .env format")
 	flag.Parse()
 
 	if env != "test" {
-		_ = godotenv.Load()
+		// Variables already set in the environment take precedence over
+		// the config file.
+		_ = godotenv.Load(configFile)
 	}
 
 	cfg := config.NewConfig(env, loglevel)
+	validator.SetBannedWords(cfg.BANNED_WORDS)
 	logger := zerolog.New(os.Stdout).Level(config.GetLoggerLevel(cfg.LOG_LEVEL))
 
-	db := database.GetDBConn(cfg.DSN, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
-	_ = db.AutoMigrate(&models.User{}, &models.Address{}, &models.Post{})
+	db := database.GetDBConn(cfg.DSN, cfg.REPLICA_DSNS, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
+	_ = database.Migrate(db)
+
+	st, err := store.New(cfg.STORE_BACKEND, cfg.REDIS_URL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer st.Close()
+
+	health := handlers.NewHealthHandler([]handlers.HealthCheck{
+		{Name: "database", Check: func(ctx context.Context) error { return database.Ping(ctx, db) }},
+		{Name: "migrations", Check: func(ctx context.Context) error { return database.CheckMigrations(ctx, db) }},
+	}, cfg, logger)
+
+	jobQueue := services.NewJobQueue(repositories.NewJobRepository(db), services.JobOptions{
+		Workers:      cfg.JOB_WORKERS,
+		PollInterval: cfg.JOB_POLL_INTERVAL,
+		LockTimeout:  cfg.JOB_LOCK_TIMEOUT,
+		MaxAttempts:  cfg.JOB_MAX_ATTEMPTS,
+		BackoffBase:  cfg.JOB_BACKOFF_BASE,
+		BackoffMax:   cfg.JOB_BACKOFF_MAX,
+	}, logger)
+
+	exportService := services.NewExportService(database.NewTransactor(db), repositories.NewExportRepository(db), jobQueue, services.ExportOptions{
+		Dir:             cfg.EXPORT_DIR,
+		TTL:             cfg.EXPORT_TTL,
+		CleanupInterval: cfg.EXPORT_CLEANUP_INTERVAL,
+	}, logger)
+	jobQueue.Register(services.ExportJobKind, exportService.RunExportJob)
+
+	webhookService := services.NewWebhookService(database.NewTransactor(db), repositories.NewWebhookRepository(db), jobQueue, services.WebhookOptions{
+		Timeout:              cfg.WEBHOOK_TIMEOUT,
+		AllowPrivateNetworks: cfg.WEBHOOK_ALLOW_PRIVATE_NETWORKS,
+	}, logger)
+	jobQueue.Register(services.WebhookJobKind, webhookService.RunDeliveryJob)
+
+	// The event stream feeds the realtime endpoints, so it always gets
+	// the events. It only gets those of this instance's relay: with
+	// several instances, realtime clients miss the events the others
+	// dispatch (see the README).
+	eventStream := services.NewEventStream(cfg.STREAM_BUFFER_SIZE)
+	sinks := map[string]services.Sink{"stream": eventStream}
+	for _, name := range cfg.OUTBOX_SINKS {
+		switch name {
+		case "log":
+			sinks[name] = services.NewLogSink(logger)
+		case "webhook":
+			sinks[name] = webhookService
+		case "broker":
+			b, err := broker.New(cfg.BROKER_BACKEND)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer b.Close()
+			sinks[name] = services.NewBrokerSink(b)
+		default:
+			log.Fatalf("unknown outbox sink %q", name)
+		}
+	}
+	outboxRelay := services.NewOutboxRelay(repositories.NewOutboxRepository(db), sinks, services.OutboxOptions{
+		PollInterval: cfg.OUTBOX_POLL_INTERVAL,
+		BatchSize:    cfg.OUTBOX_BATCH_SIZE,
+		Retention:    cfg.OUTBOX_RETENTION,
+	}, logger)
 
-	r := addRoutes(db, cfg, logger)
+	backupService := services.NewBackupService(database.NewBackups(db, cfg.BACKUP_DIR, cfg.BACKUP_RETENTION), cfg.BACKUP_INTERVAL, logger)
+
+	if err := exportService.Start(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	jobQueue.Start(context.Background())
+	outboxRelay.Start(context.Background())
+	backupService.Start(context.Background())
+
+	hub := services.NewHub(eventStream, services.HubOptions{SendBuffer: cfg.WS_SEND_BUFFER}, logger)
+	hub.Start(context.Background())
+
+	r := routes.NewRouter(db, st, health, exportService, webhookService, eventStream, hub, cfg, logger)
+	grpcServer := rpc.NewServer(
+		services.NewUserService(repositories.NewUserRepository(db)),
+		services.NewPostService(repositories.NewPostRepository(db)),
+		middlewares.NewAPIKeys(cfg.API_KEYS, repositories.NewAPIKeyRepository(db)),
+		st, cfg, logger,
+	)
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -91,6 +141,9 @@ func main() {
 		Handler: r,
 		Addr:    fmt.Sprintf(":%s", cfg.PORT),
 	}
+	// Shutdown waits for responses to end, which event streams never do
+	// on their own.
+	srv.RegisterOnShutdown(eventStream.Close)
 
 	errChan := make(chan error)
 	log.Printf("Server started on port :%s", cfg.PORT)
@@ -100,18 +153,64 @@ func main() {
 		}
 	}()
 
+	grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.GRPC_PORT))
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("gRPC server started on port :%s", cfg.GRPC_PORT)
+	go func() {
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			errChan <- err
+		}
+	}()
+
 	select {
 	case <-errChan:
 	case <-ctx.Done():
 		stop()
-		log.Println("Server shutting down in 5s")
+		log.Printf("Server shutting down: draining for %s, then waiting up to %s for requests in flight",
+			cfg.SHUTDOWN_DRAIN_DELAY, shutdownTimeout)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Fail readiness first so load balancers drain traffic away from this
+	// instance before the listener is closed.
+	health.SetReady(false)
+	time.Sleep(cfg.SHUTDOWN_DRAIN_DELAY)
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatal(err)
 	}
+	// GracefulStop waits for calls in flight; cut them off once the
+	// shutdown deadline passes.
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+	select {
+	case <-grpcStopped:
+	case <-ctx.Done():
+		grpcServer.Stop()
+	}
+	// Stop background work after the server so requests still being
+	// drained can record events and enqueue jobs.
+	if err := hub.Shutdown(ctx); err != nil {
+		log.Fatal(err)
+	}
+	if err := outboxRelay.Shutdown(ctx); err != nil {
+		log.Fatal(err)
+	}
+	if err := jobQueue.Shutdown(ctx); err != nil {
+		log.Fatal(err)
+	}
+	if err := exportService.Shutdown(ctx); err != nil {
+		log.Fatal(err)
+	}
+	if err := backupService.Shutdown(ctx); err != nil {
+		log.Fatal(err)
+	}
 
 	log.Println("Server shut down successfully")
 }
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/princecee/lema-ai/client"
+	"github.com/princecee/lema-ai/internal/db/models"
+)
+
+// apiBackend goes through the HTTP API, for servers whose database is not
+// reachable from where lemactl runs. The API has no search, so searches
+// page through the records and filter them here.
+type apiBackend struct {
+	client *client.Client
+}
+
+func newAPIBackend(baseURL, apiKey string) (*apiBackend, error) {
+	var opts []client.Option
+	if apiKey != "" {
+		opts = append(opts, client.WithAPIKey(apiKey))
+	}
+	c, err := client.New(baseURL, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &apiBackend{c}, nil
+}
+
+func (b *apiBackend) ListUsers(ctx context.Context, page, limit int) ([]*models.User, error) {
+	result, err := b.client.ListUsers(ctx, page, limit)
+	if err != nil {
+		return nil, err
+	}
+	return result.Users, nil
+}
+
+func (b *apiBackend) SearchUsers(ctx context.Context, query string, limit int) ([]*models.User, error) {
+	users := []*models.User{}
+	it := b.client.Users(ctx, 100)
+	for len(users) < limit && it.Next() {
+		u := it.User()
+		if contains(u.Name, query) || contains(u.Username, query) || contains(u.Email, query) {
+			users = append(users, u)
+		}
+	}
+	return users, it.Err()
+}
+
+func (b *apiBackend) CreateUser(ctx context.Context, u *models.User) error {
+	created, err := b.client.CreateUser(ctx, &client.CreateUserInput{
+		Name:     u.Name,
+		Username: u.Username,
+		Email:    u.Email,
+		Phone:    u.Phone,
+		Address: client.AddressInput{
+			Street:  u.Address.Street,
+			City:    u.Address.City,
+			State:   u.Address.State,
+			Zipcode: u.Address.Zipcode,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	*u = *created
+	return nil
+}
+
+func (b *apiBackend) DeleteUser(ctx context.Context, userId string) error {
+	return errDatabaseOnly
+}
+
+func (b *apiBackend) ListPosts(ctx context.Context, userId string) ([]*models.Post, error) {
+	return b.client.ListPosts(ctx, userId)
+}
+
+func (b *apiBackend) SearchPosts(ctx context.Context, query, userId string, limit int) ([]*models.Post, error) {
+	if userId == "" {
+		return nil, errors.New("posts can only be searched by user through the API, set -user")
+	}
+
+	all, err := b.client.ListPosts(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	posts := []*models.Post{}
+	for _, p := range all {
+		if len(posts) == limit {
+			break
+		}
+		if contains(p.Title, query) || contains(p.Body, query) {
+			posts = append(posts, p)
+		}
+	}
+	return posts, nil
+}
+
+func (b *apiBackend) CreatePost(ctx context.Context, p *models.Post) error {
+	created, err := b.client.CreatePost(ctx, &client.CreatePostInput{
+		Title:  p.Title,
+		Body:   p.Body,
+		UserID: p.UserID,
+	})
+	if err != nil {
+		return err
+	}
+	*p = *created
+	return nil
+}
+
+func (b *apiBackend) DeletePost(ctx context.Context, postId string) error {
+	return b.client.DeletePost(ctx, postId)
+}
+
+func (b *apiBackend) Seed(ctx context.Context, users, posts int) error {
+	return errDatabaseOnly
+}
+
+func (b *apiBackend) CreateExport(ctx context.Context, e *models.Export) error {
+	created, err := b.client.CreateExport(ctx, &client.CreateExportInput{
+		Entity:  e.Entity,
+		Format:  e.Format,
+		Filters: e.Filters,
+	})
+	if err != nil {
+		return err
+	}
+	*e = *created
+	return nil
+}
+
+func (b *apiBackend) GetExport(ctx context.Context, exportId string) (*models.Export, error) {
+	return b.client.GetExport(ctx, exportId)
+}
+
+func (b *apiBackend) DownloadExport(ctx context.Context, exportId string) (io.ReadCloser, error) {
+	return b.client.DownloadExport(ctx, exportId)
+}
+
+// Stats reports what the API exposes counts of.
+func (b *apiBackend) Stats(ctx context.Context) ([]stat, error) {
+	users, err := b.client.CountUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	webhooks, err := b.client.ListWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return []stat{{"users", users}, {"webhooks", int64(len(webhooks))}}, nil
+}
+
+func (b *apiBackend) Backup(ctx context.Context, dir string, keep int) (*models.Backup, error) {
+	return nil, errDatabaseOnly
+}
+
+func (b *apiBackend) CreateAPIKey(ctx context.Context, name, role string) (*models.APIKey, string, error) {
+	return nil, "", errDatabaseOnly
+}
+
+func (b *apiBackend) ListAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	return nil, errDatabaseOnly
+}
+
+func (b *apiBackend) SetAPIKeyRole(ctx context.Context, keyId, role string) error {
+	return errDatabaseOnly
+}
+
+func (b *apiBackend) DeleteAPIKey(ctx context.Context, keyId string) error {
+	return errDatabaseOnly
+}
+
+func (b *apiBackend) Close() error {
+	return nil
+}
+
+// contains reports whether s contains substr, ignoring case.
+func contains(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/princecee/lema-ai/internal/db/models"
+)
+
+// backend runs the commands, either on the database (dbBackend) or
+// through the HTTP API (apiBackend).
+type backend interface {
+	ListUsers(ctx context.Context, page, limit int) ([]*models.User, error)
+	SearchUsers(ctx context.Context, query string, limit int) ([]*models.User, error)
+	CreateUser(ctx context.Context, u *models.User) error
+	DeleteUser(ctx context.Context, userId string) error
+
+	ListPosts(ctx context.Context, userId string) ([]*models.Post, error)
+	SearchPosts(ctx context.Context, query, userId string, limit int) ([]*models.Post, error)
+	CreatePost(ctx context.Context, p *models.Post) error
+	DeletePost(ctx context.Context, postId string) error
+
+	Seed(ctx context.Context, users, posts int) error
+
+	CreateExport(ctx context.Context, e *models.Export) error
+	GetExport(ctx context.Context, exportId string) (*models.Export, error)
+	DownloadExport(ctx context.Context, exportId string) (io.ReadCloser, error)
+
+	Stats(ctx context.Context) ([]stat, error)
+
+	Backup(ctx context.Context, dir string, keep int) (*models.Backup, error)
+
+	CreateAPIKey(ctx context.Context, name, role string) (*models.APIKey, string, error)
+	ListAPIKeys(ctx context.Context) ([]*models.APIKey, error)
+	SetAPIKeyRole(ctx context.Context, keyId, role string) error
+	DeleteAPIKey(ctx context.Context, keyId string) error
+
+	Close() error
+}
+
+// stat is a row of the stats command.
+type stat struct {
+	Name  string `json:"name"`
+	Value int64  `json:"value"`
+}
+
+// errDatabaseOnly is returned by the commands the API has no endpoint for.
+var errDatabaseOnly = errors.New("this command needs direct database access, run it without -api")
@@ -0,0 +1,638 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/internal/services"
+	"github.com/princecee/lema-ai/pkg/validator"
+)
+
+type command func(a *app, ctx context.Context, args []string) error
+
+// commands maps "command subcommand", or "command" alone, to its
+// implementation.
+var commands = map[string]command{
+	"users list":       (*app).listUsers,
+	"users search":     (*app).searchUsers,
+	"users create":     (*app).createUser,
+	"users delete":     (*app).deleteUser,
+	"posts list":       (*app).listPosts,
+	"posts search":     (*app).searchPosts,
+	"posts create":     (*app).createPost,
+	"posts delete":     (*app).deletePost,
+	"seed":             (*app).seed,
+	"apikeys generate": (*app).generateAPIKey,
+	"apikeys create":   (*app).createAPIKey,
+	"apikeys list":     (*app).listAPIKeys,
+	"apikeys delete":   (*app).deleteAPIKey,
+	"roles list":       (*app).listRoles,
+	"roles set":        (*app).setRole,
+	"exports create":   (*app).createExport,
+	"exports get":      (*app).getExport,
+	"exports download": (*app).downloadExport,
+	"stats":            (*app).stats,
+	"backup":           (*app).backup,
+	"backup list":      (*app).listBackups,
+	"restore":          (*app).restore,
+}
+
+func (a *app) run(ctx context.Context, args []string) error {
+	if len(args) > 1 {
+		if cmd, ok := commands[args[0]+" "+args[1]]; ok {
+			return cmd(a, ctx, args[2:])
+		}
+	}
+	if cmd, ok := commands[args[0]]; ok {
+		return cmd(a, ctx, args[1:])
+	}
+	return usageError("unknown command %q", strings.Join(args, " "))
+}
+
+// parse parses the flags of a command. Errors are reported by the caller
+// rather than printed by the flag set.
+func parse(flags *flag.FlagSet, args []string) error {
+	flags.SetOutput(io.Discard)
+	if err := flags.Parse(args); err != nil {
+		return usageError("%s: %v", flags.Name(), err)
+	}
+	return nil
+}
+
+// parseID parses the flags of a command taking a single ID argument.
+func parseID(name string, args []string) (string, error) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	if err := parse(flags, args); err != nil {
+		return "", err
+	}
+	if flags.NArg() != 1 {
+		return "", usageError("%s takes an ID", name)
+	}
+	return flags.Arg(0), nil
+}
+
+func (a *app) listUsers(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("users list", flag.ContinueOnError)
+	page := flags.Int("page", 1, "Page to list")
+	limit := flags.Int("limit", 10, "Users per page")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if *page < 1 || *limit < 1 {
+		return usageError("users list: page and limit must be positive")
+	}
+
+	b, err := a.backend()
+	if err != nil {
+		return err
+	}
+	users, err := b.ListUsers(ctx, *page, *limit)
+	if err != nil {
+		return err
+	}
+	return a.out.print(users)
+}
+
+func (a *app) searchUsers(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("users search", flag.ContinueOnError)
+	limit := flags.Int("limit", 20, "Maximum number of users")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	query := joinArgs(flags)
+	if query == "" {
+		return usageError("users search takes a query")
+	}
+
+	b, err := a.backend()
+	if err != nil {
+		return err
+	}
+	users, err := b.SearchUsers(ctx, query, *limit)
+	if err != nil {
+		return err
+	}
+	return a.out.print(users)
+}
+
+func (a *app) createUser(ctx context.Context, args []string) error {
+	user := &models.User{}
+	flags := flag.NewFlagSet("users create", flag.ContinueOnError)
+	flags.StringVar(&user.Name, "name", "", "Name")
+	flags.StringVar(&user.Username, "username", "", "Username")
+	flags.StringVar(&user.Email, "email", "", "Email address")
+	flags.StringVar(&user.Phone, "phone", "", "Phone number")
+	flags.StringVar(&user.Address.Street, "street", "", "Street of the address")
+	flags.StringVar(&user.Address.City, "city", "", "City of the address")
+	flags.StringVar(&user.Address.State, "state", "", "State of the address")
+	flags.StringVar(&user.Address.Zipcode, "zipcode", "", "ZIP code of the address")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if err := validator.ValidateData(ctx, user); err != nil {
+		return err
+	}
+
+	b, err := a.backend()
+	if err != nil {
+		return err
+	}
+	if err := b.CreateUser(ctx, user); err != nil {
+		return err
+	}
+	return a.out.print(user)
+}
+
+func (a *app) deleteUser(ctx context.Context, args []string) error {
+	userId, err := parseID("users delete", args)
+	if err != nil {
+		return err
+	}
+
+	b, err := a.backend()
+	if err != nil {
+		return err
+	}
+	return b.DeleteUser(ctx, userId)
+}
+
+func (a *app) listPosts(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("posts list", flag.ContinueOnError)
+	userId := flags.String("user", "", "ID of the user whose posts to list")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if *userId == "" {
+		return usageError("posts list takes -user")
+	}
+
+	b, err := a.backend()
+	if err != nil {
+		return err
+	}
+	posts, err := b.ListPosts(ctx, *userId)
+	if err != nil {
+		return err
+	}
+	return a.out.print(posts)
+}
+
+func (a *app) searchPosts(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("posts search", flag.ContinueOnError)
+	userId := flags.String("user", "", "Only search the posts of this user")
+	limit := flags.Int("limit", 20, "Maximum number of posts")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	query := joinArgs(flags)
+	if query == "" {
+		return usageError("posts search takes a query")
+	}
+
+	b, err := a.backend()
+	if err != nil {
+		return err
+	}
+	posts, err := b.SearchPosts(ctx, query, *userId, *limit)
+	if err != nil {
+		return err
+	}
+	return a.out.print(posts)
+}
+
+// postData holds the fields of a new post, checked like the API does.
+type postData struct {
+	Title  string `json:"title" mod:"trim" validate:"required,notblank,max=200,nobannedwords"`
+	Body   string `json:"body" mod:"trim" validate:"required,notblank,max=10000,nobannedwords"`
+	UserID string `json:"userId" validate:"required,uuid"`
+}
+
+func (a *app) createPost(ctx context.Context, args []string) error {
+	data := &postData{}
+	flags := flag.NewFlagSet("posts create", flag.ContinueOnError)
+	flags.StringVar(&data.UserID, "user", "", "ID of the author")
+	flags.StringVar(&data.Title, "title", "", "Title")
+	flags.StringVar(&data.Body, "body", "", "Body")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if err := validator.ValidateData(ctx, data); err != nil {
+		return err
+	}
+
+	b, err := a.backend()
+	if err != nil {
+		return err
+	}
+	post := &models.Post{Title: data.Title, Body: data.Body, UserID: data.UserID}
+	if err := b.CreatePost(ctx, post); err != nil {
+		return err
+	}
+	return a.out.print(post)
+}
+
+func (a *app) deletePost(ctx context.Context, args []string) error {
+	postId, err := parseID("posts delete", args)
+	if err != nil {
+		return err
+	}
+
+	b, err := a.backend()
+	if err != nil {
+		return err
+	}
+	return b.DeletePost(ctx, postId)
+}
+
+func (a *app) seed(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	users := flags.Int("users", 50, "Number of users to create")
+	posts := flags.Int("posts", 5, "Number of posts to create for each user")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	b, err := a.backend()
+	if err != nil {
+		return err
+	}
+	return b.Seed(ctx, *users, *posts)
+}
+
+// apiKey is a row of the apikeys commands. Identity is the ID the server
+// logs and rate limits the key's requests by. The keys of API_KEYS are
+// admins and have no ID; stored keys are only shown in full once created.
+type apiKey struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Role      string     `json:"role"`
+	Identity  string     `json:"identity"`
+	Key       string     `json:"key"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+// generateAPIKey prints a new random key for API_KEYS. It takes effect once
+// added there and the server restarted.
+func (a *app) generateAPIKey(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("apikeys generate", flag.ContinueOnError)
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	key, err := services.GenerateAPIKey()
+	if err != nil {
+		return err
+	}
+	return a.out.print(apiKey{Role: models.RoleAdmin, Identity: identify(key), Key: key})
+}
+
+// apiKeyData holds the fields of a new stored key.
+type apiKeyData struct {
+	Name string `json:"name" mod:"trim" validate:"required,notblank,max=100"`
+	Role string `json:"role" validate:"required,oneof=admin writer reader"`
+}
+
+// createAPIKey stores a new key, which the server accepts right away, and
+// prints it. The key cannot be shown again.
+func (a *app) createAPIKey(ctx context.Context, args []string) error {
+	data := &apiKeyData{}
+	flags := flag.NewFlagSet("apikeys create", flag.ContinueOnError)
+	flags.StringVar(&data.Name, "name", "", "Name telling what the key is for")
+	flags.StringVar(&data.Role, "role", models.RoleWriter, "Role of the key: admin, writer or reader")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if err := validator.ValidateData(ctx, data); err != nil {
+		return err
+	}
+
+	b, err := a.backend()
+	if err != nil {
+		return err
+	}
+	k, key, err := b.CreateAPIKey(ctx, data.Name, data.Role)
+	if err != nil {
+		return err
+	}
+	return a.out.print(apiKey{ID: k.ID, Name: k.Name, Role: k.Role, Identity: identify(key), Key: key, CreatedAt: &k.CreatedAt})
+}
+
+// listAPIKeys prints the keys of API_KEYS and the stored keys, masked, so
+// a key seen in the logs can be traced back to its entry.
+func (a *app) listAPIKeys(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("apikeys list", flag.ContinueOnError)
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	b, err := a.backend()
+	if err != nil {
+		return err
+	}
+	stored, err := b.ListAPIKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]apiKey, 0, len(a.cfg.API_KEYS)+len(stored))
+	for _, key := range a.cfg.API_KEYS {
+		keys = append(keys, apiKey{Name: "API_KEYS", Role: models.RoleAdmin, Identity: identify(key), Key: maskKey(key)})
+	}
+	for _, k := range stored {
+		keys = append(keys, apiKey{
+			ID:        k.ID,
+			Name:      k.Name,
+			Role:      k.Role,
+			Identity:  k.Hash[:16],
+			Key:       k.Prefix + strings.Repeat("*", 8),
+			CreatedAt: &k.CreatedAt,
+		})
+	}
+	return a.out.print(keys)
+}
+
+// deleteAPIKey revokes a stored key. The keys of API_KEYS are removed from
+// the config instead.
+func (a *app) deleteAPIKey(ctx context.Context, args []string) error {
+	keyId, err := parseID("apikeys delete", args)
+	if err != nil {
+		return err
+	}
+
+	b, err := a.backend()
+	if err != nil {
+		return err
+	}
+	return b.DeleteAPIKey(ctx, keyId)
+}
+
+// identify returns the identity of the caller holding key, the start of
+// the hash the server stores it under.
+func identify(key string) string {
+	return models.HashAPIKey(key)[:16]
+}
+
+// maskKey keeps the first and last four characters of long keys.
+func maskKey(key string) string {
+	if len(key) < 16 {
+		return strings.Repeat("*", len(key))
+	}
+	return key[:4] + strings.Repeat("*", len(key)-8) + key[len(key)-4:]
+}
+
+// role is a row of roles list.
+type role struct {
+	Name   string `json:"name"`
+	Allows string `json:"allows"`
+}
+
+var roles = []role{
+	{models.RoleAdmin, "everything, including managing webhooks"},
+	{models.RoleWriter, "reads and writes, except managing webhooks"},
+	{models.RoleReader, "reads only"},
+}
+
+func (a *app) listRoles(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("roles list", flag.ContinueOnError)
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	return a.out.print(roles)
+}
+
+// setRole changes the role of a stored key.
+func (a *app) setRole(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("roles set", flag.ContinueOnError)
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return usageError("roles set takes a key ID and a role")
+	}
+	keyId, role := flags.Arg(0), flags.Arg(1)
+	if !slices.Contains(models.Roles, role) {
+		return usageError("roles set: unknown role %q, use %s", role, strings.Join(models.Roles, ", "))
+	}
+
+	b, err := a.backend()
+	if err != nil {
+		return err
+	}
+	return b.SetAPIKeyRole(ctx, keyId, role)
+}
+
+// exportData holds the fields of a new export, checked like the API does.
+type exportData struct {
+	Entity string `json:"entity" validate:"required,oneof=users posts"`
+	Format string `json:"format" validate:"required,oneof=json csv ndjson"`
+	UserID string `json:"user_id" validate:"omitempty,uuid"`
+	State  string `json:"state" mod:"trim" validate:"omitempty,max=100"`
+}
+
+// exportPollInterval is how often exports create -wait checks the export.
+const exportPollInterval = time.Second
+
+func (a *app) createExport(ctx context.Context, args []string) error {
+	data := &exportData{}
+	flags := flag.NewFlagSet("exports create", flag.ContinueOnError)
+	flags.StringVar(&data.Entity, "entity", "", "What to export: users or posts")
+	flags.StringVar(&data.Format, "format", models.ExportFormatCSV, "Format of the file: json, csv or ndjson")
+	flags.StringVar(&data.UserID, "user", "", "Only export the posts of this user")
+	flags.StringVar(&data.State, "state", "", "Only export the users of this address state")
+	wait := flags.Bool("wait", false, "Wait for the export to complete")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if err := validator.ValidateData(ctx, data); err != nil {
+		return err
+	}
+
+	b, err := a.backend()
+	if err != nil {
+		return err
+	}
+	export := &models.Export{
+		Entity:  data.Entity,
+		Format:  data.Format,
+		Filters: models.ExportFilters{UserID: data.UserID, State: data.State},
+	}
+	if err := b.CreateExport(ctx, export); err != nil {
+		return err
+	}
+
+	// Exports are written by the server's job queue, so waiting needs a
+	// server running on the database.
+	for *wait && (export.Status == models.ExportStatusPending || export.Status == models.ExportStatusRunning) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(exportPollInterval):
+		}
+
+		if export, err = b.GetExport(ctx, export.ID); err != nil {
+			return err
+		}
+	}
+	if export.Status == models.ExportStatusFailed {
+		return fmt.Errorf("export %s failed: %s", export.ID, export.Error)
+	}
+	return a.out.print(export)
+}
+
+func (a *app) getExport(ctx context.Context, args []string) error {
+	exportId, err := parseID("exports get", args)
+	if err != nil {
+		return err
+	}
+
+	b, err := a.backend()
+	if err != nil {
+		return err
+	}
+	export, err := b.GetExport(ctx, exportId)
+	if err != nil {
+		return err
+	}
+	return a.out.print(export)
+}
+
+// downloadExport writes the gzip file of a completed export, by default to
+// a file named after the export.
+func (a *app) downloadExport(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("exports download", flag.ContinueOnError)
+	path := flags.String("o", "", `File to write, "-" for the standard output`)
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usageError("exports download takes an ID")
+	}
+	exportId := flags.Arg(0)
+
+	b, err := a.backend()
+	if err != nil {
+		return err
+	}
+	export, err := b.GetExport(ctx, exportId)
+	if err != nil {
+		return err
+	}
+	file, err := b.DownloadExport(ctx, exportId)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if *path == "-" {
+		_, err = io.Copy(a.stdout, file)
+		return err
+	}
+	if *path == "" {
+		*path = fmt.Sprintf("%s-%s.%s.gz", export.Entity, export.ID, export.Format)
+	}
+
+	f, err := os.OpenFile(*path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, file); err != nil {
+		f.Close()
+		os.Remove(*path)
+		return err
+	}
+	return f.Close()
+}
+
+func (a *app) stats(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	b, err := a.backend()
+	if err != nil {
+		return err
+	}
+	stats, err := b.Stats(ctx)
+	if err != nil {
+		return err
+	}
+	return a.out.print(stats)
+}
+
+func (a *app) backup(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	dir := flags.String("dir", a.cfg.BACKUP_DIR, "Directory of the backups")
+	keep := flags.Int("keep", a.cfg.BACKUP_RETENTION, "Number of backups to keep, 0 for all")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	b, err := a.backend()
+	if err != nil {
+		return err
+	}
+	backup, err := b.Backup(ctx, *dir, *keep)
+	if err != nil {
+		return err
+	}
+	return a.out.print(backup)
+}
+
+func (a *app) listBackups(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("backup list", flag.ContinueOnError)
+	dir := flags.String("dir", a.cfg.BACKUP_DIR, "Directory of the backups")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	backups, err := database.NewBackups(nil, *dir, 0).List()
+	if err != nil {
+		return err
+	}
+	return a.out.print(backups)
+}
+
+// restore replaces the database with a backup, given by path, by name in
+// the backup directory or as "latest". The server must be stopped first.
+func (a *app) restore(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	dir := flags.String("dir", a.cfg.BACKUP_DIR, "Directory of the backups")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usageError("restore takes a backup")
+	}
+	if a.apiURL != "" {
+		return errDatabaseOnly
+	}
+
+	path := flags.Arg(0)
+	switch {
+	case path == "latest":
+		backups, err := database.NewBackups(nil, *dir, 0).List()
+		if err != nil {
+			return err
+		}
+		if len(backups) == 0 {
+			return fmt.Errorf("no backups in %s", *dir)
+		}
+		path = filepath.Join(*dir, backups[0].Name)
+	case !strings.ContainsRune(path, filepath.Separator):
+		if _, err := os.Stat(path); err != nil {
+			path = filepath.Join(*dir, path)
+		}
+	}
+
+	return database.Restore(ctx, path, a.cfg.DSN)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/princecee/lema-ai/config"
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/internal/db/repositories"
+	"github.com/princecee/lema-ai/internal/db/seeder"
+	"github.com/princecee/lema-ai/internal/services"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/pagination"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+)
+
+// dbBackend works on the database directly. Writes go through the
+// repositories, so they record the same outbox events as the API and a
+// running server delivers them.
+type dbBackend struct {
+	db        *gorm.DB
+	userRepo  *repositories.UserRepository
+	postRepo  *repositories.PostRepository
+	statsRepo *repositories.StatsRepository
+	users     *services.UserService
+	exports   *services.ExportService
+	apiKeys   *services.APIKeyService
+}
+
+func newDBBackend(cfg *config.Config) (*dbBackend, error) {
+	db := database.GetDBConn(cfg.DSN, cfg.REPLICA_DSNS, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
+	if err := database.Migrate(db); err != nil {
+		return nil, err
+	}
+
+	// Exports are enqueued here and written by the job queue of a server
+	// using the same database.
+	jobQueue := services.NewJobQueue(repositories.NewJobRepository(db), services.JobOptions{
+		MaxAttempts: cfg.JOB_MAX_ATTEMPTS,
+	}, zerolog.Nop())
+	exports := services.NewExportService(database.NewTransactor(db), repositories.NewExportRepository(db), jobQueue, services.ExportOptions{
+		Dir: cfg.EXPORT_DIR,
+		TTL: cfg.EXPORT_TTL,
+	}, zerolog.Nop())
+
+	userRepo := repositories.NewUserRepository(db)
+	return &dbBackend{
+		db:        db,
+		userRepo:  userRepo,
+		postRepo:  repositories.NewPostRepository(db),
+		statsRepo: repositories.NewStatsRepository(db),
+		users:     services.NewUserService(userRepo),
+		exports:   exports,
+		apiKeys:   services.NewAPIKeyService(repositories.NewAPIKeyRepository(db)),
+	}, nil
+}
+
+func (b *dbBackend) ListUsers(ctx context.Context, page, limit int) ([]*models.User, error) {
+	result, err := b.userRepo.GetUsers(ctx, pagination.PaginationQuery{Page: &page, Limit: &limit})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return []*models.User{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result.Users, nil
+}
+
+func (b *dbBackend) SearchUsers(ctx context.Context, query string, limit int) ([]*models.User, error) {
+	return b.userRepo.SearchUsers(ctx, query, limit)
+}
+
+func (b *dbBackend) CreateUser(ctx context.Context, u *models.User) error {
+	u.ID = uuid.NewString()
+	u.Address.ID = uuid.NewString()
+	return b.users.CreateUser(u)
+}
+
+func (b *dbBackend) DeleteUser(ctx context.Context, userId string) error {
+	err := b.userRepo.DeleteUser(ctx, userId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apperror.ErrNotFound
+	}
+	return err
+}
+
+func (b *dbBackend) ListPosts(ctx context.Context, userId string) ([]*models.Post, error) {
+	return b.postRepo.GetPosts(ctx, userId)
+}
+
+func (b *dbBackend) SearchPosts(ctx context.Context, query, userId string, limit int) ([]*models.Post, error) {
+	return b.postRepo.SearchPosts(ctx, query, userId, limit)
+}
+
+func (b *dbBackend) CreatePost(ctx context.Context, p *models.Post) error {
+	if _, err := b.userRepo.GetUser(ctx, p.UserID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.ErrNotFound
+		}
+		return err
+	}
+
+	p.ID = uuid.NewString()
+	p.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	return b.postRepo.CreatePost(ctx, p)
+}
+
+func (b *dbBackend) DeletePost(ctx context.Context, postId string) error {
+	err := b.postRepo.DeletePost(ctx, postId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apperror.ErrNotFound
+	}
+	return err
+}
+
+func (b *dbBackend) Seed(ctx context.Context, users, posts int) error {
+	return seeder.Seed(ctx, b.userRepo, b.postRepo, users, posts)
+}
+
+func (b *dbBackend) CreateExport(ctx context.Context, e *models.Export) error {
+	return b.exports.CreateExport(ctx, e)
+}
+
+func (b *dbBackend) GetExport(ctx context.Context, exportId string) (*models.Export, error) {
+	return b.exports.GetExport(ctx, exportId)
+}
+
+// DownloadExport opens the export file, which must be on this machine.
+func (b *dbBackend) DownloadExport(ctx context.Context, exportId string) (io.ReadCloser, error) {
+	_, f, err := b.exports.OpenExport(ctx, exportId)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (b *dbBackend) Stats(ctx context.Context) ([]stat, error) {
+	s, err := b.statsRepo.GetStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := []stat{
+		{"users", s.Users},
+		{"posts", s.Posts},
+		{"webhooks", s.Webhooks},
+		{"outbox.pending", s.PendingEvents},
+	}
+	stats = append(stats, statusStats("exports", s.Exports)...)
+	stats = append(stats, statusStats("jobs", s.Jobs)...)
+	return stats, nil
+}
+
+// statusStats lists counts by status, sorted by status.
+func statusStats(prefix string, counts map[string]int64) []stat {
+	stats := make([]stat, 0, len(counts))
+	for status, count := range counts {
+		stats = append(stats, stat{prefix + "." + status, count})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
+
+func (b *dbBackend) Backup(ctx context.Context, dir string, keep int) (*models.Backup, error) {
+	return database.NewBackups(b.db, dir, keep).Create(ctx)
+}
+
+func (b *dbBackend) CreateAPIKey(ctx context.Context, name, role string) (*models.APIKey, string, error) {
+	return b.apiKeys.CreateAPIKey(ctx, name, role)
+}
+
+func (b *dbBackend) ListAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	return b.apiKeys.GetAPIKeys(ctx)
+}
+
+func (b *dbBackend) SetAPIKeyRole(ctx context.Context, keyId, role string) error {
+	return b.apiKeys.SetRole(ctx, keyId, role)
+}
+
+func (b *dbBackend) DeleteAPIKey(ctx context.Context, keyId string) error {
+	return b.apiKeys.DeleteAPIKey(ctx, keyId)
+}
+
+func (b *dbBackend) Close() error {
+	return database.Close(b.db)
+}
@@ -0,0 +1,181 @@
+// Command lemactl administers the service from the command line. It works
+// on the database directly, or through the HTTP API when -api is set.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/joho/godotenv"
+	"github.com/princecee/lema-ai/client"
+	"github.com/princecee/lema-ai/config"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/validator"
+)
+
+const usage = `Usage: lemactl [flags] <command> <subcommand> [flags] [arguments]
+
+Commands:
+  users list [-page n] [-limit n]
+  users search [-limit n] <query>
+  users create -name ... -username ... -email ... -phone ... -street ... -city ... -state ... -zipcode ...
+  users delete <id>                   database only
+  posts list -user <id>
+  posts search [-user <id>] [-limit n] <query>
+  posts create -user <id> -title ... -body ...
+  posts delete <id>
+  seed [-users n] [-posts n]          database only
+  apikeys generate                    prints a key to add to API_KEYS
+  apikeys create -name ... [-role r]  database only
+  apikeys list                        database only
+  apikeys delete <id>                 database only
+  roles list
+  roles set <key id> <role>           database only
+  exports create -entity users|posts [-format json|csv|ndjson] [-user <id>] [-state ...] [-wait]
+  exports get <id>
+  exports download [-o file] <id>
+  stats
+  backup [-dir dir] [-keep n]         database only
+  backup list [-dir dir]
+  restore [-dir dir] <file|name|latest>   database only, with the server stopped
+
+Flags:
+`
+
+// app holds what the commands share. The backend is opened by the first
+// command needing it, so commands such as apikeys generate run without a
+// database.
+type app struct {
+	cfg    *config.Config
+	apiURL string
+	apiKey string
+	out    *printer
+	stdout io.Writer
+	b      backend
+}
+
+func (a *app) backend() (backend, error) {
+	if a.b != nil {
+		return a.b, nil
+	}
+
+	var err error
+	if a.apiURL != "" {
+		a.b, err = newAPIBackend(a.apiURL, a.apiKey)
+	} else {
+		a.b, err = newDBBackend(a.cfg)
+	}
+	return a.b, err
+}
+
+func (a *app) close() {
+	if a.b != nil {
+		a.b.Close()
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command of args and returns the exit status.
+func run(args []string, stdout, stderr io.Writer) int {
+	var env, configFile, dsn, apiURL, apiKey, output string
+
+	flags := flag.NewFlagSet("lemactl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&env, "env", config.EnvDevelopment, "Environment whose config to use")
+	flags.StringVar(&configFile, "config", ".env", "Path to a config file in .env format")
+	flags.StringVar(&dsn, "db", "", "Database to work on, defaults to the DSN of the config")
+	flags.StringVar(&apiURL, "api", os.Getenv("LEMACTL_API"), "Base URL of the API to go through instead of the database")
+	flags.StringVar(&apiKey, "api-key", os.Getenv("LEMACTL_API_KEY"), "API key sent to the API")
+	flags.StringVar(&output, "output", outputTable, "Output format: table, json or csv")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	if env != "test" {
+		// Variables already set in the environment take precedence over
+		// the config file.
+		_ = godotenv.Load(configFile)
+	}
+	cfg := config.NewConfig(env, "silent")
+	if dsn != "" {
+		cfg.DSN = dsn
+	}
+	validator.SetBannedWords(cfg.BANNED_WORDS)
+
+	out, err := newPrinter(stdout, output)
+	if err != nil {
+		fmt.Fprintln(stderr, "lemactl:", err)
+		return 2
+	}
+
+	a := &app{cfg: cfg, apiURL: apiURL, apiKey: apiKey, out: out, stdout: stdout}
+	defer a.close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := a.run(ctx, flags.Args()); err != nil {
+		printError(stderr, err)
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+// printError reports err with the violations of invalid input, one per
+// line.
+func printError(w io.Writer, err error) {
+	fmt.Fprintln(w, "lemactl:", err)
+
+	var appErr *apperror.AppError
+	var clientErr *client.Error
+	switch {
+	case errors.As(err, &appErr):
+		for _, v := range appErr.Violations {
+			fmt.Fprintf(w, "  %s: %s\n", v.Field, v.Message)
+		}
+	case errors.As(err, &clientErr):
+		fields := make([]string, 0, len(clientErr.Violations))
+		for field := range clientErr.Violations {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			fmt.Fprintf(w, "  %s: %s\n", field, clientErr.Violations[field])
+		}
+	}
+}
+
+// errUsage marks errors in the command line.
+var errUsage = errors.New("usage")
+
+func usageError(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", errUsage, fmt.Sprintf(format, args...))
+}
+
+// joinArgs joins the positional arguments of a command, so queries need no
+// quoting.
+func joinArgs(flags *flag.FlagSet) string {
+	return strings.Join(flags.Args(), " ")
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommands(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "lemactl.db")
+
+	lemactl := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		args = append([]string{"-env", "test", "-db", dsn, "-output", "json"}, args...)
+		code := run(args, &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	code, _, stderr := lemactl("seed", "-users", "3", "-posts", "2")
+	require.Equal(t, 0, code, stderr)
+
+	code, stdout, _ := lemactl("users", "create",
+		"-name", "Ada Lovelace", "-username", "ada", "-email", "ada@example.com", "-phone", "+15550000001",
+		"-street", "1 Main St", "-city", "Austin", "-state", "TX", "-zipcode", "73301")
+	require.Equal(t, 0, code)
+	var user models.User
+	require.NoError(t, json.Unmarshal([]byte(stdout), &user))
+
+	code, _, stderr = lemactl("users", "create", "-name", "Ada")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "email:")
+
+	code, stdout, _ = lemactl("users", "search", "LOVELACE")
+	require.Equal(t, 0, code)
+	var users []models.User
+	require.NoError(t, json.Unmarshal([]byte(stdout), &users))
+	require.Len(t, users, 1)
+	assert.Equal(t, user.ID, users[0].ID)
+
+	code, _, _ = lemactl("posts", "create", "-user", user.ID, "-title", "Notes", "-body", "On the engine")
+	require.Equal(t, 0, code)
+
+	code, stdout, _ = lemactl("posts", "search", "-user", user.ID, "engine")
+	require.Equal(t, 0, code)
+	var posts []models.Post
+	require.NoError(t, json.Unmarshal([]byte(stdout), &posts))
+	require.Len(t, posts, 1)
+
+	code, stdout, _ = lemactl("stats")
+	require.Equal(t, 0, code)
+	var stats []stat
+	require.NoError(t, json.Unmarshal([]byte(stdout), &stats))
+	assert.Contains(t, stats, stat{"users", 4})
+	assert.Contains(t, stats, stat{"posts", 7})
+
+	code, _, _ = lemactl("users", "delete", user.ID)
+	require.Equal(t, 0, code)
+	code, stdout, _ = lemactl("posts", "list", "-user", user.ID)
+	require.Equal(t, 0, code)
+	assert.JSONEq(t, "[]", stdout)
+
+	code, _, stderr = lemactl("users", "delete", user.ID)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "not found")
+
+	code, _, _ = lemactl("users", "frobnicate")
+	assert.Equal(t, 2, code)
+}
+
+func TestAPIKeys(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "lemactl.db")
+
+	lemactl := func(args ...string) (int, string) {
+		var stdout, stderr bytes.Buffer
+		args = append([]string{"-env", "test", "-db", dsn, "-output", "json"}, args...)
+		code := run(args, &stdout, &stderr)
+		return code, stdout.String() + stderr.String()
+	}
+	list := func() []apiKey {
+		code, out := lemactl("apikeys", "list")
+		require.Equal(t, 0, code, out)
+		var keys []apiKey
+		require.NoError(t, json.Unmarshal([]byte(out), &keys))
+		return keys
+	}
+
+	code, out := lemactl("apikeys", "create", "-name", "dashboard", "-role", "reader")
+	require.Equal(t, 0, code, out)
+	var created apiKey
+	require.NoError(t, json.Unmarshal([]byte(out), &created))
+	assert.Len(t, created.Key, 64)
+	assert.Equal(t, models.RoleReader, created.Role)
+
+	keys := list()
+	require.Len(t, keys, 1)
+	assert.Equal(t, created.ID, keys[0].ID)
+	assert.Equal(t, created.Identity, keys[0].Identity)
+	assert.NotContains(t, keys[0].Key, created.Key[8:])
+
+	code, out = lemactl("roles", "set", created.ID, "writer")
+	require.Equal(t, 0, code, out)
+	assert.Equal(t, models.RoleWriter, list()[0].Role)
+
+	code, _ = lemactl("roles", "set", created.ID, "owner")
+	assert.Equal(t, 2, code)
+	code, _ = lemactl("apikeys", "create", "-name", "dashboard", "-role", "owner")
+	assert.Equal(t, 1, code)
+
+	code, out = lemactl("apikeys", "delete", created.ID)
+	require.Equal(t, 0, code, out)
+	assert.Empty(t, list())
+
+	code, out = lemactl("apikeys", "delete", created.ID)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, out, "not found")
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "lemactl.db")
+	backups := filepath.Join(dir, "backups")
+
+	lemactl := func(args ...string) (int, string) {
+		var stdout, stderr bytes.Buffer
+		args = append([]string{"-env", "test", "-db", dsn, "-output", "json"}, args...)
+		code := run(args, &stdout, &stderr)
+		return code, stdout.String() + stderr.String()
+	}
+	userCount := func() int64 {
+		_, out := lemactl("stats")
+		var stats []stat
+		require.NoError(t, json.Unmarshal([]byte(out), &stats))
+		return stats[0].Value
+	}
+
+	code, out := lemactl("seed", "-users", "2", "-posts", "0")
+	require.Equal(t, 0, code, out)
+
+	code, out = lemactl("backup", "-dir", backups)
+	require.Equal(t, 0, code, out)
+	var backup models.Backup
+	require.NoError(t, json.Unmarshal([]byte(out), &backup))
+
+	code, out = lemactl("backup", "list", "-dir", backups)
+	require.Equal(t, 0, code, out)
+	assert.Contains(t, out, backup.Name)
+
+	code, out = lemactl("seed", "-users", "1", "-posts", "0")
+	require.Equal(t, 0, code, out)
+	require.Equal(t, int64(3), userCount())
+
+	code, out = lemactl("restore", "-dir", backups, "latest")
+	require.Equal(t, 0, code, out)
+	assert.Equal(t, int64(2), userCount())
+}
+
+func TestCSVOutput(t *testing.T) {
+	var out bytes.Buffer
+	p, err := newPrinter(&out, outputCSV)
+	require.NoError(t, err)
+
+	require.NoError(t, p.print([]stat{{"users", 3}, {"posts", 9}}))
+	assert.Equal(t, "name,value\nusers,3\nposts,9\n", out.String())
+
+	_, err = newPrinter(&out, "yaml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	stdcsv "encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/princecee/lema-ai/pkg/csv"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputCSV   = "csv"
+)
+
+// printer writes command results in the output format. Results are structs
+// or slices of structs; tables and CSV files have a column per field, named
+// like in exports, e.g. "address.city".
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func newPrinter(w io.Writer, format string) (*printer, error) {
+	switch format {
+	case outputTable, outputJSON, outputCSV:
+		return &printer{w, format}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q, use table, json or csv", format)
+	}
+}
+
+func (p *printer) print(v any) error {
+	if p.format == outputJSON {
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	rows := reflect.ValueOf(v)
+	if rows.Kind() != reflect.Slice {
+		rows = reflect.Append(reflect.MakeSlice(reflect.SliceOf(rows.Type()), 0, 1), rows)
+	}
+	columns := csv.Columns(rows.Type().Elem())
+
+	if p.format == outputCSV {
+		w := stdcsv.NewWriter(p.w)
+		w.Write(csv.Header(columns))
+		for i := 0; i < rows.Len(); i++ {
+			w.Write(csv.Record(columns, rows.Index(i).Interface()))
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	w := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	header := csv.Header(columns)
+	for i, name := range header {
+		header[i] = strings.ToUpper(name)
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for i := 0; i < rows.Len(); i++ {
+		record := csv.Record(columns, rows.Index(i).Interface())
+		for j, cell := range record {
+			// Keep each row on one line.
+			record[j] = strings.Join(strings.Fields(cell), " ")
+		}
+		fmt.Fprintln(w, strings.Join(record, "\t"))
+	}
+	return w.Flush()
+}
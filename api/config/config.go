@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -14,25 +15,162 @@ const (
 	EnvProduction  = "production"
 )
 
+// RateLimit is a request budget of Requests per Window. A zero value
+// disables rate limiting.
+type RateLimit struct {
+	Requests int
+	Window   time.Duration
+}
+
 type Config struct {
 	PORT              string
+	GRPC_PORT         string
 	DSN               string
 	ENV               string
 	LOG_LEVEL         string
 	MAX_IDLE_CONNS    int
 	MAX_OPEN_CONNS    int
 	CONN_MAX_LIFETIME time.Duration
+
+	REPLICA_DSNS            []string
+	READ_YOUR_WRITES_WINDOW time.Duration
+
+	READINESS_TIMEOUT    time.Duration
+	SHUTDOWN_DRAIN_DELAY time.Duration
+
+	RATE_LIMIT_USERS_READ  RateLimit
+	RATE_LIMIT_POSTS_READ  RateLimit
+	RATE_LIMIT_USERS_WRITE RateLimit
+	RATE_LIMIT_POSTS_WRITE RateLimit
+	RATE_LIMIT_IMPORT      RateLimit
+	RATE_LIMIT_EXPORTS     RateLimit
+	RATE_LIMIT_WEBHOOKS    RateLimit
+	RATE_LIMIT_GRAPHQL     RateLimit
+
+	STORE_BACKEND      string
+	REDIS_URL          string
+	RESPONSE_CACHE_TTL time.Duration
+
+	CORS_ALLOWED_ORIGINS   []string
+	CORS_ALLOWED_METHODS   []string
+	CORS_ALLOWED_HEADERS   []string
+	CORS_EXPOSED_HEADERS   []string
+	CORS_ALLOW_CREDENTIALS bool
+	CORS_MAX_AGE           time.Duration
+
+	IDEMPOTENCY_TTL time.Duration
+
+	BANNED_WORDS []string
+
+	IMPORT_MAX_BYTES  int
+	IMPORT_BATCH_SIZE int
+
+	EXPORT_DIR              string
+	EXPORT_TTL              time.Duration
+	EXPORT_CLEANUP_INTERVAL time.Duration
+
+	BACKUP_DIR       string
+	BACKUP_INTERVAL  time.Duration
+	BACKUP_RETENTION int
+
+	JOB_WORKERS       int
+	JOB_POLL_INTERVAL time.Duration
+	JOB_LOCK_TIMEOUT  time.Duration
+	JOB_MAX_ATTEMPTS  int
+	JOB_BACKOFF_BASE  time.Duration
+	JOB_BACKOFF_MAX   time.Duration
+
+	WEBHOOK_TIMEOUT                time.Duration
+	WEBHOOK_ALLOW_PRIVATE_NETWORKS bool
+
+	OUTBOX_SINKS         []string
+	OUTBOX_POLL_INTERVAL time.Duration
+	OUTBOX_BATCH_SIZE    int
+	OUTBOX_RETENTION     time.Duration
+	BROKER_BACKEND       string
+
+	STREAM_BUFFER_SIZE        int
+	STREAM_HEARTBEAT_INTERVAL time.Duration
+
+	API_KEYS         []string
+	WS_SEND_BUFFER   int
+	WS_WRITE_TIMEOUT time.Duration
 }
 
 func NewConfig(env, loglevel string) *Config {
 	return &Config{
 		PORT:              getEnv("PORT", "5001"),
+		GRPC_PORT:         getEnv("GRPC_PORT", "5002"),
 		DSN:               getEnv("DSN", "data.db"),
 		ENV:               getEnv("ENV", env),
 		LOG_LEVEL:         getEnv("LOG_LEVEL", loglevel),
 		MAX_IDLE_CONNS:    getEnvAsInt("MAX_IDLE_CONNS", 10),
 		MAX_OPEN_CONNS:    getEnvAsInt("MAX_OPEN_CONNS", 100),
 		CONN_MAX_LIFETIME: getEnvAsDuration("CONN_MAX_LIFETIME", time.Hour),
+
+		REPLICA_DSNS:            getEnvAsSlice("REPLICA_DSNS", nil),
+		READ_YOUR_WRITES_WINDOW: getEnvAsDuration("READ_YOUR_WRITES_WINDOW", 5*time.Second),
+
+		READINESS_TIMEOUT:    getEnvAsDuration("READINESS_TIMEOUT", 2*time.Second),
+		SHUTDOWN_DRAIN_DELAY: getEnvAsDuration("SHUTDOWN_DRAIN_DELAY", 5*time.Second),
+
+		RATE_LIMIT_USERS_READ:  getEnvAsRateLimit("RATE_LIMIT_USERS_READ", RateLimit{100, time.Minute}),
+		RATE_LIMIT_POSTS_READ:  getEnvAsRateLimit("RATE_LIMIT_POSTS_READ", RateLimit{100, time.Minute}),
+		RATE_LIMIT_USERS_WRITE: getEnvAsRateLimit("RATE_LIMIT_USERS_WRITE", RateLimit{20, time.Minute}),
+		RATE_LIMIT_POSTS_WRITE: getEnvAsRateLimit("RATE_LIMIT_POSTS_WRITE", RateLimit{20, time.Minute}),
+		RATE_LIMIT_IMPORT:      getEnvAsRateLimit("RATE_LIMIT_IMPORT", RateLimit{5, time.Minute}),
+		RATE_LIMIT_EXPORTS:     getEnvAsRateLimit("RATE_LIMIT_EXPORTS", RateLimit{10, time.Minute}),
+		RATE_LIMIT_WEBHOOKS:    getEnvAsRateLimit("RATE_LIMIT_WEBHOOKS", RateLimit{20, time.Minute}),
+		RATE_LIMIT_GRAPHQL:     getEnvAsRateLimit("RATE_LIMIT_GRAPHQL", RateLimit{60, time.Minute}),
+
+		STORE_BACKEND:      getEnv("STORE_BACKEND", "memory"),
+		REDIS_URL:          getEnv("REDIS_URL", "redis://localhost:6379/0"),
+		RESPONSE_CACHE_TTL: getEnvAsDuration("RESPONSE_CACHE_TTL", 0),
+
+		CORS_ALLOWED_ORIGINS:   getEnvAsSlice("CORS_ALLOWED_ORIGINS", nil),
+		CORS_ALLOWED_METHODS:   getEnvAsSlice("CORS_ALLOWED_METHODS", []string{"HEAD", "GET", "POST", "PUT", "PATCH", "DELETE"}),
+		CORS_ALLOWED_HEADERS:   getEnvAsSlice("CORS_ALLOWED_HEADERS", []string{"Accept", "Authorization", "Content-Type", "Idempotency-Key", "X-API-Key"}),
+		CORS_EXPOSED_HEADERS:   getEnvAsSlice("CORS_EXPOSED_HEADERS", []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After", "Idempotent-Replayed", "Link", "X-Total-Count", "X-Cache"}),
+		CORS_ALLOW_CREDENTIALS: getEnvAsBool("CORS_ALLOW_CREDENTIALS", false),
+		CORS_MAX_AGE:           getEnvAsDuration("CORS_MAX_AGE", 10*time.Minute),
+
+		IDEMPOTENCY_TTL: getEnvAsDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+
+		BANNED_WORDS: getEnvAsSlice("BANNED_WORDS", nil),
+
+		IMPORT_MAX_BYTES:  getEnvAsInt("IMPORT_MAX_BYTES", 50<<20),
+		IMPORT_BATCH_SIZE: getEnvAsInt("IMPORT_BATCH_SIZE", 100),
+
+		EXPORT_DIR:              getEnv("EXPORT_DIR", "exports"),
+		EXPORT_TTL:              getEnvAsDuration("EXPORT_TTL", 24*time.Hour),
+		EXPORT_CLEANUP_INTERVAL: getEnvAsDuration("EXPORT_CLEANUP_INTERVAL", 10*time.Minute),
+
+		BACKUP_DIR:       getEnv("BACKUP_DIR", "backups"),
+		BACKUP_INTERVAL:  getEnvAsDuration("BACKUP_INTERVAL", 0),
+		BACKUP_RETENTION: getEnvAsInt("BACKUP_RETENTION", 7),
+
+		JOB_WORKERS:       getEnvAsInt("JOB_WORKERS", 4),
+		JOB_POLL_INTERVAL: getEnvAsDuration("JOB_POLL_INTERVAL", time.Second),
+		JOB_LOCK_TIMEOUT:  getEnvAsDuration("JOB_LOCK_TIMEOUT", 10*time.Minute),
+		JOB_MAX_ATTEMPTS:  getEnvAsInt("JOB_MAX_ATTEMPTS", 5),
+		JOB_BACKOFF_BASE:  getEnvAsDuration("JOB_BACKOFF_BASE", 5*time.Second),
+		JOB_BACKOFF_MAX:   getEnvAsDuration("JOB_BACKOFF_MAX", time.Hour),
+
+		WEBHOOK_TIMEOUT:                getEnvAsDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		WEBHOOK_ALLOW_PRIVATE_NETWORKS: getEnvAsBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+
+		OUTBOX_SINKS:         getEnvAsSlice("OUTBOX_SINKS", []string{"webhook"}),
+		OUTBOX_POLL_INTERVAL: getEnvAsDuration("OUTBOX_POLL_INTERVAL", time.Second),
+		OUTBOX_BATCH_SIZE:    getEnvAsInt("OUTBOX_BATCH_SIZE", 100),
+		OUTBOX_RETENTION:     getEnvAsDuration("OUTBOX_RETENTION", 7*24*time.Hour),
+		BROKER_BACKEND:       getEnv("BROKER_BACKEND", "memory"),
+
+		STREAM_BUFFER_SIZE:        getEnvAsInt("STREAM_BUFFER_SIZE", 1000),
+		STREAM_HEARTBEAT_INTERVAL: getEnvAsDuration("STREAM_HEARTBEAT_INTERVAL", 15*time.Second),
+
+		API_KEYS:         getEnvAsSlice("API_KEYS", nil),
+		WS_SEND_BUFFER:   getEnvAsInt("WS_SEND_BUFFER", 64),
+		WS_WRITE_TIMEOUT: getEnvAsDuration("WS_WRITE_TIMEOUT", 10*time.Second),
 	}
 }
 
@@ -61,6 +199,53 @@ func getEnvAsDuration(name string, defaultVal time.Duration) time.Duration {
 	return defaultVal
 }
 
+func getEnvAsBool(name string, defaultVal bool) bool {
+	if value, ok := os.LookupEnv(name); ok {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultVal
+}
+
+// getEnvAsSlice parses a comma separated list, ignoring empty items.
+func getEnvAsSlice(name string, defaultVal []string) []string {
+	if value, ok := os.LookupEnv(name); ok {
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	return defaultVal
+}
+
+// getEnvAsRateLimit parses limits written as "<requests>/<window>", e.g.
+// "100/1m". Use "0/1m" to disable a limit.
+func getEnvAsRateLimit(name string, defaultVal RateLimit) RateLimit {
+	if value, ok := os.LookupEnv(name); ok {
+		requests, window, found := strings.Cut(value, "/")
+		if !found {
+			return defaultVal
+		}
+
+		r, err := strconv.Atoi(strings.TrimSpace(requests))
+		if err != nil || r < 0 {
+			return defaultVal
+		}
+
+		w, err := time.ParseDuration(strings.TrimSpace(window))
+		if err != nil || w <= 0 {
+			return defaultVal
+		}
+
+		return RateLimit{Requests: r, Window: w}
+	}
+	return defaultVal
+}
+
 func GetLoggerLevel(loglevel string) zerolog.Level {
 	switch loglevel {
 	case zerolog.LevelTraceValue:
@@ -0,0 +1,265 @@
+package database
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/princecee/lema-ai/internal/db/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
+)
+
+const (
+	backupPrefix     = "lema-"
+	backupSuffix     = ".db.gz"
+	backupTimeLayout = "20060102T150405Z"
+)
+
+// Backups takes backups of a database into a directory, keeping the last
+// keep of them. Snapshots are taken with VACUUM INTO, which reads the
+// database in a single transaction, so the server keeps serving meanwhile.
+type Backups struct {
+	db   *gorm.DB
+	dir  string
+	keep int
+}
+
+// NewBackups returns backups of db in dir. All backups are kept when keep
+// is zero.
+func NewBackups(db *gorm.DB, dir string, keep int) *Backups {
+	return &Backups{db, dir, keep}
+}
+
+// Create takes a backup and deletes the backups beyond the last keep.
+func (b *Backups) Create(ctx context.Context) (*models.Backup, error) {
+	if err := os.MkdirAll(b.dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	name := backupPrefix + now.Format(backupTimeLayout) + backupSuffix
+	path := filepath.Join(b.dir, name)
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("backup %s already exists", name)
+	}
+
+	// VACUUM INTO fails if the file exists, e.g. left by an interrupted
+	// backup.
+	snapshot := filepath.Join(b.dir, "."+name+".snapshot")
+	os.Remove(snapshot)
+	defer os.Remove(snapshot)
+	// Run on a reader, so writes go on meanwhile.
+	if err := b.db.WithContext(ctx).Clauses(dbresolver.Read).Exec("VACUUM INTO ?", snapshot).Error; err != nil {
+		return nil, err
+	}
+
+	size, err := compressFile(snapshot, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := b.Prune(); err != nil {
+		return nil, err
+	}
+	return &models.Backup{Name: name, Size: size, CreatedAt: now}, nil
+}
+
+// compressFile writes src to dst with gzip, renaming it into place once
+// complete, and returns the size of dst.
+func compressFile(src, dst string) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	tmp := dst + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp)
+
+	gz := gzip.NewWriter(out)
+	_, err = io.Copy(gz, in)
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := os.Stat(tmp)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), os.Rename(tmp, dst)
+}
+
+// List returns the backups of the directory, newest first.
+func (b *Backups) List() ([]*models.Backup, error) {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []*models.Backup{}, nil
+		}
+		return nil, err
+	}
+
+	backups := []*models.Backup{}
+	for _, entry := range entries {
+		stamp, ok := strings.CutPrefix(entry.Name(), backupPrefix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		stamp, ok = strings.CutSuffix(stamp, backupSuffix)
+		if !ok {
+			continue
+		}
+		createdAt, err := time.Parse(backupTimeLayout, stamp)
+		if err != nil {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, &models.Backup{Name: entry.Name(), Size: info.Size(), CreatedAt: createdAt})
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+	return backups, nil
+}
+
+// Prune deletes the backups beyond the last keep and returns them.
+func (b *Backups) Prune() ([]*models.Backup, error) {
+	backups, err := b.List()
+	if err != nil || b.keep <= 0 || len(backups) <= b.keep {
+		return nil, err
+	}
+
+	pruned := backups[b.keep:]
+	for _, backup := range pruned {
+		if err := os.Remove(filepath.Join(b.dir, backup.Name)); err != nil {
+			return nil, err
+		}
+	}
+	return pruned, nil
+}
+
+// Restore replaces the database file of dsn with a backup, compressed or
+// not. The backup is checked with PRAGMA integrity_check first, so a
+// damaged backup leaves the database untouched. Nothing may have the
+// database open while it is restored.
+func Restore(ctx context.Context, backupPath, dsn string) error {
+	path, err := dsnPath(dsn)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".restore"
+	defer os.Remove(tmp)
+	if err := decompressFile(backupPath, tmp); err != nil {
+		return err
+	}
+	if err := checkFile(ctx, tmp); err != nil {
+		return fmt.Errorf("backup %s is damaged: %w", backupPath, err)
+	}
+
+	// The journal of the replaced database must not be applied to the
+	// restored one.
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		if err := os.Remove(path + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(tmp, path)
+}
+
+// dsnPath returns the file of a SQLite DSN such as "data.db" or
+// "file:data.db?_busy_timeout=5000".
+func dsnPath(dsn string) (string, error) {
+	path, query, _ := strings.Cut(strings.TrimPrefix(dsn, "file:"), "?")
+	if path == "" || path == ":memory:" || strings.Contains(query, "mode=memory") {
+		return "", fmt.Errorf("database %q is not a file", dsn)
+	}
+	return path, nil
+}
+
+// decompressFile copies src to dst, decompressing it if it is gzip
+// compressed.
+func decompressFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var r io.Reader = in
+	if strings.HasSuffix(src, ".gz") {
+		gz, err := gzip.NewReader(in)
+		if err != nil {
+			return fmt.Errorf("backup %s is not a gzip file: %w", src, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, r)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// checkFile runs the integrity check on the database file at path.
+func checkFile(ctx context.Context, path string) error {
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	return CheckIntegrity(ctx, db)
+}
+
+// CheckIntegrity runs PRAGMA integrity_check and reports the problems it
+// finds.
+func CheckIntegrity(ctx context.Context, db *gorm.DB) error {
+	var results []string
+	if err := db.WithContext(ctx).Raw("PRAGMA integrity_check").Scan(&results).Error; err != nil {
+		return fmt.Errorf("integrity check failed: %w", err)
+	}
+	if len(results) == 1 && results[0] == "ok" {
+		return nil
+	}
+	return fmt.Errorf("integrity check failed: %s", strings.Join(results, "; "))
+}
@@ -0,0 +1,95 @@
+package database_test
+
+import (
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func openDB(t *testing.T, path string) *gorm.DB {
+	db := database.GetDBConn(path, nil, 1, 1, 0, "silent")
+	t.Cleanup(func() { database.Close(db) })
+	require.NoError(t, database.Migrate(db))
+	return db
+}
+
+func countUsers(t *testing.T, db *gorm.DB) int64 {
+	var count int64
+	require.NoError(t, db.Model(&models.User{}).Count(&count).Error)
+	return count
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	backupDir := filepath.Join(dir, "backups")
+
+	db := openDB(t, filepath.Join(dir, "data.db"))
+	require.NoError(t, db.Create(&models.User{ID: "1", Name: "Ada", Email: "ada@example.com", Username: "ada", Phone: "+15550000001"}).Error)
+
+	backups := database.NewBackups(db, backupDir, 2)
+	backup, err := backups.Create(ctx)
+	require.NoError(t, err)
+	assert.Positive(t, backup.Size)
+	assert.FileExists(t, filepath.Join(backupDir, backup.Name))
+
+	require.NoError(t, db.Create(&models.User{ID: "2", Name: "Bob", Email: "bob@example.com", Username: "bob", Phone: "+15550000002"}).Error)
+
+	// The backup restores the database as it was when it was taken.
+	target := filepath.Join(dir, "restored.db")
+	require.NoError(t, database.Restore(ctx, filepath.Join(backupDir, backup.Name), target))
+	restored := openDB(t, target)
+	assert.Equal(t, int64(1), countUsers(t, restored))
+	assert.NoError(t, database.CheckIntegrity(ctx, restored))
+
+	t.Run("Damaged backups are not restored", func(t *testing.T) {
+		damaged := filepath.Join(dir, "damaged.db.gz")
+		f, err := os.Create(damaged)
+		require.NoError(t, err)
+		gz := gzip.NewWriter(f)
+		gz.Write([]byte("not a database"))
+		gz.Close()
+		f.Close()
+
+		target := filepath.Join(dir, "untouched.db")
+		require.NoError(t, os.WriteFile(target, []byte("current"), 0o644))
+
+		err = database.Restore(ctx, damaged, target)
+		assert.ErrorContains(t, err, "is damaged")
+		content, _ := os.ReadFile(target)
+		assert.Equal(t, "current", string(content))
+	})
+
+	t.Run("Old backups are pruned", func(t *testing.T) {
+		for _, stamp := range []string{"20200101T000000Z", "20210101T000000Z"} {
+			require.NoError(t, os.WriteFile(filepath.Join(backupDir, "lema-"+stamp+".db.gz"), nil, 0o644))
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(backupDir, "notes.txt"), nil, 0o644))
+
+		pruned, err := backups.Prune()
+		require.NoError(t, err)
+		require.Len(t, pruned, 1)
+		assert.Equal(t, "lema-20200101T000000Z.db.gz", pruned[0].Name)
+
+		list, err := backups.List()
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		assert.Equal(t, backup.Name, list[0].Name)
+		assert.Equal(t, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), list[1].CreatedAt)
+		assert.FileExists(t, filepath.Join(backupDir, "notes.txt"))
+	})
+
+	t.Run("In-memory databases cannot be restored", func(t *testing.T) {
+		err := database.Restore(ctx, filepath.Join(backupDir, backup.Name), "file::memory:?cache=shared")
+		assert.Error(t, err)
+	})
+}
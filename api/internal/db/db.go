@@ -1,30 +1,338 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/princecee/lema-ai/internal/db/models"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
 )
 
-func GetDBConn(dsn string, maxIdleConn, maxOpenConn int, maxConnLifetime time.Duration, loglevel string) *gorm.DB {
-	level := getLoglevel(loglevel)
-	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+// Models lists every model managed by the application's migrations.
+var Models = []any{&models.User{}, &models.Address{}, &models.Post{}, &models.Export{}, &models.Job{},
+	&models.Webhook{}, &models.WebhookDelivery{}, &models.WebhookAttempt{}, &models.OutboxEvent{}, &models.APIKey{}}
+
+// busyTimeout is how long a connection waits for the lock held by another
+// before failing with "database is locked".
+const busyTimeout = 5 * time.Second
+
+// GetDBConn opens the database of dsn: PostgreSQL for postgres:// and
+// postgresql:// URLs, SQLite otherwise. PostgreSQL is used through a pool of
+// up to maxOpenConn connections.
+//
+// SQLite allows a single writer at a time, so writes go through a pool of
+// one connection, whose transactions take the write lock up front, while
+// reads go to a separate pool of up to maxOpenConn connections. File
+// databases use WAL, so reads are not blocked by the writer. In-memory
+// databases have no WAL and go through the single writer connection only.
+//
+// The reads made with ReplicaConn go to the replicas of replicaDSNs, if
+// any, while they are healthy. Each replica is opened read-only with the
+// driver of its DSN, so a PostgreSQL primary can have streaming replicas
+// and a SQLite one copies kept in sync by a tool such as LiteFS. GetDBConn
+// panics on the DSN of another database.
+func GetDBConn(dsn string, replicaDSNs []string, maxIdleConn, maxOpenConn int, maxConnLifetime time.Duration, loglevel string) *gorm.DB {
+	config := &gorm.Config{
 		TranslateError: true,
-		Logger:         logger.Default.LogMode(level),
-	})
+		Logger:         logger.Default.LogMode(getLoglevel(loglevel)),
+		// Opening the database queries it already. The config is shared
+		// with the pools of dbresolver, whose replicas may be down.
+		DisableAutomaticPing: true,
+	}
+
+	resolver := &dbresolver.DBResolver{}
+	var db *gorm.DB
+	var reader gorm.Dialector
+	useResolver := false
+	if isPostgresDSN(dsn) {
+		db = open(postgres.Open(dsn), config)
+		sqlDB := pool(db)
+		sqlDB.SetMaxIdleConns(maxIdleConn)
+		sqlDB.SetMaxOpenConns(maxOpenConn)
+		sqlDB.SetConnMaxLifetime(maxConnLifetime)
+		reader = dialector(postgresDriver, sqlDB)
+	} else {
+		db, reader, useResolver = openSQLite(dsn, config, resolver, maxIdleConn, maxOpenConn, maxConnLifetime)
+	}
+
+	if len(replicaDSNs) > 0 {
+		replicas := &replicas{logger: db.Logger}
+		dialectors := make([]gorm.Dialector, 0, len(replicaDSNs)+1)
+		for _, replicaDSN := range replicaDSNs {
+			driverName := replicaDriver(replicaDSN)
+			pool := openPool(driverName, readOnly(driverName, replicaDSN), maxIdleConn, maxOpenConn, maxConnLifetime)
+			replicas.pools = append(replicas.pools, pool)
+			dialectors = append(dialectors, dialector(driverName, pool))
+		}
+		// The primary comes last, see replicas.Resolve.
+		dialectors = append(dialectors, reader)
+		resolver.Register(dbresolver.Config{Replicas: dialectors, Policy: replicas}, replicaResolver)
+
+		if err := db.Use(replicas); err != nil {
+			panic(err)
+		}
+		replicas.start()
+		useResolver = true
+	}
+
+	if useResolver {
+		if err := db.Use(resolver); err != nil {
+			panic(err)
+		}
+	}
+	return db
+}
+
+// openSQLite opens the SQLite database of dsn and returns the dialector of
+// the pool it is read from. Unless that is the writer connection itself,
+// the pool is registered with resolver, as reported by the last result.
+func openSQLite(dsn string, config *gorm.Config, resolver *dbresolver.DBResolver, maxIdleConn, maxOpenConn int, maxConnLifetime time.Duration) (*gorm.DB, gorm.Dialector, bool) {
+	memory := isMemoryDSN(dsn)
+
+	writerDSN := dsn
+	if !memory {
+		writerDSN = withParams(dsn, "_txlock", "immediate")
+	}
+
+	db := open(sqlite.Open(withPragmas(writerDSN, memory)), config)
+	sqlDB := pool(db)
+	sqlDB.SetMaxIdleConns(1)
+	sqlDB.SetMaxOpenConns(1)
+
+	// Connections to a shared in-memory database lock whole tables and fail
+	// at once instead of waiting, so an in-memory database is read through
+	// the writer connection too. It is never recycled, as closing the last
+	// connection drops the database.
+	if memory {
+		return db, dialector(sqlite.DriverName, sqlDB), false
+	}
+
+	sqlDB.SetConnMaxLifetime(maxConnLifetime)
+	reader := dialector(sqlite.DriverName, openPool(sqlite.DriverName, withPragmas(dsn, false), maxIdleConn, maxOpenConn, maxConnLifetime))
+	resolver.Register(dbresolver.Config{Replicas: []gorm.Dialector{reader}})
+	return db, reader, true
+}
+
+func open(dialector gorm.Dialector, config *gorm.Config) *gorm.DB {
+	db, err := gorm.Open(dialector, config)
 	if err != nil {
 		panic(err)
 	}
+	return db
+}
 
+func pool(db *gorm.DB) *sql.DB {
 	sqlDB, err := db.DB()
 	if err != nil {
 		panic(err)
 	}
-	sqlDB.SetMaxIdleConns(maxIdleConn)
-	sqlDB.SetMaxOpenConns(maxOpenConn)
-	return db
+	return sqlDB
+}
+
+// postgresDriver is the database/sql driver of PostgreSQL, registered by
+// the pgx package the PostgreSQL dialector uses.
+const postgresDriver = "pgx"
+
+// replicaDriver returns the database/sql driver of the replica of dsn.
+func replicaDriver(dsn string) string {
+	switch {
+	case isPostgresDSN(dsn):
+		return postgresDriver
+	case isSQLiteDSN(dsn):
+		return sqlite.DriverName
+	}
+	panic(fmt.Errorf("replica %q is neither a PostgreSQL nor a SQLite database", redactDSN(dsn)))
+}
+
+// readOnly returns the DSN of a replica, with the parameters that make its
+// connections refuse writes.
+func readOnly(driverName, dsn string) string {
+	if driverName == postgresDriver {
+		return withParams(dsn, "default_transaction_read_only", "on")
+	}
+	return withParams(dsn,
+		"_busy_timeout", strconv.FormatInt(busyTimeout.Milliseconds(), 10),
+		"_query_only", "on",
+	)
+}
+
+// openPool opens a connection pool to the database of dsn.
+func openPool(driverName, dsn string, maxIdleConn, maxOpenConn int, maxConnLifetime time.Duration) *sql.DB {
+	pool, err := sql.Open(driverName, dsn)
+	if err != nil {
+		panic(err)
+	}
+	pool.SetMaxIdleConns(maxIdleConn)
+	pool.SetMaxOpenConns(maxOpenConn)
+	pool.SetConnMaxLifetime(maxConnLifetime)
+	return pool
+}
+
+// poolDialector hands an open pool to dbresolver, which only needs the
+// pool. Unlike the dialector it wraps it does not query the database, so a
+// replica down at startup is left out rather than failing GetDBConn.
+type poolDialector struct {
+	gorm.Dialector
+	pool *sql.DB
+}
+
+func dialector(driverName string, pool *sql.DB) gorm.Dialector {
+	if driverName == postgresDriver {
+		return poolDialector{postgres.New(postgres.Config{Conn: pool}), pool}
+	}
+	return poolDialector{sqlite.Dialector{DriverName: sqlite.DriverName, Conn: pool}, pool}
+}
+
+func (d poolDialector) Initialize(db *gorm.DB) error {
+	db.ConnPool = d.pool
+	return nil
+}
+
+// Close stops the health checks of the replicas and closes the connection
+// pools of db.
+func Close(db *gorm.DB) error {
+	if replicas, ok := db.Config.Plugins[replicasName].(*replicas); ok {
+		replicas.stop()
+	}
+
+	if resolver, ok := db.Config.Plugins[resolverName].(*dbresolver.DBResolver); ok {
+		var errs []error
+		resolver.Call(func(pool gorm.ConnPool) error {
+			if closer, ok := pool.(io.Closer); ok {
+				errs = append(errs, closer.Close())
+			}
+			return nil
+		})
+		return errors.Join(errs...)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+const resolverName = "gorm:db_resolver"
+
+// withPragmas adds to dsn the pragmas the application relies on, unless
+// dsn sets them already.
+func withPragmas(dsn string, memory bool) string {
+	dsn = withParams(dsn,
+		"_busy_timeout", strconv.FormatInt(busyTimeout.Milliseconds(), 10),
+		"_synchronous", "NORMAL",
+		"_foreign_keys", "on",
+	)
+	if !memory {
+		dsn = withParams(dsn, "_journal_mode", "WAL")
+	}
+	return dsn
+}
+
+// withParams adds the key value pairs of params to the query of dsn, unless
+// the query has them already.
+func withParams(dsn string, params ...string) string {
+	_, query, _ := strings.Cut(dsn, "?")
+	values, _ := url.ParseQuery(query)
+	for i := 0; i+1 < len(params); i += 2 {
+		if values.Has(params[i]) {
+			continue
+		}
+		if strings.Contains(dsn, "?") {
+			dsn += "&"
+		} else {
+			dsn += "?"
+		}
+		dsn += params[i] + "=" + params[i+1]
+	}
+	return dsn
+}
+
+// isSQLiteDSN reports whether dsn is a path or a file: URI rather than
+// the URL of another database, such as postgres://.
+func isSQLiteDSN(dsn string) bool {
+	scheme, _, ok := strings.Cut(dsn, "://")
+	return !ok || scheme == "file" || strings.ContainsAny(scheme, "/\\?")
+}
+
+// isPostgresDSN reports whether dsn is a postgres:// or postgresql:// URL.
+func isPostgresDSN(dsn string) bool {
+	scheme, _, _ := strings.Cut(dsn, "://")
+	return scheme == "postgres" || scheme == "postgresql"
+}
+
+// redactDSN drops the credentials of dsn.
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return dsn
+}
+
+// isMemoryDSN reports whether dsn is an in-memory database.
+func isMemoryDSN(dsn string) bool {
+	path, query, _ := strings.Cut(strings.TrimPrefix(dsn, "file:"), "?")
+	values, _ := url.ParseQuery(query)
+	return path == "" || path == ":memory:" || values.Get("mode") == "memory"
+}
+
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(Models...); err != nil {
+		return err
+	}
+
+	// Address used to have posts, which made earlier migrations point
+	// posts.user_id at addresses.id. The constraint fails every post once
+	// foreign keys are enforced.
+	if db.Migrator().HasConstraint(&models.Post{}, "fk_addresses_posts") {
+		return db.Migrator().DropConstraint(&models.Post{}, "fk_addresses_posts")
+	}
+	return nil
+}
+
+// Ping checks that the underlying connection pool can reach the database.
+func Ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.PingContext(ctx)
+}
+
+// CheckMigrations reports an error if any table or column of the
+// registered models is missing from the database.
+func CheckMigrations(ctx context.Context, db *gorm.DB) error {
+	migrator := db.WithContext(ctx).Migrator()
+	for _, model := range Models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+
+		if !migrator.HasTable(model) {
+			return fmt.Errorf("table %s is missing", stmt.Schema.Table)
+		}
+
+		for _, column := range stmt.Schema.DBNames {
+			if !migrator.HasColumn(model, column) {
+				return fmt.Errorf("column %s.%s is missing", stmt.Schema.Table, column)
+			}
+		}
+	}
+
+	return nil
 }
 
 func getLoglevel(level string) logger.LogLevel {
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"time"
+)
+
+// Roles of API keys, from the most to the least allowed. Admins may do
+// anything, writers anything but managing webhooks and readers may only
+// read.
+const (
+	RoleAdmin  = "admin"
+	RoleWriter = "writer"
+	RoleReader = "reader"
+)
+
+var Roles = []string{RoleAdmin, RoleWriter, RoleReader}
+
+// RoleAllows reports whether role grants at least the rights of want.
+// Unknown roles grant nothing.
+func RoleAllows(role, want string) bool {
+	have := slices.Index(Roles, role)
+	return have >= 0 && have <= slices.Index(Roles, want)
+}
+
+// APIKey is a key created with lemactl. Only the hash of the key is kept;
+// Prefix holds its first characters so operators can tell keys apart.
+type APIKey struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null"`
+	Role      string    `json:"role" gorm:"not null"`
+	Hash      string    `json:"-" gorm:"uniqueIndex;not null"`
+	Prefix    string    `json:"prefix" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// HashAPIKey returns the hash under which key is stored.
+func HashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Backup is a gzip compressed snapshot of the database. Backups are files
+// named after the time they were taken, not rows.
+type Backup struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+const (
+	ExportEntityUsers = "users"
+	ExportEntityPosts = "posts"
+
+	ExportFormatJSON   = "json"
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+
+	ExportStatusPending   = "pending"
+	ExportStatusRunning   = "running"
+	ExportStatusCompleted = "completed"
+	ExportStatusFailed    = "failed"
+)
+
+// Export is an asynchronous export of users or posts to a gzip file.
+type Export struct {
+	ID          string        `json:"id" gorm:"primaryKey"`
+	Entity      string        `json:"entity" gorm:"not null"`
+	Format      string        `json:"format" gorm:"not null"`
+	Filters     ExportFilters `json:"filters" gorm:"embedded;embeddedPrefix:filter_"`
+	Status      string        `json:"status" gorm:"index;not null"`
+	Total       int64         `json:"total"`
+	Processed   int64         `json:"processed"`
+	Size        int64         `json:"size"`
+	Error       string        `json:"error,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	CompletedAt *time.Time    `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time    `json:"expires_at,omitempty" gorm:"index"`
+}
+
+// ExportFilters restricts the rows of an export. UserID applies to posts
+// and State, the address state, to users.
+type ExportFilters struct {
+	UserID string `json:"user_id,omitempty"`
+	State  string `json:"state,omitempty"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	// JobStatusDead marks jobs that failed on every attempt. They are kept
+	// for inspection and are not retried.
+	JobStatusDead = "dead"
+)
+
+// ErrJobLockLost is returned when updating a job whose lock expired and
+// which another worker claimed again.
+var ErrJobLockLost = errors.New("job lock lost")
+
+// Job is a unit of background work. Failed jobs are queued again with a
+// later RunAt until MaxAttempts is reached.
+type Job struct {
+	ID          string     `json:"id" gorm:"primaryKey"`
+	Kind        string     `json:"kind" gorm:"index;not null"`
+	Payload     string     `json:"payload" gorm:"type:text"`
+	Status      string     `json:"status" gorm:"index:idx_jobs_status_run_at;not null"`
+	Attempts    int        `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts int        `json:"max_attempts" gorm:"not null"`
+	RunAt       time.Time  `json:"run_at" gorm:"index:idx_jobs_status_run_at;not null"`
+	LockedAt    *time.Time `json:"locked_at,omitempty"`
+	LastError   string     `json:"last_error,omitempty" gorm:"type:text"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Events recorded in the outbox. Webhooks subscribe to them by name.
+const (
+	EventPostCreated = "post.created"
+	EventPostUpdated = "post.updated"
+	EventPostDeleted = "post.deleted"
+	EventUserCreated = "user.created"
+	EventUserUpdated = "user.updated"
+	EventUserDeleted = "user.deleted"
+)
+
+// Events lists every event that can be subscribed to.
+var Events = []string{
+	EventPostCreated, EventPostUpdated, EventPostDeleted,
+	EventUserCreated, EventUserUpdated, EventUserDeleted,
+}
+
+// OutboxEvent is a domain event written in the same transaction as the
+// change it describes, so it exists if and only if the change committed.
+// Payload is the JSON encoding of the changed record.
+type OutboxEvent struct {
+	ID           string     `json:"id" gorm:"primaryKey"`
+	Event        string     `json:"event" gorm:"not null"`
+	Payload      string     `json:"payload" gorm:"type:text;not null"`
+	Attempts     int        `json:"attempts" gorm:"not null;default:0"`
+	LastError    string     `json:"last_error,omitempty" gorm:"type:text"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"index"`
+	DispatchedAt *time.Time `json:"dispatched_at,omitempty" gorm:"index"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox"
+}
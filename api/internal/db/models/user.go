@@ -2,19 +2,18 @@ package models
 
 type User struct {
 	ID       string  `json:"id" gorm:"primaryKey"`
-	Name     string  `json:"name" gorm:"not null"`
-	Email    string  `json:"email" gorm:"unique;not null"`
-	Username string  `json:"username" gorm:"unique;not null"`
-	Phone    string  `json:"phone" gorm:"unique;not null"`
+	Name     string  `json:"name" gorm:"not null" mod:"trim" validate:"required,notblank,max=100"`
+	Email    string  `json:"email" gorm:"unique;not null" mod:"trim,lower" validate:"required,deliverable_email"`
+	Username string  `json:"username" gorm:"unique;not null" mod:"trim" validate:"required,notblank,max=50,nobannedwords"`
+	Phone    string  `json:"phone" gorm:"unique;not null" mod:"e164" validate:"required,e164"`
 	Address  Address `json:"address" gorm:"foreignKey:UserID"`
 }
 
 type Address struct {
 	ID      string `json:"id" gorm:"primaryKey"`
-	Street  string `json:"street" gorm:"not null"`
-	City    string `json:"city" gorm:"not null"`
-	State   string `json:"state" gorm:"not null"`
-	Zipcode string `json:"zipcode" gorm:"not null"`
+	Street  string `json:"street" gorm:"not null" mod:"trim" validate:"required,notblank,max=200"`
+	City    string `json:"city" gorm:"not null" mod:"trim" validate:"required,notblank,max=100"`
+	State   string `json:"state" gorm:"not null" mod:"trim" validate:"required,notblank,max=100"`
+	Zipcode string `json:"zipcode" gorm:"not null" mod:"trim" validate:"required,us_zipcode"`
 	UserID  string `json:"user_id" gorm:"index;not null"`
-	Posts   []Post `json:"posts,omitempty" gorm:"foreignKey:UserID"`
 }
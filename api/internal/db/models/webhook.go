@@ -0,0 +1,49 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook subscribes a URL to a set of events. Deliveries are signed with
+// Secret, which is only shown when the webhook is created.
+type Webhook struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	URL       string    `json:"url" gorm:"not null"`
+	Events    []string  `json:"events" gorm:"serializer:json;not null"`
+	Secret    string    `json:"-" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookDelivery is an event sent to a webhook. Payload is kept so the
+// delivery can be sent again unchanged. A webhook gets one delivery per
+// event.
+type WebhookDelivery struct {
+	ID          string           `json:"id" gorm:"primaryKey"`
+	WebhookID   string           `json:"webhook_id" gorm:"uniqueIndex:idx_webhook_deliveries_event;not null"`
+	EventID     string           `json:"event_id" gorm:"uniqueIndex:idx_webhook_deliveries_event;not null"`
+	Event       string           `json:"event" gorm:"not null"`
+	Payload     json.RawMessage  `json:"payload" gorm:"type:text;not null"`
+	Status      string           `json:"status" gorm:"not null"`
+	CreatedAt   time.Time        `json:"created_at"`
+	DeliveredAt *time.Time       `json:"delivered_at,omitempty"`
+	Attempts    []WebhookAttempt `json:"attempts,omitempty" gorm:"foreignKey:DeliveryID"`
+}
+
+// WebhookAttempt logs one request of a delivery. StatusCode is 0 when no
+// response was received.
+type WebhookAttempt struct {
+	ID         string    `json:"id" gorm:"primaryKey"`
+	DeliveryID string    `json:"delivery_id" gorm:"index;not null"`
+	StatusCode int       `json:"status_code"`
+	Response   string    `json:"response,omitempty" gorm:"type:text"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
+)
+
+const (
+	// replicaResolver names the resolver routing reads to the replicas.
+	replicaResolver = "replicas"
+	replicasName    = "lema:replicas"
+
+	// replicaCheckInterval is how often the replicas are pinged. A replica
+	// is left out until it answers again.
+	replicaCheckInterval = 5 * time.Second
+	replicaPingTimeout   = time.Second
+)
+
+type readPrimaryCtxKey struct{}
+
+// ReadPrimary returns a context whose reads go to the primary. Requests
+// made right after a write use it to read their own writes, which the
+// replicas may not have applied yet.
+func ReadPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readPrimaryCtxKey{}, true)
+}
+
+// ReadsPrimary reports whether ctx comes from ReadPrimary.
+func ReadsPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(readPrimaryCtxKey{}).(bool)
+	return primary
+}
+
+// ReplicaConn is like Conn, but reads from a replica unless ctx is in a
+// transaction or comes from ReadPrimary. Reads go to the primary when no
+// replica is configured or healthy. Like Conn, it can be built on more than
+// once.
+func ReplicaConn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if _, ok := ctx.Value(txCtxKey{}).(*gorm.DB); ok {
+		return Conn(ctx, db)
+	}
+	if ReadsPrimary(ctx) {
+		return Conn(ctx, db)
+	}
+	return db.WithContext(ctx).Clauses(dbresolver.Use(replicaResolver)).Session(&gorm.Session{})
+}
+
+// replicas is the dbresolver policy picking the replica of a read. It pings
+// the replicas every replicaCheckInterval and takes turns between those that
+// answered. It is registered as a plugin, so Close can stop the checks.
+type replicas struct {
+	pools   []*sql.DB
+	healthy []atomic.Bool
+	next    atomic.Uint64
+	logger  logger.Interface
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func (r *replicas) Name() string {
+	return replicasName
+}
+
+func (r *replicas) Initialize(*gorm.DB) error {
+	return nil
+}
+
+// Resolve returns a healthy replica, or the primary when there is none.
+// The pools are those of the replicas followed by the primary, which also
+// makes dbresolver call the policy when there is a single replica.
+func (r *replicas) Resolve(pools []gorm.ConnPool) gorm.ConnPool {
+	n := uint64(len(r.pools))
+	next := r.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if j := (next + i) % n; r.healthy[j].Load() {
+			return pools[j]
+		}
+	}
+	return pools[n]
+}
+
+// start checks the replicas, then keeps checking them until stop.
+func (r *replicas) start() {
+	r.healthy = make([]atomic.Bool, len(r.pools))
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.check(ctx)
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(replicaCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.check(ctx)
+			}
+		}
+	}()
+}
+
+func (r *replicas) stop() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+func (r *replicas) check(ctx context.Context) {
+	for i, pool := range r.pools {
+		pingCtx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+		err := pool.PingContext(pingCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		healthy := err == nil
+		if r.healthy[i].Swap(healthy) == healthy {
+			continue
+		}
+		if healthy {
+			r.logger.Info(ctx, "replica %d is healthy", i)
+		} else {
+			r.logger.Warn(ctx, "replica %d is unhealthy, reading from the primary instead: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"context"
+
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) *APIKeyRepository {
+	return &APIKeyRepository{db}
+}
+
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, k *models.APIKey) error {
+	return database.Conn(ctx, r.db).Create(k).Error
+}
+
+func (r *APIKeyRepository) GetAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	var keys []*models.APIKey
+	err := database.Conn(ctx, r.db).Order("created_at").Find(&keys).Error
+	return keys, err
+}
+
+func (r *APIKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	var k models.APIKey
+	err := database.Conn(ctx, r.db).Where("hash = ?", hash).First(&k).Error
+	return &k, err
+}
+
+func (r *APIKeyRepository) UpdateAPIKeyRole(ctx context.Context, keyId, role string) error {
+	result := database.Conn(ctx, r.db).Model(&models.APIKey{}).Where("id = ?", keyId).Update("role", role)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) DeleteAPIKey(ctx context.Context, keyId string) error {
+	result := database.Conn(ctx, r.db).Where("id = ?", keyId).Delete(&models.APIKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"gorm.io/gorm"
+)
+
+type ExportRepository struct {
+	db *gorm.DB
+}
+
+func NewExportRepository(db *gorm.DB) *ExportRepository {
+	return &ExportRepository{db}
+}
+
+func (r *ExportRepository) CreateExport(ctx context.Context, e *models.Export) error {
+	return database.Conn(ctx, r.db).Create(e).Error
+}
+
+func (r *ExportRepository) UpdateExport(ctx context.Context, e *models.Export) error {
+	return database.Conn(ctx, r.db).Save(e).Error
+}
+
+func (r *ExportRepository) GetExport(ctx context.Context, exportId string) (*models.Export, error) {
+	var e models.Export
+	err := database.Conn(ctx, r.db).Where("id = ?", exportId).First(&e).Error
+	return &e, err
+}
+
+func (r *ExportRepository) UpdateExportProgress(ctx context.Context, exportId string, processed int64) error {
+	return database.Conn(ctx, r.db).Model(&models.Export{}).Where("id = ?", exportId).
+		Update("processed", processed).Error
+}
+
+func (r *ExportRepository) GetExpiredExports(ctx context.Context, now time.Time) ([]*models.Export, error) {
+	var exports []*models.Export
+	err := database.Conn(ctx, r.db).Where("expires_at <= ?", now).Find(&exports).Error
+	return exports, err
+}
+
+func (r *ExportRepository) DeleteExport(ctx context.Context, exportId string) error {
+	return database.Conn(ctx, r.db).Where("id = ?", exportId).Delete(&models.Export{}).Error
+}
+
+// CountUsers counts the users matching filters.
+func (r *ExportRepository) CountUsers(ctx context.Context, filters models.ExportFilters) (int64, error) {
+	var count int64
+	err := r.usersQuery(ctx, filters).Count(&count).Error
+	return count, err
+}
+
+// StreamUsers calls fn for each user matching filters, in batches ordered
+// by ID so rows written meanwhile do not shift later batches.
+func (r *ExportRepository) StreamUsers(ctx context.Context, filters models.ExportFilters, fn func(*models.User) error) error {
+	return streamByID(func(after string, users *[]*models.User) error {
+		return r.usersQuery(ctx, filters).Preload("Address").
+			Where("users.id > ?", after).Order("users.id").Limit(streamBatchSize).Find(users).Error
+	}, func(u *models.User) string { return u.ID }, fn)
+}
+
+func (r *ExportRepository) usersQuery(ctx context.Context, filters models.ExportFilters) *gorm.DB {
+	query := database.Conn(ctx, r.db).Model(&models.User{})
+	if filters.State != "" {
+		query = query.Joins("JOIN addresses ON addresses.user_id = users.id").
+			Where("addresses.state = ?", filters.State)
+	}
+	return query
+}
+
+// CountPosts counts the posts matching filters.
+func (r *ExportRepository) CountPosts(ctx context.Context, filters models.ExportFilters) (int64, error) {
+	var count int64
+	err := r.postsQuery(ctx, filters).Count(&count).Error
+	return count, err
+}
+
+// StreamPosts calls fn for each post matching filters, see StreamUsers.
+func (r *ExportRepository) StreamPosts(ctx context.Context, filters models.ExportFilters, fn func(*models.Post) error) error {
+	return streamByID(func(after string, posts *[]*models.Post) error {
+		return r.postsQuery(ctx, filters).Where("id > ?", after).Order("id").Limit(streamBatchSize).Find(posts).Error
+	}, func(p *models.Post) string { return p.ID }, fn)
+}
+
+func (r *ExportRepository) postsQuery(ctx context.Context, filters models.ExportFilters) *gorm.DB {
+	query := database.Conn(ctx, r.db).Model(&models.Post{})
+	if filters.UserID != "" {
+		query = query.Where("user_id = ?", filters.UserID)
+	}
+	return query
+}
+
+// streamByID pages through a table with keyset pagination: load fetches
+// up to streamBatchSize rows with an ID greater than after.
+func streamByID[T any](load func(after string, rows *[]T) error, id func(T) string, fn func(T) error) error {
+	after := ""
+	for {
+		var rows []T
+		if err := load(after, &rows); err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+
+		if len(rows) < streamBatchSize {
+			return nil
+		}
+		after = id(rows[len(rows)-1])
+	}
+}
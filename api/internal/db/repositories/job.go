@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"gorm.io/gorm"
+)
+
+type JobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) *JobRepository {
+	return &JobRepository{db}
+}
+
+// CreateJob inserts a job. Called with a transaction context, the job is
+// only visible to workers once the transaction commits.
+func (r *JobRepository) CreateJob(ctx context.Context, j *models.Job) error {
+	return database.Conn(ctx, r.db).Create(j).Error
+}
+
+func (r *JobRepository) GetJob(ctx context.Context, jobId string) (*models.Job, error) {
+	var j models.Job
+	err := database.Conn(ctx, r.db).Where("id = ?", jobId).First(&j).Error
+	return &j, err
+}
+
+// claimAttempts bounds how often ClaimJob retries after losing a race for a
+// job to another worker.
+const claimAttempts = 3
+
+// ClaimJob locks the next job that is due and returns it, or nil if there
+// is none. Running jobs whose lock is older than lockTimeout are assumed to
+// belong to a crashed worker and are claimed again.
+//
+// SQLite has no SELECT ... FOR UPDATE, so a candidate is selected first and
+// then claimed with an UPDATE conditioned on the state that was read. Only
+// one worker can win that update.
+func (r *JobRepository) ClaimJob(ctx context.Context, now time.Time, lockTimeout time.Duration) (*models.Job, error) {
+	for range claimAttempts {
+		var candidate models.Job
+		err := database.Conn(ctx, r.db).
+			Where("status = ? AND run_at <= ?", models.JobStatusQueued, now).
+			Or("status = ? AND locked_at <= ?", models.JobStatusRunning, now.Add(-lockTimeout)).
+			Order("run_at").
+			First(&candidate).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result := database.Conn(ctx, r.db).Model(&models.Job{}).
+			Where("id = ? AND status = ? AND attempts = ?", candidate.ID, candidate.Status, candidate.Attempts).
+			Updates(map[string]any{
+				"status":    models.JobStatusRunning,
+				"locked_at": now,
+				"attempts":  candidate.Attempts + 1,
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			candidate.Status = models.JobStatusRunning
+			candidate.LockedAt = &now
+			candidate.Attempts++
+			return &candidate, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *JobRepository) CompleteJob(ctx context.Context, j *models.Job, now time.Time) error {
+	return r.updateJob(ctx, j, map[string]any{
+		"status":       models.JobStatusSucceeded,
+		"locked_at":    nil,
+		"completed_at": now,
+	})
+}
+
+// RetryJob queues a failed job again to run at runAt.
+func (r *JobRepository) RetryJob(ctx context.Context, j *models.Job, runAt time.Time, lastError string) error {
+	return r.updateJob(ctx, j, map[string]any{
+		"status":     models.JobStatusQueued,
+		"locked_at":  nil,
+		"run_at":     runAt,
+		"last_error": lastError,
+	})
+}
+
+// ReleaseJob queues a job that was interrupted, e.g. by a shutdown, without
+// counting the attempt.
+func (r *JobRepository) ReleaseJob(ctx context.Context, j *models.Job) error {
+	return r.updateJob(ctx, j, map[string]any{
+		"status":    models.JobStatusQueued,
+		"locked_at": nil,
+		"attempts":  gorm.Expr("attempts - 1"),
+	})
+}
+
+// BuryJob moves a job to the dead-letter state.
+func (r *JobRepository) BuryJob(ctx context.Context, j *models.Job, lastError string) error {
+	return r.updateJob(ctx, j, map[string]any{
+		"status":     models.JobStatusDead,
+		"locked_at":  nil,
+		"last_error": lastError,
+	})
+}
+
+// RenewJob moves the lock of a running job to now, so it is not assumed
+// lost while it is still being worked on.
+func (r *JobRepository) RenewJob(ctx context.Context, j *models.Job, now time.Time) error {
+	return r.updateJob(ctx, j, map[string]any{"locked_at": now})
+}
+
+// updateJob updates a job claimed with ClaimJob. The update is conditioned
+// on the job still running the attempt that was claimed: once its lock has
+// expired and another worker claimed it again, the attempt count no longer
+// matches and models.ErrJobLockLost is returned instead of overwriting the
+// new attempt.
+func (r *JobRepository) updateJob(ctx context.Context, j *models.Job, values map[string]any) error {
+	result := database.Conn(ctx, r.db).Model(&models.Job{}).
+		Where("id = ? AND status = ? AND attempts = ?", j.ID, models.JobStatusRunning, j.Attempts).
+		Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrJobLockLost
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"gorm.io/gorm"
+)
+
+// addOutboxEvent records event in the outbox using tx, the transaction of
+// the change the event describes.
+func addOutboxEvent(tx *gorm.DB, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.OutboxEvent{
+		ID:        uuid.NewString(),
+		Event:     event,
+		Payload:   string(payload),
+		CreatedAt: time.Now().UTC(),
+	}).Error
+}
+
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{db}
+}
+
+// GetPendingEvents returns up to limit events not dispatched yet, oldest
+// first.
+func (r *OutboxRepository) GetPendingEvents(ctx context.Context, limit int) ([]*models.OutboxEvent, error) {
+	var events []*models.OutboxEvent
+	err := database.Conn(ctx, r.db).Where("dispatched_at IS NULL").
+		Order("created_at").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+func (r *OutboxRepository) MarkDispatched(ctx context.Context, eventId string, now time.Time) error {
+	return database.Conn(ctx, r.db).Model(&models.OutboxEvent{}).Where("id = ?", eventId).
+		Updates(map[string]any{"dispatched_at": now, "last_error": ""}).Error
+}
+
+// RecordFailure counts a failed attempt to publish an event.
+func (r *OutboxRepository) RecordFailure(ctx context.Context, eventId string, lastError string) error {
+	return database.Conn(ctx, r.db).Model(&models.OutboxEvent{}).Where("id = ?", eventId).
+		Updates(map[string]any{"attempts": gorm.Expr("attempts + 1"), "last_error": lastError}).Error
+}
+
+// DeleteDispatchedEvents deletes events dispatched before before.
+func (r *OutboxRepository) DeleteDispatchedEvents(ctx context.Context, before time.Time) error {
+	return database.Conn(ctx, r.db).Where("dispatched_at < ?", before).Delete(&models.OutboxEvent{}).Error
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 
+	database "github.com/princecee/lema-ai/internal/db"
 	"github.com/princecee/lema-ai/internal/db/models"
 	"gorm.io/gorm"
 )
@@ -15,23 +16,99 @@ func NewPostRepository(db *gorm.DB) *PostRepository {
 	return &PostRepository{db}
 }
 
+// CreatePost inserts a post and records a post.created event in the same
+// transaction.
 func (r *PostRepository) CreatePost(ctx context.Context, p *models.Post) error {
-	return r.db.WithContext(ctx).Create(p).Error
+	return database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(p).Error; err != nil {
+			return err
+		}
+		return addOutboxEvent(tx, models.EventPostCreated, p)
+	})
+}
+
+func (r *PostRepository) UpdatePost(ctx context.Context, p *models.Post) error {
+	return database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(p).Error; err != nil {
+			return err
+		}
+		return addOutboxEvent(tx, models.EventPostUpdated, p)
+	})
 }
 
 func (r *PostRepository) GetPost(ctx context.Context, postId string) (*models.Post, error) {
 	var post models.Post
-	err := r.db.WithContext(ctx).Where("id = ?", postId).First(&post).Error
+	err := database.Conn(ctx, r.db).Where("id = ?", postId).First(&post).Error
 	return &post, err
 }
 
+// GetPosts reads the posts of a user from a replica, see
+// database.ReplicaConn.
 func (r *PostRepository) GetPosts(ctx context.Context, userId string) ([]*models.Post, error) {
 	var posts []*models.Post
-	err := r.db.WithContext(ctx).Where("user_id = ?", userId).Find(&posts).Error
+	err := database.ReplicaConn(ctx, r.db).Where("user_id = ?", userId).Find(&posts).Error
+	return posts, err
+}
+
+// SearchPosts returns up to limit posts whose title or body contains
+// query, ignoring case, oldest first. When userId is set only that user's
+// posts are searched.
+func (r *PostRepository) SearchPosts(ctx context.Context, query, userId string, limit int) ([]*models.Post, error) {
+	pattern := likePattern(query)
+	tx := database.Conn(ctx, r.db).
+		Where("title LIKE ? ESCAPE '\\' OR body LIKE ? ESCAPE '\\'", pattern, pattern)
+	if userId != "" {
+		tx = tx.Where("user_id = ?", userId)
+	}
+
+	var posts []*models.Post
+	err := tx.Order("created_at, id").Limit(limit).Find(&posts).Error
 	return posts, err
 }
 
+// GetPostsByUsers returns limit posts of each user, oldest first, skipping
+// the first offset, in a single query on a replica, see
+// database.ReplicaConn.
+func (r *PostRepository) GetPostsByUsers(ctx context.Context, userIds []string, limit, offset int) ([]*models.Post, error) {
+	var posts []*models.Post
+	if len(userIds) == 0 {
+		return posts, nil
+	}
+
+	conn := database.ReplicaConn(ctx, r.db)
+	numbered := conn.Model(&models.Post{}).
+		Select("*, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY created_at, id) AS position").
+		Where("user_id IN ?", userIds)
+	err := conn.Table("(?) AS posts", numbered).
+		Select("id, user_id, title, body, created_at").
+		Where("position > ? AND position <= ?", offset, offset+limit).
+		Order("user_id, position").
+		Find(&posts).Error
+	return posts, err
+}
+
+// StreamPosts calls fn for each of a user's posts, loading them in batches
+// ordered by ID from a replica, see database.ReplicaConn. No cursor is
+// held open while fn runs, so a slow client does not hold up writes behind
+// its connection.
+func (r *PostRepository) StreamPosts(ctx context.Context, userId string, fn func(*models.Post) error) error {
+	return streamByID(func(after string, posts *[]*models.Post) error {
+		return database.ReplicaConn(ctx, r.db).Where("user_id = ? AND id > ?", userId, after).
+			Order("id").Limit(streamBatchSize).Find(posts).Error
+	}, func(p *models.Post) string { return p.ID }, fn)
+}
+
+// DeletePost deletes a post and records a post.deleted event carrying the
+// deleted post. It returns gorm.ErrRecordNotFound if there is no such post.
 func (r *PostRepository) DeletePost(ctx context.Context, postId string) error {
-	result := r.db.WithContext(ctx).Unscoped().Where("id = ?", postId).Delete(&models.Post{})
-	return result.Error
+	return database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var post models.Post
+		if err := tx.Where("id = ?", postId).First(&post).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&post).Error; err != nil {
+			return err
+		}
+		return addOutboxEvent(tx, models.EventPostDeleted, &post)
+	})
 }
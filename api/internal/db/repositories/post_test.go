@@ -26,9 +26,9 @@ type PostRepositoryTestSuite struct {
 func (s *PostRepositoryTestSuite) SetupSuite() {
 	cfg := config.NewConfig("test", "silent")
 	cfg.DSN = "file::memory:?cache=shared"
-	db := database.GetDBConn(cfg.DSN, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
+	db := database.GetDBConn(cfg.DSN, cfg.REPLICA_DSNS, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
 
-	err := db.AutoMigrate(&models.User{}, &models.Address{}, &models.Post{})
+	err := db.AutoMigrate(&models.User{}, &models.Address{}, &models.Post{}, &models.OutboxEvent{})
 	if err != nil {
 		s.Fail(err.Error())
 	}
@@ -65,12 +65,7 @@ func (s *PostRepositoryTestSuite) SetupSuite() {
 }
 
 func (s *PostRepositoryTestSuite) TearDownSuite() {
-	sqlDB, err := s.db.DB()
-	if err != nil {
-		s.Fail(err.Error())
-	}
-
-	sqlDB.Close()
+	s.NoError(database.Close(s.db))
 }
 
 func (s *PostRepositoryTestSuite) TestPostRepository() {
@@ -109,6 +104,23 @@ func (s *PostRepositoryTestSuite) TestPostRepository() {
 		s.NotEmpty(posts)
 		s.GreaterOrEqual(len(posts), 4)
 
+		t.Run("Get posts by users", func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			userIds := []string{s.users[0].ID, s.users[1].ID, uuid.NewString()}
+			page, err := s.postRepo.GetPostsByUsers(ctx, userIds, 2, 1)
+			s.NoError(err)
+			s.Len(page, 4)
+
+			byUser := map[string][]string{}
+			for _, p := range page {
+				byUser[p.UserID] = append(byUser[p.UserID], p.ID)
+			}
+			s.Len(byUser, 2)
+			s.Len(byUser[user.ID], 2)
+		})
+
 		t.Run("Get post by ID", func(t *testing.T) {
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
@@ -119,6 +131,52 @@ func (s *PostRepositoryTestSuite) TestPostRepository() {
 			s.Equal(posts[0].ID, post.ID)
 		})
 
+		t.Run("Search posts", func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			found, err := s.postRepo.SearchPosts(ctx, posts[1].Title, "", 10)
+			s.NoError(err)
+			ids := make([]string, len(found))
+			for i, p := range found {
+				ids[i] = p.ID
+			}
+			s.Contains(ids, posts[1].ID)
+
+			found, err = s.postRepo.SearchPosts(ctx, "", user.ID, 10)
+			s.NoError(err)
+			s.Len(found, len(posts))
+			for _, p := range found {
+				s.Equal(user.ID, p.UserID)
+			}
+
+			found, err = s.postRepo.SearchPosts(ctx, posts[1].Title, s.users[1].ID, 10)
+			s.NoError(err)
+			s.Empty(found)
+		})
+
+		t.Run("Stream posts", func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			// Writing while streaming must not wait for the stream to end,
+			// which it would while the stream held the only connection of
+			// the in-memory database.
+			var streamed []string
+			err := s.postRepo.StreamPosts(ctx, user.ID, func(p *models.Post) error {
+				streamed = append(streamed, p.ID)
+				return s.postRepo.CreatePost(ctx, &models.Post{
+					ID:        uuid.NewString(),
+					Title:     gofakeit.Sentence(7),
+					Body:      gofakeit.Sentence(40),
+					UserID:    s.users[1].ID,
+					CreatedAt: time.Now().UTC().Format(time.RFC3339),
+				})
+			})
+			s.NoError(err)
+			s.Len(streamed, len(posts))
+		})
+
 		t.Run("Delete post", func(t *testing.T) {
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"context"
+
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"gorm.io/gorm"
+)
+
+// Stats counts the records of the service.
+type Stats struct {
+	Users    int64
+	Posts    int64
+	Webhooks int64
+	// Exports and Jobs are counted by status.
+	Exports map[string]int64
+	Jobs    map[string]int64
+	// PendingEvents is the number of outbox events not dispatched yet.
+	PendingEvents int64
+}
+
+type StatsRepository struct {
+	db *gorm.DB
+}
+
+func NewStatsRepository(db *gorm.DB) *StatsRepository {
+	return &StatsRepository{db}
+}
+
+func (r *StatsRepository) GetStats(ctx context.Context) (*Stats, error) {
+	conn := database.Conn(ctx, r.db)
+	stats := &Stats{}
+
+	counts := []struct {
+		model any
+		dst   *int64
+	}{
+		{&models.User{}, &stats.Users},
+		{&models.Post{}, &stats.Posts},
+		{&models.Webhook{}, &stats.Webhooks},
+	}
+	for _, c := range counts {
+		if err := conn.Model(c.model).Count(c.dst).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	err := conn.Model(&models.OutboxEvent{}).Where("dispatched_at IS NULL").Count(&stats.PendingEvents).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.Exports, err = countByStatus(conn, &models.Export{}); err != nil {
+		return nil, err
+	}
+	if stats.Jobs, err = countByStatus(conn, &models.Job{}); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func countByStatus(conn *gorm.DB, model any) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := conn.Model(model).Select("status, COUNT(*) AS count").Group("status").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
@@ -2,7 +2,9 @@ package repositories
 
 import (
 	"context"
+	"strings"
 
+	database "github.com/princecee/lema-ai/internal/db"
 	"github.com/princecee/lema-ai/internal/db/models"
 	"github.com/princecee/lema-ai/pkg/pagination"
 	"gorm.io/gorm"
@@ -16,17 +18,48 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{db}
 }
 
+// CreateUser inserts a user and records a user.created event in the same
+// transaction.
 func (r *UserRepository) CreateUser(ctx context.Context, u *models.User) error {
-	result := r.db.WithContext(ctx).Create(u)
-	return result.Error
+	return database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(u).Error; err != nil {
+			return err
+		}
+		return addOutboxEvent(tx, models.EventUserCreated, u)
+	})
+}
+
+// UpdateUser saves every field of u and its address, and records a
+// user.updated event in the same transaction.
+func (r *UserRepository) UpdateUser(ctx context.Context, u *models.User) error {
+	return database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(u).Error; err != nil {
+			return err
+		}
+		return addOutboxEvent(tx, models.EventUserUpdated, u)
+	})
 }
 
 func (r *UserRepository) GetUser(ctx context.Context, userId string) (*models.User, error) {
 	u := models.User{}
-	err := r.db.WithContext(ctx).Preload("Address").Where("id = ?", userId).First(&u).Error
+	err := database.Conn(ctx, r.db).Preload("Address").Where("id = ?", userId).First(&u).Error
 	return &u, err
 }
 
+func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	u := models.User{}
+	err := database.Conn(ctx, r.db).Preload("Address").Where("email = ?", email).First(&u).Error
+	return &u, err
+}
+
+func (r *UserRepository) UserExists(ctx context.Context, userId string) (bool, error) {
+	var count int64
+	err := database.Conn(ctx, r.db).Model(&models.User{}).Where("id = ?", userId).Count(&count).Error
+	return count > 0, err
+}
+
+// GetUsers reads a page of users from a replica, see
+// database.ReplicaConn.
 func (r *UserRepository) GetUsers(ctx context.Context, opts pagination.PaginationQuery) (*pagination.GetUsersResult, error) {
 	count, err := r.GetUserCount(ctx)
 	if err != nil {
@@ -35,7 +68,7 @@ func (r *UserRepository) GetUsers(ctx context.Context, opts pagination.Paginatio
 
 	offset := pagination.GetPaginationData(opts)
 	var users []*models.User
-	err = r.db.WithContext(ctx).Preload("Address").Offset(offset).Limit(*opts.Limit).Find(&users).Error
+	err = database.ReplicaConn(ctx, r.db).Preload("Address").Offset(offset).Limit(*opts.Limit).Find(&users).Error
 
 	totalPages := pagination.GetTotalPages(count, *opts.Limit)
 	return &pagination.GetUsersResult{
@@ -49,8 +82,93 @@ func (r *UserRepository) GetUsers(ctx context.Context, opts pagination.Paginatio
 	}, err
 }
 
+// streamBatchSize is the number of rows loaded at a time when streaming.
+const streamBatchSize = 100
+
+// StreamUsers calls fn for each user of the page described by opts, loading
+// them in batches rather than all at once.
+func (r *UserRepository) StreamUsers(ctx context.Context, opts pagination.PaginationQuery, fn func(*models.User) error) error {
+	offset := pagination.GetPaginationData(opts)
+	for remaining := *opts.Limit; remaining > 0; {
+		size := min(remaining, streamBatchSize)
+
+		var users []*models.User
+		err := database.Conn(ctx, r.db).Preload("Address").Offset(offset).Limit(size).Find(&users).Error
+		if err != nil {
+			return err
+		}
+
+		for _, u := range users {
+			if err := fn(u); err != nil {
+				return err
+			}
+		}
+
+		if len(users) < size {
+			return nil
+		}
+		offset += size
+		remaining -= size
+	}
+	return nil
+}
+
+// GetUserCount counts the users on a replica, see database.ReplicaConn.
 func (r *UserRepository) GetUserCount(ctx context.Context) (int64, error) {
 	var count int64
-	err := r.db.WithContext(ctx).Model(&models.User{}).Count(&count).Error
+	err := database.ReplicaConn(ctx, r.db).Model(&models.User{}).Count(&count).Error
 	return count, err
 }
+
+// SearchUsers returns up to limit users whose name, username or email
+// contains query, ignoring case, ordered by name.
+func (r *UserRepository) SearchUsers(ctx context.Context, query string, limit int) ([]*models.User, error) {
+	pattern := likePattern(query)
+	var users []*models.User
+	err := database.Conn(ctx, r.db).Preload("Address").
+		Where("name LIKE ? ESCAPE '\\' OR username LIKE ? ESCAPE '\\' OR email LIKE ? ESCAPE '\\'", pattern, pattern, pattern).
+		Order("name, id").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+// DeleteUser deletes a user with its address and posts. A post.deleted
+// event is recorded for each post and a user.deleted event carrying the
+// user, all in one transaction. It returns gorm.ErrRecordNotFound if there
+// is no such user.
+func (r *UserRepository) DeleteUser(ctx context.Context, userId string) error {
+	return database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Preload("Address").Where("id = ?", userId).First(&user).Error; err != nil {
+			return err
+		}
+
+		var posts []*models.Post
+		if err := tx.Where("user_id = ?", userId).Find(&posts).Error; err != nil {
+			return err
+		}
+		for _, post := range posts {
+			if err := tx.Unscoped().Delete(post).Error; err != nil {
+				return err
+			}
+			if err := addOutboxEvent(tx, models.EventPostDeleted, post); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Unscoped().Where("user_id = ?", userId).Delete(&models.Address{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&user).Error; err != nil {
+			return err
+		}
+		return addOutboxEvent(tx, models.EventUserDeleted, &user)
+	})
+}
+
+// likePattern matches values containing s with LIKE ... ESCAPE '\'.
+func likePattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+	return "%" + s + "%"
+}
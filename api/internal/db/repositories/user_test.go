@@ -2,6 +2,7 @@ package repositories_test
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -25,9 +26,9 @@ type UserRepositoryTestSuite struct {
 func (s *UserRepositoryTestSuite) SetupSuite() {
 	cfg := config.NewConfig("test", "silent")
 	cfg.DSN = "file::memory:?cache=shared"
-	db := database.GetDBConn(cfg.DSN, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
+	db := database.GetDBConn(cfg.DSN, cfg.REPLICA_DSNS, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
 
-	err := db.AutoMigrate(&models.User{}, &models.Address{})
+	err := db.AutoMigrate(&models.User{}, &models.Address{}, &models.Post{}, &models.OutboxEvent{})
 	if err != nil {
 		s.Fail(err.Error())
 	}
@@ -37,12 +38,7 @@ func (s *UserRepositoryTestSuite) SetupSuite() {
 }
 
 func (s *UserRepositoryTestSuite) TearDownSuite() {
-	sqlDB, err := s.db.DB()
-	if err != nil {
-		s.Fail(err.Error())
-	}
-
-	sqlDB.Close()
+	s.NoError(database.Close(s.db))
 }
 
 func (s *UserRepositoryTestSuite) TestUserRepository() {
@@ -133,6 +129,56 @@ func (s *UserRepositoryTestSuite) TestUserRepository() {
 		s.NotEmpty(user)
 		s.Equal(userId, user.ID)
 	})
+
+	t.Run("Search users", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		user, err := s.userRepo.GetUser(ctx, userId)
+		s.NoError(err)
+
+		users, err := s.userRepo.SearchUsers(ctx, strings.ToUpper(user.Email), 10)
+		s.NoError(err)
+		s.Len(users, 1)
+		s.Equal(userId, users[0].ID)
+		s.Equal(user.Address.ID, users[0].Address.ID)
+
+		users, err = s.userRepo.SearchUsers(ctx, "%", 10)
+		s.NoError(err)
+		s.Empty(users)
+
+		users, err = s.userRepo.SearchUsers(ctx, "", 5)
+		s.NoError(err)
+		s.Len(users, 5)
+	})
+
+	t.Run("Delete user", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		post := &models.Post{ID: uuid.NewString(), Title: gofakeit.Sentence(5), Body: gofakeit.Sentence(20), UserID: userId}
+		s.NoError(s.db.Create(post).Error)
+
+		err := s.userRepo.DeleteUser(ctx, userId)
+		s.NoError(err)
+
+		_, err = s.userRepo.GetUser(ctx, userId)
+		s.ErrorIs(err, gorm.ErrRecordNotFound)
+
+		var count int64
+		s.NoError(s.db.Model(&models.Address{}).Where("user_id = ?", userId).Count(&count).Error)
+		s.Zero(count)
+		s.NoError(s.db.Model(&models.Post{}).Where("user_id = ?", userId).Count(&count).Error)
+		s.Zero(count)
+
+		var events []models.OutboxEvent
+		s.NoError(s.db.Where("event IN ?", []string{models.EventPostDeleted, models.EventUserDeleted}).Find(&events).Error)
+		s.Len(events, 2)
+		s.ElementsMatch([]string{models.EventPostDeleted, models.EventUserDeleted}, []string{events[0].Event, events[1].Event})
+
+		err = s.userRepo.DeleteUser(ctx, userId)
+		s.ErrorIs(err, gorm.ErrRecordNotFound)
+	})
 }
 
 func TestUserRepository(t *testing.T) {
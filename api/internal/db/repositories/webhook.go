@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db}
+}
+
+func (r *WebhookRepository) CreateWebhook(ctx context.Context, w *models.Webhook) error {
+	return database.Conn(ctx, r.db).Create(w).Error
+}
+
+func (r *WebhookRepository) GetWebhook(ctx context.Context, webhookId string) (*models.Webhook, error) {
+	var w models.Webhook
+	err := database.Conn(ctx, r.db).Where("id = ?", webhookId).First(&w).Error
+	return &w, err
+}
+
+func (r *WebhookRepository) GetWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	var webhooks []*models.Webhook
+	err := database.Conn(ctx, r.db).Order("created_at").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// GetWebhooksByEvent returns the webhooks subscribed to event. Events are
+// stored as a JSON array, so the quoted name is matched.
+func (r *WebhookRepository) GetWebhooksByEvent(ctx context.Context, event string) ([]*models.Webhook, error) {
+	var webhooks []*models.Webhook
+	err := database.Conn(ctx, r.db).Where("events LIKE ?", `%"`+event+`"%`).Find(&webhooks).Error
+	return webhooks, err
+}
+
+// DeleteWebhook deletes a webhook with its deliveries and their attempts.
+func (r *WebhookRepository) DeleteWebhook(ctx context.Context, webhookId string) error {
+	return database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		deliveries := tx.Model(&models.WebhookDelivery{}).Select("id").Where("webhook_id = ?", webhookId)
+		if err := tx.Where("delivery_id IN (?)", deliveries).Delete(&models.WebhookAttempt{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("webhook_id = ?", webhookId).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where("id = ?", webhookId).Delete(&models.Webhook{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// CreateDelivery inserts a delivery unless the webhook already has one for
+// the same event. It reports whether the delivery was inserted.
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, d *models.WebhookDelivery) (bool, error) {
+	result := database.Conn(ctx, r.db).Omit("Attempts").Clauses(clause.OnConflict{DoNothing: true}).Create(d)
+	return result.RowsAffected == 1, result.Error
+}
+
+// GetDelivery returns a delivery of a webhook with its attempts, oldest
+// first.
+func (r *WebhookRepository) GetDelivery(ctx context.Context, webhookId, deliveryId string) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	err := database.Conn(ctx, r.db).
+		Preload("Attempts", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Where("id = ? AND webhook_id = ?", deliveryId, webhookId).
+		First(&d).Error
+	return &d, err
+}
+
+// GetDeliveries returns the latest deliveries of a webhook, newest first.
+func (r *WebhookRepository) GetDeliveries(ctx context.Context, webhookId string, limit int) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	err := database.Conn(ctx, r.db).
+		Preload("Attempts", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Where("webhook_id = ?", webhookId).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *WebhookRepository) UpdateDeliveryStatus(ctx context.Context, deliveryId, status string, deliveredAt *time.Time) error {
+	return database.Conn(ctx, r.db).Model(&models.WebhookDelivery{}).Where("id = ?", deliveryId).
+		Updates(map[string]any{"status": status, "delivered_at": deliveredAt}).Error
+}
+
+func (r *WebhookRepository) CreateAttempt(ctx context.Context, a *models.WebhookAttempt) error {
+	return database.Conn(ctx, r.db).Create(a).Error
+}
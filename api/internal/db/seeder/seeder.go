@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/brianvoe/gofakeit/v7"
+	"github.com/google/uuid"
 	"github.com/princecee/lema-ai/internal/db/models"
 )
 
@@ -16,18 +17,18 @@ type postRepo interface {
 	CreatePost(ctx context.Context, p *models.Post) error
 }
 
-// Seed the database with 50 random users and 5 posts each
-func Seed(userRepo userRepo, postRepo postRepo) {
-	for i := 0; i < 50; i++ {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
+// Seed creates users random users with posts random posts each. It stops
+// at the first record that cannot be created.
+func Seed(ctx context.Context, userRepo userRepo, postRepo postRepo, users, posts int) error {
+	for i := 0; i < users; i++ {
 		user := &models.User{
+			ID:       uuid.NewString(),
 			Name:     gofakeit.Name(),
 			Username: gofakeit.Username(),
-			Phone:    gofakeit.Phone(),
+			Phone:    "+1" + gofakeit.Phone(),
 			Email:    gofakeit.Email(),
 			Address: models.Address{
+				ID:      uuid.NewString(),
 				Street:  gofakeit.StreetName(),
 				City:    gofakeit.City(),
 				State:   gofakeit.State(),
@@ -36,23 +37,22 @@ func Seed(userRepo userRepo, postRepo postRepo) {
 		}
 
 		if err := userRepo.CreateUser(ctx, user); err != nil {
-			panic(err)
+			return err
 		}
 
-		for j := 0; j < 5; j++ {
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-
+		for j := 0; j < posts; j++ {
 			post := models.Post{
-				Title:  gofakeit.Sentence(7),
-				Body:   gofakeit.Paragraph(3, 7, 5, " "),
-				UserID: user.ID,
+				ID:        uuid.NewString(),
+				Title:     gofakeit.Sentence(7),
+				Body:      gofakeit.Paragraph(3, 7, 5, " "),
+				UserID:    user.ID,
+				CreatedAt: time.Now().UTC().Format(time.RFC3339),
 			}
 
 			if err := postRepo.CreatePost(ctx, &post); err != nil {
-				panic(err)
+				return err
 			}
 		}
 	}
-
+	return nil
 }
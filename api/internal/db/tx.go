@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type txCtxKey struct{}
+
+// Transactor runs functions in a database transaction. Repositories called
+// with the context passed to fn take part in the transaction, see Conn.
+type Transactor struct {
+	db *gorm.DB
+}
+
+func NewTransactor(db *gorm.DB) *Transactor {
+	return &Transactor{db}
+}
+
+// Transaction commits if fn returns nil and rolls back otherwise. Nested
+// calls use savepoints, so an inner failure only undoes the inner work.
+func (t *Transactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return Conn(ctx, t.db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txCtxKey{}, tx))
+	})
+}
+
+// Conn returns the transaction stored in ctx, or db when there is none,
+// bound to ctx.
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txCtxKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
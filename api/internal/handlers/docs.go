@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/princecee/lema-ai/config"
+	"github.com/princecee/lema-ai/pkg/json"
+	"github.com/rs/zerolog"
+)
+
+//go:embed swagger.html
+var swaggerUI []byte
+
+type DocsHandler struct {
+	spec   []byte
+	config *config.Config
+	logger zerolog.Logger
+}
+
+func NewDocsHandler(cfg *config.Config, l zerolog.Logger) *DocsHandler {
+	spec, err := json.WriteJSON(NewOpenAPIDocument())
+	if err != nil {
+		panic(err)
+	}
+
+	return &DocsHandler{spec, cfg, l}
+}
+
+func (h *DocsHandler) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(h.spec)
+}
+
+func (h *DocsHandler) SwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(swaggerUI)
+}
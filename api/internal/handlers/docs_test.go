@@ -0,0 +1,108 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/princecee/lema-ai/config"
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/repositories"
+	"github.com/princecee/lema-ai/internal/handlers"
+	"github.com/princecee/lema-ai/internal/routes"
+	"github.com/princecee/lema-ai/internal/services"
+	"github.com/princecee/lema-ai/pkg/json"
+	"github.com/princecee/lema-ai/pkg/store"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type DocsHandlerTestSuite struct {
+	suite.Suite
+	db     *gorm.DB
+	router chi.Router
+	server *httptest.Server
+}
+
+func (s *DocsHandlerTestSuite) SetupSuite() {
+	cfg := config.NewConfig("test", "silent")
+	cfg.DSN = "file::memory:?cache=shared"
+	var logger zerolog.Logger
+
+	db := database.GetDBConn(cfg.DSN, cfg.REPLICA_DSNS, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
+	health := handlers.NewHealthHandler(nil, cfg, logger)
+
+	s.db = db
+	jobQueue := services.NewJobQueue(repositories.NewJobRepository(db), services.JobOptions{}, logger)
+	exportService := services.NewExportService(database.NewTransactor(db), repositories.NewExportRepository(db), jobQueue, services.ExportOptions{}, logger)
+	webhookService := services.NewWebhookService(database.NewTransactor(db), repositories.NewWebhookRepository(db), jobQueue, services.WebhookOptions{}, logger)
+	s.router = routes.NewRouter(db, store.NewMemoryStore(), health, exportService, webhookService, services.NewEventStream(10), services.NewHub(services.NewEventStream(10), services.HubOptions{}, logger), cfg, logger)
+	s.server = httptest.NewServer(s.router)
+}
+
+func (s *DocsHandlerTestSuite) TearDownSuite() {
+	s.NoError(database.Close(s.db))
+	s.server.Close()
+}
+
+func (s *DocsHandlerTestSuite) TestDocsHandler() {
+	t := s.T()
+
+	t.Run("Every registered route is documented", func(t *testing.T) {
+		doc := handlers.NewOpenAPIDocument()
+
+		err := chi.Walk(s.router, func(method, route string, h http.Handler, m ...func(http.Handler) http.Handler) error {
+			path := strings.ReplaceAll(route, "/*/", "/")
+			if len(path) > 1 {
+				path = strings.TrimSuffix(path, "/")
+			}
+
+			item, ok := doc.Paths[path]
+			if !s.Truef(ok, "route %s is missing from the OpenAPI document", path) {
+				return nil
+			}
+			s.Containsf(item, strings.ToLower(method), "operation %s %s is missing from the OpenAPI document", method, path)
+			return nil
+		})
+		s.NoError(err)
+	})
+
+	t.Run("Serves the OpenAPI document", func(t *testing.T) {
+		resp, err := s.server.Client().Get(s.server.URL + "/api/v1/openapi.json")
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+		s.Equal("application/json", resp.Header.Get("Content-Type"))
+		defer resp.Body.Close()
+
+		doc := handlers.OpenAPIDocument{}
+		s.NoError(json.ReadJSON(resp.Body, &doc))
+		s.Equal("3.0.3", doc.OpenAPI)
+
+		s.Contains(doc.Components.Schemas, "Response")
+		s.Contains(doc.Components.Schemas, "GetUsersQuery")
+
+		createPost := doc.Components.Schemas["createPostData"]
+		s.Require().NotNil(createPost)
+		s.ElementsMatch([]string{"title", "body", "userId"}, createPost.Required)
+
+		getPost := doc.Paths["/api/v1/posts/{post_id}"]["get"]
+		s.Require().NotNil(getPost)
+		s.Equal("post_id", getPost.Parameters[0].Name)
+		s.Equal("path", getPost.Parameters[0].In)
+	})
+
+	t.Run("Serves Swagger UI", func(t *testing.T) {
+		resp, err := s.server.Client().Get(s.server.URL + "/api/v1/docs")
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+		s.Contains(resp.Header.Get("Content-Type"), "text/html")
+		defer resp.Body.Close()
+	})
+}
+
+func TestDocsHandler(t *testing.T) {
+	suite.Run(t, new(DocsHandlerTestSuite))
+}
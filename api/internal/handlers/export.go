@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/princecee/lema-ai/config"
+	"github.com/princecee/lema-ai/internal/db/models"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/json"
+	"github.com/princecee/lema-ai/pkg/response"
+	"github.com/princecee/lema-ai/pkg/validator"
+	"github.com/rs/zerolog"
+)
+
+type ExportService interface {
+	CreateExport(ctx context.Context, e *models.Export) error
+	GetExport(ctx context.Context, exportId string) (*models.Export, error)
+	OpenExport(ctx context.Context, exportId string) (*models.Export, *os.File, error)
+}
+
+type ExportHandler struct {
+	exportService ExportService
+	config        *config.Config
+	logger        zerolog.Logger
+}
+
+func NewExportHandler(exportService ExportService, cfg *config.Config, l zerolog.Logger) *ExportHandler {
+	return &ExportHandler{exportService, cfg, l}
+}
+
+type createExportData struct {
+	Entity  string            `json:"entity" validate:"required,oneof=users posts"`
+	Format  string            `json:"format" validate:"required,oneof=json csv ndjson"`
+	Filters exportFiltersData `json:"filters"`
+}
+
+type exportFiltersData struct {
+	UserID string `json:"user_id" validate:"omitempty,uuid"`
+	State  string `json:"state" mod:"trim" validate:"omitempty,max=100"`
+}
+
+func (h *ExportHandler) CreateExport(w http.ResponseWriter, r *http.Request) {
+	resp := response.Response[any]{}
+	data := new(createExportData)
+
+	err := json.ReadJSONStrict(r.Body, data)
+	defer r.Body.Close()
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	if err := validator.ValidateData(r.Context(), data); err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	// Each filter only has a meaning for one entity.
+	const message = "{0} only applies to {1} exports"
+	switch {
+	case data.Filters.UserID != "" && data.Entity != models.ExportEntityPosts:
+		response.SendError(w, r, apperror.ErrValidation.WithViolations(
+			apperror.NewViolation("filters.user_id", "excluded", message, "filters.user_id", models.ExportEntityPosts)))
+		return
+	case data.Filters.State != "" && data.Entity != models.ExportEntityUsers:
+		response.SendError(w, r, apperror.ErrValidation.WithViolations(
+			apperror.NewViolation("filters.state", "excluded", message, "filters.state", models.ExportEntityUsers)))
+		return
+	}
+
+	export := &models.Export{
+		Entity: data.Entity,
+		Format: data.Format,
+		Filters: models.ExportFilters{
+			UserID: data.Filters.UserID,
+			State:  data.Filters.State,
+		},
+	}
+
+	if err := h.exportService.CreateExport(r.Context(), export); err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	status := http.StatusAccepted
+	resp.StatusCode = &status
+	resp.Message = "Export created successfully"
+	resp.Data = export
+	response.SendResponse(w, resp, map[string]string{
+		"Location": "/api/v1/exports/" + export.ID,
+	})
+}
+
+func (h *ExportHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	resp := response.Response[any]{}
+
+	exportId := chi.URLParam(r, "export_id")
+	if !validator.IsValidUUID(exportId) {
+		response.SendError(w, r, apperror.InvalidParameter("export_id", "Invalid export ID"))
+		return
+	}
+
+	export, err := h.exportService.GetExport(r.Context(), exportId)
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	resp.Message = "Export fetched successfully"
+	resp.Data = export
+	response.SendResponse(w, resp, nil)
+}
+
+// DownloadExport serves the gzip file of a completed export. Range requests
+// are supported so interrupted downloads can resume.
+func (h *ExportHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	exportId := chi.URLParam(r, "export_id")
+	if !validator.IsValidUUID(exportId) {
+		response.SendError(w, r, apperror.InvalidParameter("export_id", "Invalid export ID"))
+		return
+	}
+
+	export, f, err := h.exportService.OpenExport(r.Context(), exportId)
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+	defer f.Close()
+
+	filename := fmt.Sprintf("%s-%s.%s.gz", export.Entity, export.ID, export.Format)
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	var modTime time.Time
+	if export.CompletedAt != nil {
+		modTime = *export.CompletedAt
+	}
+	http.ServeContent(w, r, filename, modTime, f)
+}
@@ -0,0 +1,245 @@
+package handlers_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/princecee/lema-ai/config"
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/internal/db/repositories"
+	"github.com/princecee/lema-ai/internal/middlewares"
+	"github.com/princecee/lema-ai/internal/routes"
+	"github.com/princecee/lema-ai/internal/services"
+	"github.com/princecee/lema-ai/pkg/json"
+	"github.com/princecee/lema-ai/pkg/response"
+	"github.com/princecee/lema-ai/pkg/store"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type ExportHandlerTestSuite struct {
+	suite.Suite
+	db            *gorm.DB
+	server        *httptest.Server
+	exportService *services.ExportService
+	jobQueue      *services.JobQueue
+	users         []*models.User
+}
+
+func (s *ExportHandlerTestSuite) SetupSuite() {
+	cfg := config.NewConfig("test", "silent")
+	cfg.DSN = "file:export?mode=memory&cache=shared"
+	cfg.RATE_LIMIT_EXPORTS = config.RateLimit{}
+	var logger zerolog.Logger
+
+	db := database.GetDBConn(cfg.DSN, cfg.REPLICA_DSNS, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
+
+	err := database.Migrate(db)
+	if err != nil {
+		s.Fail(err.Error())
+	}
+
+	s.db = db
+	userRepo := repositories.NewUserRepository(db)
+	postRepo := repositories.NewPostRepository(db)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for i, state := range []string{"IL", "IL", "TX"} {
+		user := &models.User{
+			ID:       uuid.NewString(),
+			Name:     "User " + state,
+			Username: "user" + uuid.NewString()[:8],
+			Email:    uuid.NewString()[:8] + "@example.com",
+			Phone:    "+1555000000" + string(rune('0'+i)),
+			Address: models.Address{
+				ID:      uuid.NewString(),
+				Street:  "1 Main St",
+				City:    "Springfield",
+				State:   state,
+				Zipcode: "62701",
+			},
+		}
+		if err := userRepo.CreateUser(ctx, user); err != nil {
+			s.Fail(err.Error())
+		}
+		s.users = append(s.users, user)
+	}
+
+	for i := 0; i < 3; i++ {
+		post := &models.Post{ID: uuid.NewString(), UserID: s.users[0].ID, Title: "Title", Body: "Body"}
+		if err := postRepo.CreatePost(ctx, post); err != nil {
+			s.Fail(err.Error())
+		}
+	}
+
+	s.jobQueue = services.NewJobQueue(repositories.NewJobRepository(db), services.JobOptions{
+		Workers:      2,
+		PollInterval: 10 * time.Millisecond,
+		LockTimeout:  time.Minute,
+		MaxAttempts:  1,
+	}, logger)
+	s.exportService = services.NewExportService(database.NewTransactor(db), repositories.NewExportRepository(db), s.jobQueue, services.ExportOptions{
+		Dir:             s.T().TempDir(),
+		TTL:             time.Hour,
+		CleanupInterval: time.Minute,
+	}, logger)
+	s.jobQueue.Register(services.ExportJobKind, s.exportService.RunExportJob)
+
+	if err := s.exportService.Start(context.Background()); err != nil {
+		s.Fail(err.Error())
+	}
+	s.jobQueue.Start(context.Background())
+
+	r := chi.NewRouter()
+	r.Use(middlewares.Locale)
+	r.Mount("/api/v1/exports", routes.AddExportRoutes(s.exportService, store.NewMemoryStore(), cfg, logger))
+
+	s.server = httptest.NewServer(r)
+}
+
+func (s *ExportHandlerTestSuite) TearDownSuite() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.NoError(s.jobQueue.Shutdown(ctx))
+	s.NoError(s.exportService.Shutdown(ctx))
+
+	s.NoError(database.Close(s.db))
+	s.server.Close()
+}
+
+// createExport starts an export and waits for it to finish.
+func (s *ExportHandlerTestSuite) createExport(payload map[string]any) *models.Export {
+	body, _ := json.WriteJSON(payload)
+	resp, err := s.server.Client().Post(s.server.URL+"/api/v1/exports", "application/json", bytes.NewBuffer(body))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusAccepted, resp.StatusCode)
+
+	created := response.Response[*models.Export]{}
+	_ = json.ReadJSON(resp.Body, &created)
+	s.Equal(models.ExportStatusPending, created.Data.Status)
+	s.Equal("/api/v1/exports/"+created.Data.ID, resp.Header.Get("Location"))
+
+	var export *models.Export
+	s.Require().Eventually(func() bool {
+		resp, err := s.server.Client().Get(s.server.URL + "/api/v1/exports/" + created.Data.ID)
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+
+		result := response.Response[*models.Export]{}
+		_ = json.ReadJSON(resp.Body, &result)
+		export = result.Data
+		return export != nil && export.Status != models.ExportStatusPending && export.Status != models.ExportStatusRunning
+	}, 5*time.Second, 10*time.Millisecond)
+
+	return export
+}
+
+// download fetches and decompresses the file of an export.
+func (s *ExportHandlerTestSuite) download(exportId string) string {
+	resp, err := s.server.Client().Get(s.server.URL + "/api/v1/exports/" + exportId + "/download")
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Equal("application/gzip", resp.Header.Get("Content-Type"))
+	s.Contains(resp.Header.Get("Content-Disposition"), "attachment")
+
+	gz, err := gzip.NewReader(resp.Body)
+	s.Require().NoError(err)
+	b, err := io.ReadAll(gz)
+	s.Require().NoError(err)
+	return string(b)
+}
+
+func (s *ExportHandlerTestSuite) TestExportHandler() {
+	t := s.T()
+
+	t.Run("Export users filtered by state as CSV", func(t *testing.T) {
+		export := s.createExport(map[string]any{"entity": "users", "format": "csv", "filters": map[string]any{"state": "IL"}})
+		s.Equal(models.ExportStatusCompleted, export.Status)
+		s.Equal(int64(2), export.Total)
+		s.Equal(int64(2), export.Processed)
+		s.NotNil(export.ExpiresAt)
+
+		records, err := csv.NewReader(strings.NewReader(s.download(export.ID))).ReadAll()
+		s.NoError(err)
+		s.Len(records, 3)
+		s.Contains(records[0], "address.state")
+	})
+
+	t.Run("Export a user's posts as NDJSON", func(t *testing.T) {
+		export := s.createExport(map[string]any{"entity": "posts", "format": "ndjson", "filters": map[string]any{"user_id": s.users[0].ID}})
+		s.Equal(models.ExportStatusCompleted, export.Status)
+
+		lines := strings.Split(strings.TrimSpace(s.download(export.ID)), "\n")
+		s.Len(lines, 3)
+	})
+
+	t.Run("Export all users as JSON", func(t *testing.T) {
+		export := s.createExport(map[string]any{"entity": "users", "format": "json"})
+		s.Equal(models.ExportStatusCompleted, export.Status)
+
+		var users []*models.User
+		s.NoError(json.ReadJSON(io.NopCloser(strings.NewReader(s.download(export.ID))), &users))
+		s.Len(users, 3)
+	})
+
+	t.Run("Export an empty result as JSON", func(t *testing.T) {
+		export := s.createExport(map[string]any{"entity": "posts", "format": "json", "filters": map[string]any{"user_id": uuid.NewString()}})
+		s.Equal(int64(0), export.Total)
+		s.Equal("[]", strings.TrimSpace(s.download(export.ID)))
+	})
+
+	t.Run("Reject filters of another entity", func(t *testing.T) {
+		body, _ := json.WriteJSON(map[string]any{"entity": "users", "format": "csv", "filters": map[string]any{"user_id": s.users[0].ID}})
+		resp, err := s.server.Client().Post(s.server.URL+"/api/v1/exports", "application/json", bytes.NewBuffer(body))
+		s.NoError(err)
+		defer resp.Body.Close()
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Reject unknown formats", func(t *testing.T) {
+		body, _ := json.WriteJSON(map[string]any{"entity": "users", "format": "xml"})
+		resp, err := s.server.Client().Post(s.server.URL+"/api/v1/exports", "application/json", bytes.NewBuffer(body))
+		s.NoError(err)
+		defer resp.Body.Close()
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Download an export that is not ready", func(t *testing.T) {
+		export := &models.Export{ID: uuid.NewString(), Entity: "users", Format: "csv", Status: models.ExportStatusRunning}
+		s.NoError(s.db.Create(export).Error)
+
+		resp, err := s.server.Client().Get(s.server.URL + "/api/v1/exports/" + export.ID + "/download")
+		s.NoError(err)
+		defer resp.Body.Close()
+		s.Equal(http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Get a non-existent export", func(t *testing.T) {
+		resp, err := s.server.Client().Get(s.server.URL + "/api/v1/exports/" + uuid.NewString())
+		s.NoError(err)
+		defer resp.Body.Close()
+		s.Equal(http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestExportHandler(t *testing.T) {
+	suite.Run(t, new(ExportHandlerTestSuite))
+}
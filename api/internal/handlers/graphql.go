@@ -0,0 +1,516 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/dataloader/v7"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/princecee/lema-ai/config"
+	"github.com/princecee/lema-ai/internal/db/models"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/i18n"
+	"github.com/princecee/lema-ai/pkg/json"
+	"github.com/princecee/lema-ai/pkg/response"
+	"github.com/princecee/lema-ai/pkg/validator"
+	"github.com/rs/zerolog"
+)
+
+//go:embed schema.graphql
+var graphQLSchema string
+
+const (
+	// graphQLMaxLimit bounds the page size of users and of the posts of
+	// each user, and so the rows a single query can load.
+	graphQLMaxLimit = 100
+	graphQLMaxDepth = 10
+)
+
+// GraphQLPostService is a PostService that can also load the posts of
+// several users at once.
+type GraphQLPostService interface {
+	PostService
+	GetPostsByUsers(ctx context.Context, userIds []string, limit, offset int) (map[string][]*models.Post, error)
+}
+
+type GraphQLHandler struct {
+	schema      *graphql.Schema
+	postService GraphQLPostService
+	config      *config.Config
+	logger      zerolog.Logger
+}
+
+func NewGraphQLHandler(userService UserService, postService GraphQLPostService, cfg *config.Config, l zerolog.Logger) *GraphQLHandler {
+	schema := graphql.MustParseSchema(graphQLSchema, &graphQLResolver{userService, postService},
+		graphql.UseFieldResolvers(),
+		graphql.MaxDepth(graphQLMaxDepth),
+		// Resolvers waiting on the posts loader hold a slot until the batch
+		// runs, so a full page of users must fit to be loaded together.
+		graphql.MaxParallelism(graphQLMaxLimit),
+	)
+	return &GraphQLHandler{schema, postService, cfg, l}
+}
+
+type graphQLRequest struct {
+	Query         string         `json:"query" validate:"required"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// graphQLResponse documents the body of GraphQL responses, which is sent
+// without the Response envelope.
+type graphQLResponse struct {
+	Data   map[string]any `json:"data"`
+	Errors []graphQLError `json:"errors"`
+}
+
+type graphQLError struct {
+	Message    string                 `json:"message"`
+	Path       []any                  `json:"path"`
+	Extensions graphQLErrorExtensions `json:"extensions"`
+}
+
+type graphQLErrorExtensions struct {
+	Code       apperror.Code        `json:"code"`
+	Violations []apperror.Violation `json:"violations"`
+}
+
+// Query executes a GraphQL query or mutation. Errors raised by resolvers
+// are reported in the errors of the response with a 200 status, carrying
+// the error code and violations in their extensions.
+func (h *GraphQLHandler) Query(w http.ResponseWriter, r *http.Request) {
+	data := new(graphQLRequest)
+	err := json.ReadJSONStrict(r.Body, data)
+	defer r.Body.Close()
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	if err := validator.ValidateData(r.Context(), data); err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	ctx := withPostsLoader(r.Context(), newPostsLoader(h.postService))
+	result := h.schema.Exec(ctx, data.Query, data.OperationName, data.Variables)
+
+	locale := i18n.LocaleFromContext(r.Context())
+	for _, qErr := range result.Errors {
+		if qErr.ResolverError == nil {
+			// Syntax and validation errors of the query itself.
+			continue
+		}
+
+		appErr := apperror.FromError(qErr.ResolverError)
+		if appErr.Code == apperror.CodeInternal {
+			h.logger.Error().Err(qErr.ResolverError).Interface("path", qErr.Path).Msg("graphql resolver failed")
+		}
+
+		appErr = appErr.Localize(locale)
+		qErr.Message = appErr.Message
+		qErr.Extensions = map[string]any{"code": appErr.Code}
+		if len(appErr.Violations) > 0 {
+			qErr.Extensions["violations"] = appErr.Violations
+		}
+	}
+
+	body, err := json.WriteJSON(result)
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// IsGraphQLQuery reports whether r is a GraphQL request whose operation is
+// a query, for ReadYourWrites not to count it as a write although it is a
+// POST. The body is put back for Query to read.
+func IsGraphQLQuery(r *http.Request) bool {
+	body, err := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+	if err != nil {
+		return false
+	}
+
+	data := new(graphQLRequest)
+	if err := json.ReadJSONStrict(io.NopCloser(bytes.NewReader(body)), data); err != nil {
+		return false
+	}
+	return graphQLOperationType(data.Query, data.OperationName) == "query"
+}
+
+// graphQLOperationType returns the type of the operation of document named
+// operationName, or of its only operation if operationName is empty:
+// "query", "mutation" or "subscription". It only scans the top level of
+// the document, skipping comments and strings, and returns "" when there
+// is no such operation; executing the document reports why.
+func graphQLOperationType(document, operationName string) string {
+	type operation struct{ kind, name string }
+	var operations []operation
+	depth := 0
+	// definition is set between the keyword starting a definition and the
+	// opening brace of its selection set, named until a name was read.
+	definition, named := false, false
+
+	for i := 0; i < len(document); {
+		c := document[i]
+		switch {
+		case c == '#':
+			for i < len(document) && document[i] != '\n' && document[i] != '\r' {
+				i++
+			}
+		case c == '"':
+			i = skipGraphQLString(document, i)
+		case c == '{' || c == '(' || c == '[':
+			if depth == 0 && c == '{' {
+				if !definition {
+					operations = append(operations, operation{kind: "query"})
+				}
+				definition = false
+			}
+			depth++
+			i++
+		case c == '}' || c == ')' || c == ']':
+			depth--
+			i++
+		case c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z':
+			start := i
+			for i < len(document) && (document[i] == '_' || 'a' <= document[i] && document[i] <= 'z' ||
+				'A' <= document[i] && document[i] <= 'Z' || '0' <= document[i] && document[i] <= '9') {
+				i++
+			}
+			if depth > 0 {
+				continue
+			}
+			switch name := document[start:i]; {
+			case !definition && (name == "query" || name == "mutation" || name == "subscription"):
+				operations = append(operations, operation{kind: name})
+				definition, named = true, false
+			case !definition && name == "fragment":
+				definition, named = true, true
+			case definition && !named:
+				operations[len(operations)-1].name = name
+				named = true
+			}
+		default:
+			if depth == 0 && definition && c != ' ' && c != '\t' && c != '\n' && c != '\r' && c != ',' {
+				// Variables and directives come after the name.
+				named = true
+			}
+			i++
+		}
+	}
+
+	for _, op := range operations {
+		if operationName == "" && len(operations) == 1 || operationName != "" && op.name == operationName {
+			return op.kind
+		}
+	}
+	return ""
+}
+
+// skipGraphQLString returns the index following the string or block string
+// starting at document[i].
+func skipGraphQLString(document string, i int) int {
+	if strings.HasPrefix(document[i:], `"""`) {
+		end := strings.Index(document[i+3:], `"""`)
+		if end < 0 {
+			return len(document)
+		}
+		return i + 3 + end + 3
+	}
+
+	for i++; i < len(document); i++ {
+		switch document[i] {
+		case '\\':
+			i++
+		case '"', '\n':
+			return i + 1
+		}
+	}
+	return len(document)
+}
+
+type graphQLResolver struct {
+	userService UserService
+	postService GraphQLPostService
+}
+
+type userPageArgs struct {
+	Page  int32
+	Limit int32
+}
+
+func (r *graphQLResolver) Users(ctx context.Context, args userPageArgs) (*userPageResolver, error) {
+	if args.Page < 1 {
+		return nil, apperror.InvalidParameter("page", "invalid page number")
+	}
+	if args.Limit < 1 || args.Limit > graphQLMaxLimit {
+		return nil, apperror.InvalidParameter("limit", "invalid limit number")
+	}
+
+	result, err := r.userService.GetUsers(ctx, int(args.Page), int(args.Limit))
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*userResolver, len(result.Users))
+	for i, u := range result.Users {
+		users[i] = &userResolver{u}
+	}
+	return &userPageResolver{
+		Users:      users,
+		Count:      int32(result.Count),
+		TotalPages: int32(result.TotalPages),
+		Page:       int32(result.Page),
+		Limit:      int32(result.Limit),
+		HasNext:    result.HasNext,
+		HasPrev:    result.HasPrev,
+	}, nil
+}
+
+func (r *graphQLResolver) User(args struct{ ID graphql.ID }) (*userResolver, error) {
+	userId := string(args.ID)
+	if !validator.IsValidUUID(userId) {
+		return nil, apperror.InvalidParameter("id", "Invalid user ID")
+	}
+
+	user, err := r.userService.GetUser(userId)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &userResolver{user}, nil
+}
+
+func (r *graphQLResolver) Post(args struct{ ID graphql.ID }) (*postResolver, error) {
+	postId := string(args.ID)
+	if !validator.IsValidUUID(postId) {
+		return nil, apperror.InvalidParameter("id", "Invalid post ID")
+	}
+
+	post, err := r.postService.GetPost(postId)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &postResolver{post}, nil
+}
+
+type createPostArgs struct {
+	Input struct {
+		UserID graphql.ID
+		Title  string
+		Body   string
+	}
+}
+
+// CreatePost validates its input like POST /api/v1/posts, so violations
+// name the fields of CreatePostInput.
+func (r *graphQLResolver) CreatePost(ctx context.Context, args createPostArgs) (*postResolver, error) {
+	data := &createPostData{
+		Title:  args.Input.Title,
+		Body:   args.Input.Body,
+		UserID: string(args.Input.UserID),
+	}
+	if err := validator.ValidateData(ctx, data); err != nil {
+		return nil, err
+	}
+
+	post := &models.Post{
+		ID:     uuid.NewString(),
+		Title:  data.Title,
+		Body:   data.Body,
+		UserID: data.UserID,
+	}
+	if err := r.postService.CreatePost(post); err != nil {
+		return nil, err
+	}
+	return &postResolver{post}, nil
+}
+
+func (r *graphQLResolver) DeletePost(args struct{ ID graphql.ID }) (bool, error) {
+	postId := string(args.ID)
+	if !validator.IsValidUUID(postId) {
+		return false, apperror.InvalidParameter("id", "Invalid post ID")
+	}
+
+	if err := r.postService.DeletePost(postId); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+type userPageResolver struct {
+	Users      []*userResolver
+	Count      int32
+	TotalPages int32
+	Page       int32
+	Limit      int32
+	HasNext    bool
+	HasPrev    bool
+}
+
+type userResolver struct {
+	user *models.User
+}
+
+func (r *userResolver) ID() graphql.ID {
+	return graphql.ID(r.user.ID)
+}
+
+func (r *userResolver) Name() string {
+	return r.user.Name
+}
+
+func (r *userResolver) Email() string {
+	return r.user.Email
+}
+
+func (r *userResolver) Username() string {
+	return r.user.Username
+}
+
+func (r *userResolver) Phone() string {
+	return r.user.Phone
+}
+
+func (r *userResolver) Address() *addressResolver {
+	return &addressResolver{&r.user.Address}
+}
+
+type postsArgs struct {
+	Limit  int32
+	Offset int32
+}
+
+// Posts loads a page of the user's posts through the request's posts
+// loader, which batches the users of a listing into one query.
+func (r *userResolver) Posts(ctx context.Context, args postsArgs) ([]*postResolver, error) {
+	if args.Limit < 1 || args.Limit > graphQLMaxLimit {
+		return nil, apperror.InvalidParameter("limit", "invalid limit number")
+	}
+	if args.Offset < 0 {
+		return nil, apperror.InvalidParameter("offset", "invalid offset number")
+	}
+
+	key := postsKey{UserID: r.user.ID, Limit: int(args.Limit), Offset: int(args.Offset)}
+	posts, err := postsLoaderFromContext(ctx).Load(ctx, key)()
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*postResolver, len(posts))
+	for i, p := range posts {
+		resolvers[i] = &postResolver{p}
+	}
+	return resolvers, nil
+}
+
+type addressResolver struct {
+	address *models.Address
+}
+
+func (r *addressResolver) ID() graphql.ID {
+	return graphql.ID(r.address.ID)
+}
+
+func (r *addressResolver) Street() string {
+	return r.address.Street
+}
+
+func (r *addressResolver) City() string {
+	return r.address.City
+}
+
+func (r *addressResolver) State() string {
+	return r.address.State
+}
+
+func (r *addressResolver) Zipcode() string {
+	return r.address.Zipcode
+}
+
+type postResolver struct {
+	post *models.Post
+}
+
+func (r *postResolver) ID() graphql.ID {
+	return graphql.ID(r.post.ID)
+}
+
+func (r *postResolver) UserID() graphql.ID {
+	return graphql.ID(r.post.UserID)
+}
+
+func (r *postResolver) Title() string {
+	return r.post.Title
+}
+
+func (r *postResolver) Body() string {
+	return r.post.Body
+}
+
+func (r *postResolver) CreatedAt() string {
+	return r.post.CreatedAt
+}
+
+// postsKey identifies a page of a user's posts.
+type postsKey struct {
+	UserID string
+	Limit  int
+	Offset int
+}
+
+type postsLoader = dataloader.Loader[postsKey, []*models.Post]
+
+type postsLoaderKey struct{}
+
+// newPostsLoader returns a loader collecting the posts pages requested
+// while a query runs. Each batch loads the users asking for the same page
+// in one query.
+func newPostsLoader(postService GraphQLPostService) *postsLoader {
+	return dataloader.NewBatchedLoader(func(ctx context.Context, keys []postsKey) []*dataloader.Result[[]*models.Post] {
+		type page struct{ limit, offset int }
+		pages := map[page][]int{}
+		for i, key := range keys {
+			p := page{key.Limit, key.Offset}
+			pages[p] = append(pages[p], i)
+		}
+
+		results := make([]*dataloader.Result[[]*models.Post], len(keys))
+		for p, indexes := range pages {
+			userIds := make([]string, len(indexes))
+			for j, i := range indexes {
+				userIds[j] = keys[i].UserID
+			}
+
+			posts, err := postService.GetPostsByUsers(ctx, userIds, p.limit, p.offset)
+			for _, i := range indexes {
+				results[i] = &dataloader.Result[[]*models.Post]{Data: posts[keys[i].UserID], Error: err}
+			}
+		}
+		return results
+	})
+}
+
+func withPostsLoader(ctx context.Context, l *postsLoader) context.Context {
+	return context.WithValue(ctx, postsLoaderKey{}, l)
+}
+
+func postsLoaderFromContext(ctx context.Context) *postsLoader {
+	return ctx.Value(postsLoaderKey{}).(*postsLoader)
+}
@@ -0,0 +1,273 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/princecee/lema-ai/config"
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/internal/db/repositories"
+	"github.com/princecee/lema-ai/internal/handlers"
+	"github.com/princecee/lema-ai/internal/middlewares"
+	"github.com/princecee/lema-ai/internal/routes"
+	"github.com/princecee/lema-ai/internal/services"
+	"github.com/princecee/lema-ai/pkg/json"
+	"github.com/princecee/lema-ai/pkg/store"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type gqlResponse struct {
+	Data   map[string]any `json:"data"`
+	Errors []struct {
+		Message    string `json:"message"`
+		Extensions struct {
+			Code       string `json:"code"`
+			Violations []struct {
+				Field string `json:"field"`
+				Code  string `json:"code"`
+			} `json:"violations"`
+		} `json:"extensions"`
+	} `json:"errors"`
+}
+
+type GraphQLHandlerTestSuite struct {
+	suite.Suite
+	db          *gorm.DB
+	server      *httptest.Server
+	postQueries atomic.Int64
+	users       []*models.User
+}
+
+func (s *GraphQLHandlerTestSuite) SetupSuite() {
+	cfg := config.NewConfig("test", "silent")
+	cfg.DSN = "file:graphql?mode=memory&cache=shared"
+	var logger zerolog.Logger
+
+	db := database.GetDBConn(cfg.DSN, cfg.REPLICA_DSNS, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
+
+	err := database.Migrate(db)
+	if err != nil {
+		s.Fail(err.Error())
+	}
+	s.db = db
+
+	// Count the queries reading posts to check that they are batched.
+	err = db.Callback().Query().After("gorm:query").Register("count_post_queries", func(tx *gorm.DB) {
+		// Subqueries are built in dry run mode and not executed on their own.
+		if !tx.DryRun && strings.Contains(tx.Statement.SQL.String(), "posts") {
+			s.postQueries.Add(1)
+		}
+	})
+	if err != nil {
+		s.Fail(err.Error())
+	}
+
+	userRepo := repositories.NewUserRepository(db)
+	postRepo := repositories.NewPostRepository(db)
+	for i := range 3 {
+		id := uuid.NewString()
+		user := &models.User{
+			ID:       id,
+			Name:     "Jane Doe",
+			Username: id,
+			Email:    id + "@example.com",
+			Phone:    fmt.Sprintf("+1555100000%d", i),
+			Address:  models.Address{ID: uuid.NewString(), Street: "1 Main St", City: "Springfield", State: "IL", Zipcode: "62701"},
+		}
+		if err := userRepo.CreateUser(context.Background(), user); err != nil {
+			s.Fail(err.Error())
+		}
+		s.users = append(s.users, user)
+
+		for j := range 3 {
+			post := &models.Post{
+				ID:        uuid.NewString(),
+				UserID:    id,
+				Title:     fmt.Sprintf("Post %d", j),
+				Body:      "Hello",
+				CreatedAt: fmt.Sprintf("2024-01-0%dT00:00:00Z", j+1),
+			}
+			if err := postRepo.CreatePost(context.Background(), post); err != nil {
+				s.Fail(err.Error())
+			}
+		}
+	}
+
+	userService := services.NewUserService(userRepo)
+	postService := services.NewPostService(postRepo)
+
+	r := chi.NewRouter()
+	r.Use(middlewares.Locale)
+	r.Mount("/api/v1/graphql", routes.AddGraphQLRoutes(userService, postService, store.NewMemoryStore(), cfg, logger))
+	s.server = httptest.NewServer(r)
+}
+
+func (s *GraphQLHandlerTestSuite) TearDownSuite() {
+	s.NoError(database.Close(s.db))
+	s.server.Close()
+}
+
+func (s *GraphQLHandlerTestSuite) query(query string, variables map[string]any, header http.Header) gqlResponse {
+	body, err := json.WriteJSON(map[string]any{"query": query, "variables": variables})
+	s.Require().NoError(err)
+
+	req, err := http.NewRequest(http.MethodPost, s.server.URL+"/api/v1/graphql", bytes.NewReader(body))
+	s.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := s.server.Client().Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	var result gqlResponse
+	s.Require().NoError(json.ReadJSON(resp.Body, &result))
+	return result
+}
+
+func (s *GraphQLHandlerTestSuite) TestUsersWithPosts() {
+	s.postQueries.Store(0)
+	result := s.query(`{
+		users(page: 1, limit: 10) {
+			count
+			hasNext
+			users { id address { city } posts(limit: 2, offset: 1) { title userId } }
+		}
+	}`, nil, nil)
+	s.Require().Empty(result.Errors)
+
+	page := result.Data["users"].(map[string]any)
+	s.EqualValues(3, page["count"])
+	s.Equal(false, page["hasNext"])
+
+	users := page["users"].([]any)
+	s.Len(users, 3)
+	for _, u := range users {
+		user := u.(map[string]any)
+		s.Equal("Springfield", user["address"].(map[string]any)["city"])
+
+		posts := user["posts"].([]any)
+		s.Require().Len(posts, 2)
+		s.Equal("Post 1", posts[0].(map[string]any)["title"])
+		s.Equal("Post 2", posts[1].(map[string]any)["title"])
+		s.Equal(user["id"], posts[0].(map[string]any)["userId"])
+	}
+
+	// The posts of all users are loaded in a single query.
+	s.EqualValues(1, s.postQueries.Load())
+}
+
+func (s *GraphQLHandlerTestSuite) TestUserAndPost() {
+	user := s.users[0]
+	result := s.query(`query($id: ID!) { user(id: $id) { name posts { id } } }`, map[string]any{"id": user.ID}, nil)
+	s.Require().Empty(result.Errors)
+	s.Equal("Jane Doe", result.Data["user"].(map[string]any)["name"])
+
+	posts := result.Data["user"].(map[string]any)["posts"].([]any)
+	s.Len(posts, 3)
+	postId := posts[0].(map[string]any)["id"]
+
+	result = s.query(`query($id: ID!) { post(id: $id) { title } }`, map[string]any{"id": postId}, nil)
+	s.Require().Empty(result.Errors)
+	s.Equal("Post 0", result.Data["post"].(map[string]any)["title"])
+
+	result = s.query(`query($id: ID!) { user(id: $id) { name } }`, map[string]any{"id": uuid.NewString()}, nil)
+	s.Empty(result.Errors)
+	s.Nil(result.Data["user"])
+}
+
+func (s *GraphQLHandlerTestSuite) TestMutations() {
+	user := s.users[1]
+	result := s.query(`mutation($input: CreatePostInput!) { createPost(input: $input) { id title userId } }`, map[string]any{
+		"input": map[string]any{"userId": user.ID, "title": "  New post ", "body": "Body"},
+	}, nil)
+	s.Require().Empty(result.Errors)
+
+	post := result.Data["createPost"].(map[string]any)
+	s.Equal("New post", post["title"])
+	s.Equal(user.ID, post["userId"])
+
+	result = s.query(`mutation($id: ID!) { deletePost(id: $id) }`, map[string]any{"id": post["id"]}, nil)
+	s.Require().Empty(result.Errors)
+	s.Equal(true, result.Data["deletePost"])
+
+	result = s.query(`query($id: ID!) { post(id: $id) { id } }`, map[string]any{"id": post["id"]}, nil)
+	s.Nil(result.Data["post"])
+}
+
+func (s *GraphQLHandlerTestSuite) TestErrors() {
+	result := s.query(`mutation { createPost(input: {userId: "nope", title: " ", body: "Body"}) { id } }`, nil, nil)
+	s.Require().Len(result.Errors, 1)
+	s.Equal("validation_failed", result.Errors[0].Extensions.Code)
+
+	fields := []string{}
+	for _, v := range result.Errors[0].Extensions.Violations {
+		fields = append(fields, v.Field)
+	}
+	s.ElementsMatch([]string{"title", "userId"}, fields)
+
+	result = s.query(`{ users(limit: 1000) { count } }`, nil, http.Header{"Accept-Language": {"fr"}})
+	s.Require().Len(result.Errors, 1)
+	s.Equal("invalid_parameter", result.Errors[0].Extensions.Code)
+	s.Equal("limite invalide", result.Errors[0].Message)
+
+	result = s.query(`{ users { unknown } }`, nil, nil)
+	s.Require().Len(result.Errors, 1)
+	s.Nil(result.Data)
+}
+
+func (s *GraphQLHandlerTestSuite) TestInvalidRequest() {
+	resp, err := s.server.Client().Post(s.server.URL+"/api/v1/graphql", "application/json", strings.NewReader(`{"query":""}`))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *GraphQLHandlerTestSuite) TestIsGraphQLQuery() {
+	tests := []struct {
+		body  string
+		query bool
+	}{
+		{`{"query":"{ users { id } }"}`, true},
+		{`{"query":"query Users($limit: Int) { users(limit: $limit) { id } }"}`, true},
+		{`{"query":"mutation { deletePost(id: \"1\") }"}`, false},
+		{`{"query":"mutation Delete { deletePost(id: \"1\") }"}`, false},
+		{`{"query":"# mutation\n{ users(search: \"mutation {\") { id } }"}`, true},
+		{`{"query":"{ users(search: \"\\\"}\") { id } }"}`, true},
+		{`{"query":"{ users(search: \"\"\"mutation }\"\"\") { id } }"}`, true},
+		{`{"query":"fragment mutation on User { id } query { users { ...mutation } }"}`, true},
+		{`{"query":"query Users { users { id } } mutation Delete { deletePost(id: \"1\") }","operationName":"Users"}`, true},
+		{`{"query":"query Users { users { id } } mutation Delete { deletePost(id: \"1\") }","operationName":"Delete"}`, false},
+		{`{"query":"query Users { users { id } } mutation Delete { deletePost(id: \"1\") }"}`, false},
+		{`{"query":"subscription { posts { id } }"}`, false},
+		{`{"query":`, false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/graphql", strings.NewReader(tt.body))
+		s.Equal(tt.query, handlers.IsGraphQLQuery(req), tt.body)
+
+		body, err := io.ReadAll(req.Body)
+		s.NoError(err)
+		s.Equal(tt.body, string(body))
+	}
+}
+
+func TestGraphQLHandler(t *testing.T) {
+	suite.Run(t, new(GraphQLHandlerTestSuite))
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/princecee/lema-ai/config"
+	"github.com/princecee/lema-ai/pkg/response"
+	"github.com/rs/zerolog"
+)
+
+const (
+	healthStatusUp   = "up"
+	healthStatusDown = "down"
+)
+
+// HealthCheck is a named dependency check run by the readiness probe.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type CheckResult struct {
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+type ReadinessResult struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type HealthHandler struct {
+	checks []HealthCheck
+	ready  atomic.Bool
+	config *config.Config
+	logger zerolog.Logger
+}
+
+func NewHealthHandler(checks []HealthCheck, cfg *config.Config, l zerolog.Logger) *HealthHandler {
+	h := &HealthHandler{checks: checks, config: cfg, logger: l}
+	h.ready.Store(true)
+	return h
+}
+
+// SetReady toggles whether the readiness probe may report the service as
+// ready. It is flipped off at the start of a graceful shutdown so load
+// balancers stop routing new traffic before the server closes.
+func (h *HealthHandler) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	resp := response.Response[any]{}
+	resp.Message = "Service is alive"
+	resp.Data = map[string]string{"status": healthStatusUp}
+	response.SendResponse(w, resp, nil)
+}
+
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	resp := response.Response[any]{}
+	headers := map[string]string{"Cache-Control": "no-store"}
+
+	if !h.ready.Load() {
+		resp.Message = "Service is shutting down"
+		resp.Data = ReadinessResult{Status: healthStatusDown, Checks: map[string]CheckResult{}}
+		resp.Success = new(bool)
+		resp.StatusCode = intPtr(http.StatusServiceUnavailable)
+		response.SendResponse(w, resp, headers)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.config.READINESS_TIMEOUT)
+	defer cancel()
+
+	result := ReadinessResult{Status: healthStatusUp, Checks: map[string]CheckResult{}}
+	for _, c := range h.checks {
+		start := time.Now()
+		err := c.Check(ctx)
+
+		check := CheckResult{Status: healthStatusUp, Duration: time.Since(start).String()}
+		if err != nil {
+			h.logger.Warn().Err(err).Str("check", c.Name).Msg("readiness check failed")
+			check.Status = healthStatusDown
+			check.Error = err.Error()
+			result.Status = healthStatusDown
+		}
+		result.Checks[c.Name] = check
+	}
+
+	resp.Data = result
+	if result.Status != healthStatusUp {
+		resp.Message = "Service is not ready"
+		resp.Success = new(bool)
+		resp.StatusCode = intPtr(http.StatusServiceUnavailable)
+		response.SendResponse(w, resp, headers)
+		return
+	}
+
+	resp.Message = "Service is ready"
+	response.SendResponse(w, resp, headers)
+}
+
+func intPtr(i int) *int {
+	return &i
+}
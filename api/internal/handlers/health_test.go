@@ -0,0 +1,139 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/princecee/lema-ai/config"
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/handlers"
+	"github.com/princecee/lema-ai/pkg/json"
+	"github.com/princecee/lema-ai/pkg/response"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type HealthHandlerTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	cfg     *config.Config
+	handler *handlers.HealthHandler
+	server  *httptest.Server
+}
+
+func (s *HealthHandlerTestSuite) SetupSuite() {
+	cfg := config.NewConfig("test", "silent")
+	cfg.DSN = "file::memory:?cache=shared"
+	var logger zerolog.Logger
+
+	db := database.GetDBConn(cfg.DSN, cfg.REPLICA_DSNS, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
+
+	s.db = db
+	s.cfg = cfg
+	s.handler = handlers.NewHealthHandler([]handlers.HealthCheck{
+		{Name: "database", Check: func(ctx context.Context) error { return database.Ping(ctx, db) }},
+		{Name: "migrations", Check: func(ctx context.Context) error { return database.CheckMigrations(ctx, db) }},
+	}, cfg, logger)
+
+	r := chi.NewRouter()
+	r.Get("/healthz", s.handler.Liveness)
+	r.Get("/readyz", s.handler.Readiness)
+
+	s.server = httptest.NewServer(r)
+}
+
+func (s *HealthHandlerTestSuite) TearDownSuite() {
+	s.NoError(database.Close(s.db))
+	s.server.Close()
+}
+
+func (s *HealthHandlerTestSuite) TestHealthHandler() {
+	t := s.T()
+
+	t.Run("Liveness", func(t *testing.T) {
+		resp, err := s.server.Client().Get(s.server.URL + "/healthz")
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+		defer resp.Body.Close()
+
+		response := response.Response[map[string]string]{}
+		_ = json.ReadJSON(resp.Body, &response)
+		s.Equal(true, *response.Success)
+		s.Equal("up", response.Data["status"])
+	})
+
+	t.Run("Readiness before migrations", func(t *testing.T) {
+		resp, err := s.server.Client().Get(s.server.URL + "/readyz")
+		s.NoError(err)
+		s.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+		defer resp.Body.Close()
+
+		response := response.Response[handlers.ReadinessResult]{}
+		_ = json.ReadJSON(resp.Body, &response)
+		s.Equal(false, *response.Success)
+		s.Equal("Service is not ready", response.Message)
+		s.Equal("up", response.Data.Checks["database"].Status)
+		s.Equal("down", response.Data.Checks["migrations"].Status)
+		s.NotEmpty(response.Data.Checks["migrations"].Error)
+	})
+
+	t.Run("Readiness after migrations", func(t *testing.T) {
+		s.NoError(database.Migrate(s.db))
+
+		resp, err := s.server.Client().Get(s.server.URL + "/readyz")
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+		defer resp.Body.Close()
+
+		response := response.Response[handlers.ReadinessResult]{}
+		_ = json.ReadJSON(resp.Body, &response)
+		s.Equal(true, *response.Success)
+		s.Equal("Service is ready", response.Message)
+		s.Equal("up", response.Data.Status)
+		s.Len(response.Data.Checks, 2)
+	})
+
+	t.Run("Readiness with failing check", func(t *testing.T) {
+		h := handlers.NewHealthHandler([]handlers.HealthCheck{
+			{Name: "broken", Check: func(ctx context.Context) error { return errors.New("unreachable") }},
+		}, s.cfg, zerolog.Nop())
+
+		rec := httptest.NewRecorder()
+		h.Readiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		s.Equal(http.StatusServiceUnavailable, rec.Code)
+
+		response := response.Response[handlers.ReadinessResult]{}
+		_ = json.ReadJSON(rec.Result().Body, &response)
+		s.Equal("down", response.Data.Checks["broken"].Status)
+		s.Equal("unreachable", response.Data.Checks["broken"].Error)
+	})
+
+	t.Run("Readiness while shutting down", func(t *testing.T) {
+		s.handler.SetReady(false)
+		defer s.handler.SetReady(true)
+
+		resp, err := s.server.Client().Get(s.server.URL + "/readyz")
+		s.NoError(err)
+		s.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+		defer resp.Body.Close()
+
+		response := response.Response[handlers.ReadinessResult]{}
+		_ = json.ReadJSON(resp.Body, &response)
+		s.Equal(false, *response.Success)
+		s.Equal("Service is shutting down", response.Message)
+
+		resp, err = s.server.Client().Get(s.server.URL + "/healthz")
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+		defer resp.Body.Close()
+	})
+}
+
+func TestHealthHandler(t *testing.T) {
+	suite.Run(t, new(HealthHandlerTestSuite))
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/princecee/lema-ai/config"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/pkg/csv"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/importer"
+	"github.com/princecee/lema-ai/pkg/json"
+	"github.com/princecee/lema-ai/pkg/response"
+	"github.com/princecee/lema-ai/pkg/validator"
+	"github.com/rs/zerolog"
+)
+
+type ImportService interface {
+	ImportUsers(ctx context.Context, rows []importer.Row[*models.User], session *importer.Session) error
+	ImportPosts(ctx context.Context, rows []importer.Row[*models.Post], session *importer.Session) error
+}
+
+type ImportHandler struct {
+	importService ImportService
+	config        *config.Config
+	logger        zerolog.Logger
+}
+
+func NewImportHandler(importService ImportService, cfg *config.Config, l zerolog.Logger) *ImportHandler {
+	return &ImportHandler{importService, cfg, l}
+}
+
+// ImportQuery holds the options of an import.
+type ImportQuery struct {
+	DryRun bool `json:"dry_run"`
+	Upsert bool `json:"upsert"`
+}
+
+type importPostData struct {
+	ID     string `json:"id" validate:"omitempty,uuid"`
+	Title  string `json:"title" mod:"trim" validate:"required,notblank,max=200,nobannedwords"`
+	Body   string `json:"body" mod:"trim" validate:"required,notblank,max=10000,nobannedwords"`
+	UserID string `json:"user_id" validate:"required,uuid"`
+}
+
+func (h *ImportHandler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	toUser := func(data *createUserData) *models.User {
+		return &models.User{
+			Name:     data.Name,
+			Username: data.Username,
+			Email:    data.Email,
+			Phone:    data.Phone,
+			Address: models.Address{
+				Street:  data.Address.Street,
+				City:    data.Address.City,
+				State:   data.Address.State,
+				Zipcode: data.Address.Zipcode,
+			},
+		}
+	}
+
+	runImport(h, w, r, toUser, h.importService.ImportUsers)
+}
+
+func (h *ImportHandler) ImportPosts(w http.ResponseWriter, r *http.Request) {
+	toPost := func(data *importPostData) *models.Post {
+		return &models.Post{ID: data.ID, Title: data.Title, Body: data.Body, UserID: data.UserID}
+	}
+
+	runImport(h, w, r, toPost, h.importService.ImportPosts)
+}
+
+// runImport decodes the CSV or NDJSON request body row by row, validates
+// each row and hands valid rows to importBatch in batches. Rows that fail
+// to decode or validate are reported without reaching the database.
+func runImport[D any, M any](
+	h *ImportHandler,
+	w http.ResponseWriter,
+	r *http.Request,
+	toModel func(*D) M,
+	importBatch func(ctx context.Context, rows []importer.Row[M], session *importer.Session) error,
+) {
+	resp := response.Response[any]{}
+	defer r.Body.Close()
+
+	query, err := parseImportQuery(r)
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	read, err := newRowDecoder(r, reflect.TypeOf((*D)(nil)).Elem())
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	session := importer.NewSession(importer.Options{DryRun: query.DryRun, Upsert: query.Upsert})
+	batchSize := max(h.config.IMPORT_BATCH_SIZE, 1)
+	batch := make([]importer.Row[M], 0, batchSize)
+
+	for {
+		data := new(D)
+		line, err := read(data)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var appErr *apperror.AppError
+			if !errors.As(err, &appErr) || appErr.Status == http.StatusRequestEntityTooLarge {
+				response.SendError(w, r, readError(err))
+				return
+			}
+			session.Fail(ctx, line, err)
+			continue
+		}
+
+		if err := validator.ValidateData(ctx, data); err != nil {
+			session.Fail(ctx, line, err)
+			continue
+		}
+
+		batch = append(batch, importer.Row[M]{Line: line, Value: toModel(data)})
+		if len(batch) == batchSize {
+			if err := importBatch(ctx, batch, session); err != nil {
+				response.SendError(w, r, err)
+				return
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if len(batch) > 0 {
+		if err := importBatch(ctx, batch, session); err != nil {
+			response.SendError(w, r, err)
+			return
+		}
+	}
+
+	resp.Message = "Import completed"
+	if query.DryRun {
+		resp.Message = "Dry run completed, nothing was saved"
+	}
+	resp.Data = session.Report()
+	response.SendResponse(w, resp, nil)
+}
+
+func parseImportQuery(r *http.Request) (ImportQuery, error) {
+	query := ImportQuery{}
+	for name, dst := range map[string]*bool{"dry_run": &query.DryRun, "upsert": &query.Upsert} {
+		value := r.URL.Query().Get(name)
+		if value == "" {
+			continue
+		}
+
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return query, apperror.InvalidParameter(name, "{0} must be true or false", name)
+		}
+		*dst = b
+	}
+	return query, nil
+}
+
+// newRowDecoder returns a function decoding the next row of the request body
+// according to its Content-Type.
+func newRowDecoder(r *http.Request, rowType reflect.Type) (func(dst any) (int, error), error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == response.CSVContentType {
+		cr, err := csv.NewReader(r.Body, rowType)
+		if err != nil {
+			return nil, readError(err)
+		}
+		return cr.Read, nil
+	}
+
+	return json.NewRowReader(r.Body).Read, nil
+}
+
+// readError reports a failure to read the request body.
+func readError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		limit := strconv.FormatInt(maxBytesErr.Limit, 10)
+		return apperror.ErrPayloadTooLarge.WithMessage("Request body must not be larger than {0} bytes", limit)
+	}
+	return err
+}
@@ -0,0 +1,244 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/princecee/lema-ai/config"
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/internal/db/repositories"
+	"github.com/princecee/lema-ai/internal/middlewares"
+	"github.com/princecee/lema-ai/internal/routes"
+	"github.com/princecee/lema-ai/internal/services"
+	"github.com/princecee/lema-ai/pkg/importer"
+	"github.com/princecee/lema-ai/pkg/json"
+	"github.com/princecee/lema-ai/pkg/response"
+	"github.com/princecee/lema-ai/pkg/store"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+const importUsersCSVHeader = "name,username,email,phone,address.street,address.city,address.state,address.zipcode\n"
+
+type ImportHandlerTestSuite struct {
+	suite.Suite
+	db       *gorm.DB
+	server   *httptest.Server
+	userRepo *repositories.UserRepository
+	user     *models.User
+}
+
+func (s *ImportHandlerTestSuite) SetupSuite() {
+	cfg := config.NewConfig("test", "silent")
+	cfg.DSN = "file:import?mode=memory&cache=shared"
+	cfg.RATE_LIMIT_IMPORT = config.RateLimit{}
+	cfg.IMPORT_BATCH_SIZE = 2
+	var logger zerolog.Logger
+
+	db := database.GetDBConn(cfg.DSN, cfg.REPLICA_DSNS, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
+
+	err := db.AutoMigrate(&models.User{}, &models.Address{}, &models.Post{}, &models.OutboxEvent{})
+	if err != nil {
+		s.Fail(err.Error())
+	}
+
+	s.db = db
+	s.userRepo = repositories.NewUserRepository(db)
+	postRepo := repositories.NewPostRepository(db)
+	importService := services.NewImportService(database.NewTransactor(db), s.userRepo, postRepo)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s.user = &models.User{
+		ID:       uuid.NewString(),
+		Name:     "Jane Doe",
+		Username: "jane",
+		Email:    "jane@example.com",
+		Phone:    "+15551234567",
+		Address: models.Address{
+			ID:      uuid.NewString(),
+			Street:  "1 Main St",
+			City:    "Springfield",
+			State:   "IL",
+			Zipcode: "62701",
+		},
+	}
+	if err := s.userRepo.CreateUser(ctx, s.user); err != nil {
+		s.Fail(err.Error())
+	}
+
+	r := chi.NewRouter()
+	r.Use(middlewares.Locale)
+	r.Use(middlewares.RequestSize(1 << 10))
+	importRouter := routes.AddImportRoutes(importService, store.NewMemoryStore(), cfg, logger)
+	r.Mount("/api/v1/import", importRouter)
+
+	s.server = httptest.NewServer(r)
+}
+
+func (s *ImportHandlerTestSuite) TearDownSuite() {
+	s.NoError(database.Close(s.db))
+	s.server.Close()
+}
+
+func (s *ImportHandlerTestSuite) postImport(path, contentType, body string) (*http.Response, *importer.Report) {
+	resp, err := s.server.Client().Post(s.server.URL+"/api/v1/import"+path, contentType, strings.NewReader(body))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	result := response.Response[*importer.Report]{}
+	_ = json.ReadJSON(resp.Body, &result)
+	return resp, result.Data
+}
+
+func (s *ImportHandlerTestSuite) countUsers(email string) int64 {
+	var count int64
+	s.db.Model(&models.User{}).Where("email = ?", email).Count(&count)
+	return count
+}
+
+func (s *ImportHandlerTestSuite) TestImportUsers() {
+	t := s.T()
+
+	t.Run("Dry run saves nothing", func(t *testing.T) {
+		body := importUsersCSVHeader +
+			"Ann Lee,ann,ann@example.com,555-222-3333,2 Oak Ave,Portland,OR,97201\n" +
+			"Ann Again,ann2,ANN@example.com,555-222-4444,3 Oak Ave,Portland,OR,97201\n"
+
+		resp, report := s.postImport("/users?dry_run=true", "text/csv", body)
+		s.Equal(http.StatusOK, resp.StatusCode)
+		s.True(report.DryRun)
+		s.Equal(1, report.Created)
+		s.Equal(1, report.Skipped)
+		s.Equal(int64(0), s.countUsers("ann@example.com"))
+	})
+
+	t.Run("Create, skip and fail rows from CSV", func(t *testing.T) {
+		body := importUsersCSVHeader +
+			"Ann Lee,ann,ann@example.com,555-222-3333,2 Oak Ave,Portland,OR,97201\n" +
+			"Jane Again,jane2,jane@example.com,555-222-5555,4 Elm St,Springfield,IL,62701\n" +
+			"Bad Zip,bad,bad@example.com,555-222-6666,5 Pine Rd,Austin,TX,ABCDE\n" +
+			"Bob Ray,bob,bob@example.com,555-222-7777,6 Birch Ln,Denver,CO,80202\n"
+
+		resp, report := s.postImport("/users", "text/csv; charset=utf-8", body)
+		s.Equal(http.StatusOK, resp.StatusCode)
+		s.False(report.DryRun)
+		s.Equal(2, report.Created)
+		s.Equal(1, report.Skipped)
+		s.Equal(1, report.Failed)
+		s.Require().Len(report.Rows, 4)
+
+		s.Equal(importer.StatusCreated, report.Rows[0].Status)
+		s.Equal(2, report.Rows[0].Row)
+		s.Equal(importer.StatusSkipped, report.Rows[1].Status)
+		s.Equal(s.user.ID, report.Rows[1].ID)
+		s.Equal(importer.StatusFailed, report.Rows[2].Status)
+		s.Require().Len(report.Rows[2].Errors, 1)
+		s.Equal("address.zipcode", report.Rows[2].Errors[0].Field)
+		s.Equal(importer.StatusCreated, report.Rows[3].Status)
+
+		s.Equal(int64(1), s.countUsers("ann@example.com"))
+		s.Equal(int64(0), s.countUsers("bad@example.com"))
+		s.Equal(int64(1), s.countUsers("bob@example.com"))
+	})
+
+	t.Run("Upsert by email", func(t *testing.T) {
+		body := importUsersCSVHeader +
+			"Jane Smith,jane,JANE@example.com,555-123-4567,9 New Rd,Chicago,IL,60601\n"
+
+		resp, report := s.postImport("/users?upsert=true", "text/csv", body)
+		s.Equal(http.StatusOK, resp.StatusCode)
+		s.Equal(1, report.Updated)
+		s.Equal(s.user.ID, report.Rows[0].ID)
+
+		user, err := s.userRepo.GetUser(context.Background(), s.user.ID)
+		s.NoError(err)
+		s.Equal("Jane Smith", user.Name)
+		s.Equal("Chicago", user.Address.City)
+		s.Equal(s.user.Address.ID, user.Address.ID)
+	})
+
+	t.Run("Reject an invalid CSV header", func(t *testing.T) {
+		resp, _ := s.postImport("/users", "text/csv", "name,name\nA,B\n")
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Reject an invalid dry_run value", func(t *testing.T) {
+		resp, _ := s.postImport("/users?dry_run=maybe", "text/csv", importUsersCSVHeader)
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Reject unsupported content types", func(t *testing.T) {
+		resp, _ := s.postImport("/users", "application/json", `[{"name":"x"}]`)
+		s.Equal(http.StatusUnsupportedMediaType, resp.StatusCode)
+	})
+
+	t.Run("Reject bodies over the size limit", func(t *testing.T) {
+		row := "Big Row,big,big@example.com,555-222-8888,7 Long St,Austin,TX,73301\n"
+		resp, _ := s.postImport("/users", "text/csv", importUsersCSVHeader+strings.Repeat(row, 20))
+		s.Equal(http.StatusRequestEntityTooLarge, resp.StatusCode)
+	})
+}
+
+func (s *ImportHandlerTestSuite) TestImportPosts() {
+	t := s.T()
+	postId := uuid.NewString()
+	missingUserId := uuid.NewString()
+
+	t.Run("Import posts from NDJSON", func(t *testing.T) {
+		body := `{"id":"` + postId + `","title":"First","body":"Hello","user_id":"` + s.user.ID + `"}` + "\n" +
+			"\n" +
+			`{"title":"Second","body":"World","user_id":"` + s.user.ID + `"}` + "\n" +
+			`{"title":"Orphan","body":"Nobody","user_id":"` + missingUserId + `"}` + "\n" +
+			`{"title":"Broken",` + "\n" +
+			`{"id":"` + postId + `","title":"Duplicate","body":"Again","user_id":"` + s.user.ID + `"}` + "\n"
+
+		resp, report := s.postImport("/posts", "application/x-ndjson", body)
+		s.Equal(http.StatusOK, resp.StatusCode)
+		s.Equal(2, report.Created)
+		s.Equal(1, report.Skipped)
+		s.Equal(2, report.Failed)
+		s.Require().Len(report.Rows, 5)
+
+		s.Equal(postId, report.Rows[0].ID)
+		s.Equal(3, report.Rows[1].Row)
+		s.Equal(importer.StatusFailed, report.Rows[2].Status)
+		s.Equal("User "+missingUserId+" does not exist", report.Rows[2].Message)
+		s.Equal(importer.StatusFailed, report.Rows[3].Status)
+		s.Equal(importer.StatusSkipped, report.Rows[4].Status)
+
+		var count int64
+		s.db.Model(&models.Post{}).Where("user_id = ?", s.user.ID).Count(&count)
+		s.Equal(int64(2), count)
+	})
+
+	t.Run("Localize row messages", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, s.server.URL+"/api/v1/import/posts",
+			strings.NewReader(`{"title":"Orphan","body":"Nobody","user_id":"`+missingUserId+`"}`))
+		s.Require().NoError(err)
+		req.Header.Set("Content-Type", "application/x-ndjson")
+		req.Header.Set("Accept-Language", "fr")
+
+		resp, err := s.server.Client().Do(req)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+
+		result := response.Response[*importer.Report]{}
+		_ = json.ReadJSON(resp.Body, &result)
+		s.Require().Len(result.Data.Rows, 1)
+		s.Equal("L'utilisateur "+missingUserId+" n'existe pas", result.Data.Rows[0].Message)
+	})
+}
+
+func TestImportHandler(t *testing.T) {
+	suite.Run(t, new(ImportHandlerTestSuite))
+}
@@ -0,0 +1,565 @@
+package handlers
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/pkg/importer"
+	"github.com/princecee/lema-ai/pkg/pagination"
+	"github.com/princecee/lema-ai/pkg/response"
+)
+
+// OpenAPI document types. Only the parts of the specification this API
+// uses are modelled.
+type (
+	OpenAPIDocument struct {
+		OpenAPI    string                 `json:"openapi"`
+		Info       OpenAPIInfo            `json:"info"`
+		Paths      map[string]OpenAPIPath `json:"paths"`
+		Components OpenAPIComponents      `json:"components"`
+	}
+
+	OpenAPIInfo struct {
+		Title   string `json:"title"`
+		Version string `json:"version"`
+	}
+
+	// OpenAPIPath maps lower-case HTTP methods to operations.
+	OpenAPIPath map[string]*OpenAPIOperation
+
+	OpenAPIOperation struct {
+		OperationID string                      `json:"operationId"`
+		Summary     string                      `json:"summary"`
+		Tags        []string                    `json:"tags"`
+		Parameters  []OpenAPIParameter          `json:"parameters,omitempty"`
+		RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+		Responses   map[string]*OpenAPIResponse `json:"responses"`
+	}
+
+	OpenAPIParameter struct {
+		Name     string  `json:"name"`
+		In       string  `json:"in"`
+		Required bool    `json:"required"`
+		Schema   *Schema `json:"schema"`
+	}
+
+	OpenAPIRequestBody struct {
+		Required bool                        `json:"required"`
+		Content  map[string]OpenAPIMediaType `json:"content"`
+	}
+
+	OpenAPIResponse struct {
+		Description string                      `json:"description"`
+		Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+	}
+
+	OpenAPIMediaType struct {
+		Schema *Schema `json:"schema"`
+	}
+
+	OpenAPIComponents struct {
+		Schemas map[string]*Schema `json:"schemas"`
+	}
+
+	Schema struct {
+		Ref                  string             `json:"$ref,omitempty"`
+		Type                 string             `json:"type,omitempty"`
+		Format               string             `json:"format,omitempty"`
+		Enum                 []string           `json:"enum,omitempty"`
+		Pattern              string             `json:"pattern,omitempty"`
+		MaxLength            *int               `json:"maxLength,omitempty"`
+		Properties           map[string]*Schema `json:"properties,omitempty"`
+		Required             []string           `json:"required,omitempty"`
+		Items                *Schema            `json:"items,omitempty"`
+		AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+		AllOf                []*Schema          `json:"allOf,omitempty"`
+	}
+)
+
+// apiOperation describes a route for the generated OpenAPI document. Query
+// parameters and request bodies are derived from the handler payload types.
+type apiOperation struct {
+	Method   string
+	Path     string
+	ID       string
+	Summary  string
+	Tag      string
+	Query    any
+	Params   []OpenAPIParameter
+	Request  any
+	Response any
+	// Unwrapped marks responses sent as Response is, without the Response
+	// envelope.
+	Unwrapped bool
+	// Rows is the row type of listings that can also be streamed as CSV or
+	// NDJSON.
+	Rows any
+	// Upload is the row type of request bodies sent as CSV or NDJSON.
+	Upload any
+	// Status is the success status, 200 by default. 101 marks a WebSocket
+	// upgrade.
+	Status int
+	// File is the media type of a file download or event stream,
+	// replacing the JSON response.
+	File   string
+	Errors []int
+}
+
+var apiOperations = []apiOperation{
+	{
+		Method: http.MethodGet, Path: "/healthz", ID: "liveness", Tag: "health",
+		Summary: "Report whether the process is alive",
+	},
+	{
+		Method: http.MethodGet, Path: "/readyz", ID: "readiness", Tag: "health",
+		Summary:  "Report whether the service and its dependencies are ready",
+		Response: ReadinessResult{},
+		Errors:   []int{http.StatusServiceUnavailable},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/openapi.json", ID: "getOpenAPI", Tag: "docs",
+		Summary: "Get this OpenAPI document",
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/docs", ID: "getDocs", Tag: "docs",
+		Summary: "Browse this OpenAPI document with Swagger UI",
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/users", ID: "getUsers", Tag: "users",
+		Summary:  "List users",
+		Query:    GetUsersQuery{},
+		Response: pagination.GetUsersResult{},
+		Rows:     models.User{},
+		Errors:   []int{http.StatusBadRequest, http.StatusTooManyRequests, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/users/count", ID: "getUsersCount", Tag: "users",
+		Summary:  "Count users",
+		Response: map[string]int64{},
+		Errors:   []int{http.StatusTooManyRequests, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/users/{user_id}", ID: "getUser", Tag: "users",
+		Summary:  "Get a user",
+		Response: models.User{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusTooManyRequests, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/users", ID: "createUser", Tag: "users",
+		Summary:  "Create a user",
+		Params:   []OpenAPIParameter{idempotencyKeyParameter},
+		Request:  createUserData{},
+		Response: models.User{},
+		Errors: []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnsupportedMediaType,
+			http.StatusUnprocessableEntity, http.StatusTooManyRequests, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodPatch, Path: "/api/v1/users/{user_id}", ID: "updateUser", Tag: "users",
+		Summary:  "Update a user",
+		Request:  updateUserData{},
+		Response: models.User{},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict,
+			http.StatusUnsupportedMediaType, http.StatusTooManyRequests, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/posts", ID: "createPost", Tag: "posts",
+		Summary:  "Create a post",
+		Params:   []OpenAPIParameter{idempotencyKeyParameter},
+		Request:  createPostData{},
+		Response: models.Post{},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict,
+			http.StatusUnprocessableEntity, http.StatusTooManyRequests, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/posts", ID: "getPosts", Tag: "posts",
+		Summary: "List a user's posts",
+		Params: []OpenAPIParameter{
+			{Name: "user_id", In: "query", Required: true, Schema: &Schema{Type: "string", Format: "uuid"}},
+		},
+		Response: []models.Post{},
+		Rows:     models.Post{},
+		Errors:   []int{http.StatusBadRequest, http.StatusTooManyRequests, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/posts/stream", ID: "streamPosts", Tag: "posts",
+		Summary: "Stream post events as server-sent events",
+		Params: []OpenAPIParameter{
+			{Name: "user_id", In: "query", Schema: &Schema{Type: "string", Format: "uuid"}},
+			{Name: "Last-Event-ID", In: "header", Schema: &Schema{Type: "string"}},
+		},
+		File:   response.EventStreamContentType,
+		Errors: []int{http.StatusBadRequest, http.StatusTooManyRequests},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/posts/{post_id}", ID: "getPost", Tag: "posts",
+		Summary:  "Get a post",
+		Response: models.Post{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusTooManyRequests, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodPatch, Path: "/api/v1/posts/{post_id}", ID: "updatePost", Tag: "posts",
+		Summary:  "Update a post",
+		Request:  updatePostData{},
+		Response: models.Post{},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnsupportedMediaType,
+			http.StatusTooManyRequests, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/posts/{post_id}", ID: "deletePost", Tag: "posts",
+		Summary: "Delete a post",
+		Errors:  []int{http.StatusBadRequest, http.StatusTooManyRequests, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/exports", ID: "createExport", Tag: "exports",
+		Summary:  "Start an export of users or posts",
+		Status:   http.StatusAccepted,
+		Params:   []OpenAPIParameter{idempotencyKeyParameter},
+		Request:  createExportData{},
+		Response: models.Export{},
+		Errors: []int{http.StatusBadRequest, http.StatusUnsupportedMediaType,
+			http.StatusTooManyRequests, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/exports/{export_id}", ID: "getExport", Tag: "exports",
+		Summary:  "Get the status and progress of an export",
+		Response: models.Export{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/exports/{export_id}/download", ID: "downloadExport", Tag: "exports",
+		Summary: "Download the gzip file of a completed export",
+		File:    "application/gzip",
+		Errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/webhooks", ID: "createWebhook", Tag: "webhooks",
+		Summary:  "Subscribe a URL to events",
+		Status:   http.StatusCreated,
+		Params:   []OpenAPIParameter{idempotencyKeyParameter},
+		Request:  createWebhookData{},
+		Response: createdWebhook{},
+		Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusUnsupportedMediaType,
+			http.StatusTooManyRequests, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/webhooks", ID: "getWebhooks", Tag: "webhooks",
+		Summary:  "List webhooks",
+		Response: []models.Webhook{},
+		Errors:   []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/webhooks/{webhook_id}", ID: "getWebhook", Tag: "webhooks",
+		Summary:  "Get a webhook",
+		Response: models.Webhook{},
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/webhooks/{webhook_id}", ID: "deleteWebhook", Tag: "webhooks",
+		Summary: "Delete a webhook and its deliveries",
+		Errors:  []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusTooManyRequests, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/webhooks/{webhook_id}/deliveries", ID: "getWebhookDeliveries", Tag: "webhooks",
+		Summary:  "List the latest deliveries of a webhook with their attempts",
+		Response: []models.WebhookDelivery{},
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/webhooks/{webhook_id}/deliveries/{delivery_id}", ID: "getWebhookDelivery", Tag: "webhooks",
+		Summary:  "Get a delivery with its attempts",
+		Response: models.WebhookDelivery{},
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/webhooks/{webhook_id}/deliveries/{delivery_id}/redeliver", ID: "redeliverWebhook", Tag: "webhooks",
+		Summary:  "Send a delivery again",
+		Status:   http.StatusAccepted,
+		Params:   []OpenAPIParameter{idempotencyKeyParameter},
+		Response: models.WebhookDelivery{},
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusTooManyRequests, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/import/users", ID: "importUsers", Tag: "import",
+		Summary:  "Import users from CSV or NDJSON",
+		Query:    ImportQuery{},
+		Upload:   createUserData{},
+		Response: importer.Report{},
+		Errors: []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType,
+			http.StatusTooManyRequests, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/import/posts", ID: "importPosts", Tag: "import",
+		Summary:  "Import posts from CSV or NDJSON",
+		Query:    ImportQuery{},
+		Upload:   importPostData{},
+		Response: importer.Report{},
+		Errors: []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType,
+			http.StatusTooManyRequests, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/ws", ID: "connectWebSocket", Tag: "realtime",
+		Summary: "Open a WebSocket receiving the events of subscribed topics",
+		Status:  http.StatusSwitchingProtocols,
+		Params: []OpenAPIParameter{
+			{Name: "X-API-Key", In: "header", Schema: &Schema{Type: "string"}},
+			{Name: "api_key", In: "query", Schema: &Schema{Type: "string"}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusUnauthorized},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/graphql", ID: "graphql", Tag: "graphql",
+		Summary:   "Run a GraphQL query or mutation",
+		Request:   graphQLRequest{},
+		Response:  graphQLResponse{},
+		Unwrapped: true,
+		Errors:    []int{http.StatusBadRequest, http.StatusUnsupportedMediaType, http.StatusTooManyRequests},
+	},
+}
+
+var idempotencyKeyParameter = OpenAPIParameter{
+	Name: "Idempotency-Key", In: "header", Schema: &Schema{Type: "string"},
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// NewOpenAPIDocument builds the OpenAPI document for every documented route.
+func NewOpenAPIDocument() *OpenAPIDocument {
+	doc := &OpenAPIDocument{
+		OpenAPI: "3.0.3",
+		Info:    OpenAPIInfo{Title: "Lema API", Version: "1.0.0"},
+		Paths:   map[string]OpenAPIPath{},
+		Components: OpenAPIComponents{Schemas: map[string]*Schema{
+			"Response": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"success": {Type: "boolean"},
+					"message": {Type: "string"},
+					"data":    {},
+				},
+				Required: []string{"success", "message"},
+			},
+		}},
+	}
+
+	for _, op := range apiOperations {
+		doc.addOperation(op)
+	}
+
+	return doc
+}
+
+func (d *OpenAPIDocument) addOperation(op apiOperation) {
+	operation := &OpenAPIOperation{
+		OperationID: op.ID,
+		Summary:     op.Summary,
+		Tags:        []string{op.Tag},
+		Responses:   map[string]*OpenAPIResponse{},
+	}
+
+	for _, match := range pathParamPattern.FindAllStringSubmatch(op.Path, -1) {
+		operation.Parameters = append(operation.Parameters, OpenAPIParameter{
+			Name: match[1], In: "path", Required: true, Schema: &Schema{Type: "string", Format: "uuid"},
+		})
+	}
+
+	if op.Query != nil {
+		schema := d.schemaFor(reflect.TypeOf(op.Query))
+		schema = d.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+		names := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			operation.Parameters = append(operation.Parameters, OpenAPIParameter{
+				Name: name, In: "query", Schema: schema.Properties[name],
+			})
+		}
+	}
+	operation.Parameters = append(operation.Parameters, op.Params...)
+
+	if op.Request != nil {
+		operation.RequestBody = &OpenAPIRequestBody{
+			Required: true,
+			Content:  jsonContent(d.schemaFor(reflect.TypeOf(op.Request))),
+		}
+	}
+
+	if op.Upload != nil {
+		operation.RequestBody = &OpenAPIRequestBody{
+			Required: true,
+			Content: map[string]OpenAPIMediaType{
+				response.CSVContentType:    {Schema: &Schema{Type: "string"}},
+				response.NDJSONContentType: {Schema: d.schemaFor(reflect.TypeOf(op.Upload))},
+			},
+		}
+	}
+
+	success := &Schema{Ref: "#/components/schemas/Response"}
+	if op.Unwrapped {
+		success = d.schemaFor(reflect.TypeOf(op.Response))
+	} else if op.Response != nil {
+		success = &Schema{AllOf: []*Schema{success, {
+			Type:       "object",
+			Properties: map[string]*Schema{"data": d.schemaFor(reflect.TypeOf(op.Response))},
+		}}}
+	}
+	content := jsonContent(success)
+	if op.File != "" {
+		content = map[string]OpenAPIMediaType{op.File: {Schema: &Schema{Type: "string", Format: "binary"}}}
+	}
+	if op.Rows != nil {
+		content[response.CSVContentType] = OpenAPIMediaType{Schema: &Schema{Type: "string"}}
+		content[response.NDJSONContentType] = OpenAPIMediaType{Schema: d.schemaFor(reflect.TypeOf(op.Rows))}
+	}
+	status := cmp.Or(op.Status, http.StatusOK)
+	if status == http.StatusSwitchingProtocols {
+		// The response is a protocol upgrade without a body.
+		content = nil
+	}
+	operation.Responses[strconv.Itoa(status)] = &OpenAPIResponse{
+		Description: http.StatusText(status),
+		Content:     content,
+	}
+
+	problem := d.schemaFor(reflect.TypeOf(response.Problem{}))
+	for _, code := range op.Errors {
+		content := jsonContent(&Schema{Ref: "#/components/schemas/Response"})
+		content[response.ProblemContentType] = OpenAPIMediaType{Schema: problem}
+		operation.Responses[strconv.Itoa(code)] = &OpenAPIResponse{
+			Description: http.StatusText(code),
+			Content:     content,
+		}
+	}
+
+	if d.Paths[op.Path] == nil {
+		d.Paths[op.Path] = OpenAPIPath{}
+	}
+	d.Paths[op.Path][strings.ToLower(op.Method)] = operation
+}
+
+// schemaFor derives a JSON schema from a Go type using its json and
+// validate struct tags. Named structs are registered as components and
+// referenced.
+func (d *OpenAPIDocument) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return &Schema{Type: "string", Format: "date-time"}
+	case reflect.TypeOf(json.RawMessage{}):
+		// Raw JSON can hold any value.
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: d.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaFor(t.Elem())}
+	case reflect.Struct:
+		name := t.Name()
+		ref := &Schema{Ref: "#/components/schemas/" + name}
+		if _, ok := d.Components.Schemas[name]; ok {
+			return ref
+		}
+
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		// Register before walking the fields so recursive types terminate.
+		d.Components.Schemas[name] = schema
+		d.addFields(schema, t)
+		return ref
+	default:
+		return &Schema{}
+	}
+}
+
+// addFields adds the fields of struct type t to schema. Untagged embedded
+// structs are flattened, as encoding/json does.
+func (d *OpenAPIDocument) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			d.addFields(schema, field.Type)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if jsonName == "-" {
+			continue
+		}
+		if jsonName == "" {
+			jsonName = field.Name
+		}
+
+		schema.Properties[jsonName] = stringRules(d.schemaFor(field.Type), field.Tag.Get("validate"))
+		if strings.Contains(field.Tag.Get("validate"), "required") {
+			schema.Required = append(schema.Required, jsonName)
+		}
+	}
+}
+
+// stringRules documents the validate rules of a string field that map onto
+// JSON schema keywords. Rules after "dive" apply to the items of an array.
+func stringRules(schema *Schema, rules string) *Schema {
+	if schema.Type == "array" {
+		if _, itemRules, found := strings.Cut(rules, "dive,"); found {
+			schema.Items = stringRules(schema.Items, itemRules)
+		}
+		return schema
+	}
+	if schema.Type != "string" {
+		return schema
+	}
+
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "max":
+			if n, err := strconv.Atoi(param); err == nil {
+				schema.MaxLength = &n
+			}
+		case "uuid":
+			schema.Format = "uuid"
+		case "oneof":
+			schema.Enum = strings.Fields(param)
+		case "deliverable_email":
+			schema.Format = "email"
+		case "http_url":
+			schema.Format = "uri"
+		case "e164":
+			schema.Pattern = `^\+[1-9][0-9]{1,14}$`
+		case "us_zipcode":
+			schema.Pattern = `^[0-9]{5}(-[0-9]{4})?$`
+		}
+	}
+	return schema
+}
+
+func jsonContent(schema *Schema) map[string]OpenAPIMediaType {
+	return map[string]OpenAPIMediaType{"application/json": {Schema: schema}}
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
+	"reflect"
 
 	"github.com/go-chi/chi"
 	"github.com/google/uuid"
@@ -16,8 +18,10 @@ import (
 
 type PostService interface {
 	CreatePost(p *models.Post) error
+	UpdatePost(p *models.Post) error
 	GetPost(postId string) (*models.Post, error)
-	GetPosts(userId string) ([]*models.Post, error)
+	GetPosts(ctx context.Context, userId string) ([]*models.Post, error)
+	StreamPosts(ctx context.Context, userId string, fn func(*models.Post) error) error
 	DeletePost(postId string) error
 }
 
@@ -32,28 +36,31 @@ func NewPostHandler(postService PostService, cfg *config.Config, l zerolog.Logge
 }
 
 type createPostData struct {
-	Title  string `json:"title" validate:"required"`
-	Body   string `json:"body" validate:"required"`
-	UserID string `json:"userId" validate:"required"`
+	Title  string `json:"title" mod:"trim" validate:"required,notblank,max=200,nobannedwords"`
+	Body   string `json:"body" mod:"trim" validate:"required,notblank,max=10000,nobannedwords"`
+	UserID string `json:"userId" validate:"required,uuid"`
+}
+
+// updatePostData holds the fields of a partial post update. Omitted fields
+// are left unchanged.
+type updatePostData struct {
+	Title *string `json:"title" mod:"trim" validate:"omitnil,notblank,max=200,nobannedwords"`
+	Body  *string `json:"body" mod:"trim" validate:"omitnil,notblank,max=10000,nobannedwords"`
 }
 
 func (h *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	resp := response.Response[any]{}
 	data := new(createPostData)
 
-	err := json.ReadJSON(r.Body, data)
+	err := json.ReadJSONStrict(r.Body, data)
 	defer r.Body.Close()
 	if err != nil {
-		resp.Message = err.Error()
-		response.SendErrorResponse(w, resp, http.StatusBadRequest)
+		response.SendError(w, r, err)
 		return
 	}
 
-	validationErrors := validator.ValidateData(data)
-	if validationErrors != nil {
-		resp.Message = apperror.ErrBadRequest.Error()
-		resp.Data = validationErrors
-		response.SendErrorResponse(w, resp, http.StatusBadRequest)
+	if err := validator.ValidateData(r.Context(), data); err != nil {
+		response.SendError(w, r, err)
 		return
 	}
 
@@ -66,9 +73,7 @@ func (h *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 
 	err = h.postService.CreatePost(post)
 	if err != nil {
-		code := apperror.GetErrorStatusCode(err)
-		resp.Message = err.Error()
-		response.SendErrorResponse(w, resp, code)
+		response.SendError(w, r, err)
 		return
 	}
 
@@ -77,21 +82,60 @@ func (h *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	response.SendResponse(w, resp, nil)
 }
 
+func (h *PostHandler) UpdatePost(w http.ResponseWriter, r *http.Request) {
+	resp := response.Response[any]{}
+
+	postId := chi.URLParam(r, "post_id")
+	if !validator.IsValidUUID(postId) {
+		response.SendError(w, r, apperror.InvalidParameter("post_id", "Invalid post ID"))
+		return
+	}
+
+	data := new(updatePostData)
+	err := json.ReadJSONStrict(r.Body, data)
+	defer r.Body.Close()
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	if err := validator.ValidateData(r.Context(), data); err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	post, err := h.postService.GetPost(postId)
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	setIfPresent(&post.Title, data.Title)
+	setIfPresent(&post.Body, data.Body)
+
+	err = h.postService.UpdatePost(post)
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	resp.Message = "Post updated successfully"
+	resp.Data = post
+	response.SendResponse(w, resp, nil)
+}
+
 func (h *PostHandler) GetPost(w http.ResponseWriter, r *http.Request) {
 	resp := response.Response[any]{}
 
 	postId := chi.URLParam(r, "post_id")
 	if !validator.IsValidUUID(postId) {
-		resp.Message = "Invalid post ID"
-		response.SendErrorResponse(w, resp, http.StatusBadRequest)
+		response.SendError(w, r, apperror.InvalidParameter("post_id", "Invalid post ID"))
 		return
 	}
 
 	post, err := h.postService.GetPost(postId)
 	if err != nil {
-		code := apperror.GetErrorStatusCode(err)
-		resp.Message = err.Error()
-		response.SendErrorResponse(w, resp, code)
+		response.SendError(w, r, err)
 		return
 	}
 
@@ -105,16 +149,19 @@ func (h *PostHandler) GetPosts(w http.ResponseWriter, r *http.Request) {
 
 	userId := r.URL.Query().Get("user_id")
 	if !validator.IsValidUUID(userId) {
-		resp.Message = "Invalid user ID"
-		response.SendErrorResponse(w, resp, http.StatusBadRequest)
+		response.SendError(w, r, apperror.InvalidParameter("user_id", "Invalid user ID"))
 		return
 	}
 
-	posts, err := h.postService.GetPosts(userId)
+	w.Header().Add("Vary", "Accept")
+	if format := response.Negotiate(r); format != response.JSONContentType {
+		h.streamPosts(w, r, format, userId)
+		return
+	}
+
+	posts, err := h.postService.GetPosts(r.Context(), userId)
 	if err != nil {
-		code := apperror.GetErrorStatusCode(err)
-		resp.Message = err.Error()
-		response.SendErrorResponse(w, resp, code)
+		response.SendError(w, r, err)
 		return
 	}
 
@@ -123,21 +170,38 @@ func (h *PostHandler) GetPosts(w http.ResponseWriter, r *http.Request) {
 	response.SendResponse(w, resp, nil)
 }
 
+// streamPosts writes a user's posts as CSV or NDJSON rows.
+func (h *PostHandler) streamPosts(w http.ResponseWriter, r *http.Request, format, userId string) {
+	rw := response.NewRowWriter(w, format, reflect.TypeOf(models.Post{}), nil)
+
+	err := h.postService.StreamPosts(r.Context(), userId, func(p *models.Post) error {
+		if err := rw.WriteRow(p); err != nil {
+			return err
+		}
+		return rw.Flush()
+	})
+	if err == nil {
+		err = rw.Flush()
+	}
+	if err != nil {
+		// The status line has been sent, so the client only sees a
+		// truncated body.
+		h.logger.Error().Err(err).Msg("failed to stream posts")
+	}
+}
+
 func (h *PostHandler) DeletePost(w http.ResponseWriter, r *http.Request) {
 	resp := response.Response[any]{}
 
 	postId := chi.URLParam(r, "post_id")
 	if !validator.IsValidUUID(postId) {
-		resp.Message = "Invalid post ID"
-		response.SendErrorResponse(w, resp, http.StatusBadRequest)
+		response.SendError(w, r, apperror.InvalidParameter("post_id", "Invalid post ID"))
 		return
 	}
 
 	err := h.postService.DeletePost(postId)
 	if err != nil {
-		code := apperror.GetErrorStatusCode(err)
-		resp.Message = err.Error()
-		response.SendErrorResponse(w, resp, code)
+		response.SendError(w, r, err)
 		return
 	}
 
@@ -3,9 +3,11 @@ package handlers_test
 import (
 	"bytes"
 	"context"
+	"encoding/csv"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,10 +18,13 @@ import (
 	database "github.com/princecee/lema-ai/internal/db"
 	"github.com/princecee/lema-ai/internal/db/models"
 	"github.com/princecee/lema-ai/internal/db/repositories"
+	"github.com/princecee/lema-ai/internal/middlewares"
 	"github.com/princecee/lema-ai/internal/routes"
 	"github.com/princecee/lema-ai/internal/services"
+	apperror "github.com/princecee/lema-ai/pkg/error"
 	"github.com/princecee/lema-ai/pkg/json"
 	"github.com/princecee/lema-ai/pkg/response"
+	"github.com/princecee/lema-ai/pkg/store"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/suite"
 	"gorm.io/gorm"
@@ -35,11 +40,12 @@ type PostHandlerTestSuite struct {
 func (s *PostHandlerTestSuite) SetupSuite() {
 	cfg := config.NewConfig("test", "silent")
 	cfg.DSN = "file::memory:?cache=shared"
+	cfg.RATE_LIMIT_POSTS_WRITE = config.RateLimit{}
 	var logger zerolog.Logger
 
-	db := database.GetDBConn(cfg.DSN, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
+	db := database.GetDBConn(cfg.DSN, cfg.REPLICA_DSNS, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
 
-	err := db.AutoMigrate(&models.User{}, &models.Address{}, &models.Post{})
+	err := db.AutoMigrate(&models.User{}, &models.Address{}, &models.Post{}, &models.OutboxEvent{})
 	if err != nil {
 		s.Fail(err.Error())
 	}
@@ -79,19 +85,15 @@ func (s *PostHandlerTestSuite) SetupSuite() {
 	s.users = users
 
 	r := chi.NewRouter()
-	postRouter := routes.AddPostRoutes(db, postService, cfg, logger)
+	r.Use(middlewares.Locale)
+	postRouter := routes.AddPostRoutes(db, postService, services.NewEventStream(10), store.NewMemoryStore(), cfg, logger)
 	r.Mount("/api/v1/posts", postRouter)
 
 	s.server = httptest.NewServer(r)
 }
 
 func (s *PostHandlerTestSuite) TearDownSuite() {
-	sqlDB, err := s.db.DB()
-	if err != nil {
-		s.Fail(err.Error())
-	}
-
-	sqlDB.Close()
+	s.NoError(database.Close(s.db))
 	s.server.Close()
 }
 
@@ -144,6 +146,26 @@ func (s *PostHandlerTestSuite) TestPostHandler() {
 		postId = response.Data[0].ID
 	})
 
+	t.Run("Get posts by user ID as CSV", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, url+"?user_id="+s.users[1].ID, nil)
+		s.NoError(err)
+		req.Header.Set("Accept", "text/csv")
+
+		resp, err := s.server.Client().Do(req)
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+		defer resp.Body.Close()
+
+		s.Equal("text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+		records, err := csv.NewReader(resp.Body).ReadAll()
+		s.NoError(err)
+		s.Len(records, 6)
+		s.Equal([]string{"id", "user_id", "title", "body", "created_at"}, records[0])
+		for _, record := range records[1:] {
+			s.Equal(s.users[1].ID, record[1])
+		}
+	})
+
 	t.Run("Get post by ID", func(t *testing.T) {
 		for i := 1; i <= 25; i++ {
 			resp, err := s.server.Client().Get(url + fmt.Sprintf("/%s", postId))
@@ -185,6 +207,232 @@ func (s *PostHandlerTestSuite) TestPostHandler() {
 		s.Empty(response.Data)
 	})
 
+	t.Run("Create post with missing fields", func(t *testing.T) {
+		payload, _ := json.WriteJSON(map[string]any{"title": gofakeit.Sentence(7)})
+
+		resp, err := s.server.Client().Post(url, "application/json", bytes.NewBuffer(payload))
+		s.NoError(err)
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+		defer resp.Body.Close()
+
+		response := response.Response[map[string]string]{}
+		_ = json.ReadJSON(resp.Body, &response)
+		s.Equal(false, *response.Success)
+		s.Equal("bad request", response.Message)
+		s.Contains(response.Data, "body")
+		s.Contains(response.Data, "userId")
+		s.NotContains(response.Data, "title")
+	})
+
+	t.Run("Create post with missing fields as problem+json", func(t *testing.T) {
+		payload, _ := json.WriteJSON(map[string]any{"title": gofakeit.Sentence(7)})
+
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(payload))
+		s.NoError(err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/problem+json")
+
+		resp, err := s.server.Client().Do(req)
+		s.NoError(err)
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+		s.Equal("application/problem+json", resp.Header.Get("Content-Type"))
+		defer resp.Body.Close()
+
+		problem := response.Problem{}
+		_ = json.ReadJSON(resp.Body, &problem)
+		s.Equal(apperror.CodeValidation, problem.Code)
+		s.Equal(http.StatusBadRequest, problem.Status)
+		s.Equal("Bad Request", problem.Title)
+		s.Equal("/api/v1/posts", problem.Instance)
+		s.Len(problem.Errors, 2)
+		for _, violation := range problem.Errors {
+			s.Contains([]string{"body", "userId"}, violation.Field)
+			s.Equal("required", violation.Code)
+		}
+	})
+
+	t.Run("Create post with missing fields in French", func(t *testing.T) {
+		payload, _ := json.WriteJSON(map[string]any{"title": gofakeit.Sentence(7)})
+
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(payload))
+		s.NoError(err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", "fr-CA, en;q=0.5")
+
+		resp, err := s.server.Client().Do(req)
+		s.NoError(err)
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+		s.Equal("fr", resp.Header.Get("Content-Language"))
+		defer resp.Body.Close()
+
+		response := response.Response[map[string]string]{}
+		_ = json.ReadJSON(resp.Body, &response)
+		s.Equal("requête invalide", response.Message)
+		s.Equal("body est un champ obligatoire", response.Data["body"])
+		s.Equal("userId est un champ obligatoire", response.Data["userId"])
+	})
+
+	t.Run("Create post with malformed JSON in Spanish", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewBufferString(`{"title": 1}`))
+		s.NoError(err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/problem+json")
+		req.Header.Set("Accept-Language", "es")
+
+		resp, err := s.server.Client().Do(req)
+		s.NoError(err)
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+		defer resp.Body.Close()
+
+		problem := response.Problem{}
+		_ = json.ReadJSON(resp.Body, &problem)
+		s.Equal(apperror.CodeInvalidJSON, problem.Code)
+		s.Equal("title debe ser de tipo string", problem.Detail)
+		s.Require().Len(problem.Errors, 1)
+		s.Equal("title debe ser de tipo string", problem.Errors[0].Message)
+	})
+
+	t.Run("Create post with malformed JSON", func(t *testing.T) {
+		resp, err := s.server.Client().Post(url, "application/json", bytes.NewBufferString("{"))
+		s.NoError(err)
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+		defer resp.Body.Close()
+
+		response := response.Response[any]{}
+		_ = json.ReadJSON(resp.Body, &response)
+		s.Equal(false, *response.Success)
+		s.Equal("Request body contains malformed JSON", response.Message)
+	})
+
+	t.Run("Create post with unknown fields", func(t *testing.T) {
+		payload, _ := json.WriteJSON(map[string]any{
+			"title":  gofakeit.Sentence(7),
+			"body":   gofakeit.Sentence(40),
+			"userId": s.users[0].ID,
+			"author": "someone",
+		})
+
+		resp, err := s.server.Client().Post(url, "application/json", bytes.NewBuffer(payload))
+		s.NoError(err)
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+		defer resp.Body.Close()
+
+		response := response.Response[any]{}
+		_ = json.ReadJSON(resp.Body, &response)
+		s.Equal("Unknown field author", response.Message)
+	})
+
+	t.Run("Create post with wrong field type", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewBufferString(`{"title": 1, "body": "b", "userId": "u"}`))
+		s.NoError(err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/problem+json")
+
+		resp, err := s.server.Client().Do(req)
+		s.NoError(err)
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+		defer resp.Body.Close()
+
+		problem := response.Problem{}
+		_ = json.ReadJSON(resp.Body, &problem)
+		s.Equal(apperror.CodeInvalidJSON, problem.Code)
+		s.Require().Len(problem.Errors, 1)
+		s.Equal("title", problem.Errors[0].Field)
+		s.Equal("title must be of type string", problem.Errors[0].Message)
+	})
+
+	t.Run("Create post with multiple JSON documents", func(t *testing.T) {
+		body := fmt.Sprintf(`{"title": "a", "body": "b", "userId": %q} {}`, s.users[0].ID)
+
+		resp, err := s.server.Client().Post(url, "application/json", bytes.NewBufferString(body))
+		s.NoError(err)
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+		defer resp.Body.Close()
+
+		response := response.Response[any]{}
+		_ = json.ReadJSON(resp.Body, &response)
+		s.Equal("Request body must only contain a single JSON document", response.Message)
+	})
+
+	t.Run("Create post with unsupported content type", func(t *testing.T) {
+		resp, err := s.server.Client().Post(url, "text/plain", bytes.NewBufferString("hello"))
+		s.NoError(err)
+		s.Equal(http.StatusUnsupportedMediaType, resp.StatusCode)
+		defer resp.Body.Close()
+
+		response := response.Response[any]{}
+		_ = json.ReadJSON(resp.Body, &response)
+		s.Equal(false, *response.Success)
+		s.Equal("Content-Type must be application/json", response.Message)
+	})
+
+	t.Run("Get non-existent post as problem+json", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, url+"/"+uuid.NewString(), nil)
+		s.NoError(err)
+		req.Header.Set("Accept", "application/problem+json, application/json;q=0.9")
+
+		resp, err := s.server.Client().Do(req)
+		s.NoError(err)
+		s.Equal(http.StatusNotFound, resp.StatusCode)
+		defer resp.Body.Close()
+
+		problem := response.Problem{}
+		_ = json.ReadJSON(resp.Body, &problem)
+		s.Equal(apperror.CodeNotFound, problem.Code)
+		s.Equal("not found", problem.Detail)
+	})
+
+	t.Run("Update post", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPatch, url+"/"+postId, bytes.NewBufferString(`{"title": "  A new title  "}`))
+		s.NoError(err)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.server.Client().Do(req)
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+		defer resp.Body.Close()
+
+		response := response.Response[*models.Post]{}
+		_ = json.ReadJSON(resp.Body, &response)
+		s.Equal(true, *response.Success)
+		s.Equal("Post updated successfully", response.Message)
+		s.Equal("A new title", response.Data.Title)
+		s.NotEmpty(response.Data.Body)
+	})
+
+	t.Run("Update post with invalid fields", func(t *testing.T) {
+		payload, _ := json.WriteJSON(map[string]any{"title": "   ", "body": strings.Repeat("a", 10001)})
+
+		req, err := http.NewRequest(http.MethodPatch, url+"/"+postId, bytes.NewBuffer(payload))
+		s.NoError(err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/problem+json")
+
+		resp, err := s.server.Client().Do(req)
+		s.NoError(err)
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+		defer resp.Body.Close()
+
+		problem := response.Problem{}
+		_ = json.ReadJSON(resp.Body, &problem)
+		s.Equal(apperror.CodeValidation, problem.Code)
+		s.ElementsMatch([]apperror.Violation{
+			{Field: "title", Code: "notblank", Message: "title must not be blank"},
+			{Field: "body", Code: "max", Message: "body must be a maximum of 10,000 characters in length"},
+		}, problem.Errors)
+	})
+
+	t.Run("Update non-existent post", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPatch, url+"/"+uuid.NewString(), bytes.NewBufferString(`{"title": "t"}`))
+		s.NoError(err)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.server.Client().Do(req)
+		s.NoError(err)
+		s.Equal(http.StatusNotFound, resp.StatusCode)
+		resp.Body.Close()
+	})
+
 	t.Run("Delete post by ID", func(t *testing.T) {
 		req, err := http.NewRequest(http.MethodDelete, url+"/"+postId, nil)
 		s.NoError(err)
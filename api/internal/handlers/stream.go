@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/princecee/lema-ai/config"
+	"github.com/princecee/lema-ai/internal/db/models"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/response"
+	"github.com/princecee/lema-ai/pkg/validator"
+	"github.com/rs/zerolog"
+)
+
+type EventStream interface {
+	Subscribe(lastEventId string, buffer int) ([]*models.OutboxEvent, <-chan *models.OutboxEvent, func())
+}
+
+// subscriberBuffer is the number of events a client may lag behind before
+// it is disconnected.
+const subscriberBuffer = 64
+
+type StreamHandler struct {
+	stream EventStream
+	config *config.Config
+	logger zerolog.Logger
+}
+
+func NewStreamHandler(stream EventStream, cfg *config.Config, l zerolog.Logger) *StreamHandler {
+	return &StreamHandler{stream, cfg, l}
+}
+
+// StreamPosts sends post events as server-sent events, optionally only
+// those of one user. Each event's id is the id of the outbox event, so a
+// client reconnecting with Last-Event-ID gets the events it missed while
+// they are still buffered.
+func (h *StreamHandler) StreamPosts(w http.ResponseWriter, r *http.Request) {
+	userId := r.URL.Query().Get("user_id")
+	if userId != "" && !validator.IsValidUUID(userId) {
+		response.SendError(w, r, apperror.InvalidParameter("user_id", "Invalid user ID"))
+		return
+	}
+
+	missed, events, cancel := h.stream.Subscribe(r.Header.Get("Last-Event-ID"), subscriberBuffer)
+	defer cancel()
+
+	ew := response.NewEventWriter(w)
+	send := func(e *models.OutboxEvent) error {
+		if !isPostEvent(e, userId) {
+			return nil
+		}
+		return ew.WriteEvent(e.ID, e.Event, []byte(e.Payload))
+	}
+
+	for _, e := range missed {
+		if err := send(e); err != nil {
+			return
+		}
+	}
+
+	// A heartbeat interval of zero or less disables heartbeats: the nil
+	// channel never fires.
+	var heartbeat <-chan time.Time
+	if h.config.STREAM_HEARTBEAT_INTERVAL > 0 {
+		ticker := time.NewTicker(h.config.STREAM_HEARTBEAT_INTERVAL)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				// The client lagged behind or the server is shutting
+				// down; either way it should reconnect.
+				return
+			}
+			if err := send(e); err != nil {
+				return
+			}
+		case <-heartbeat:
+			if err := ew.WriteComment("heartbeat"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// isPostEvent reports whether e is a post event, of userId's posts when it
+// is set.
+func isPostEvent(e *models.OutboxEvent, userId string) bool {
+	if !strings.HasPrefix(e.Event, "post.") {
+		return false
+	}
+	if userId == "" {
+		return true
+	}
+
+	var post models.Post
+	if err := json.Unmarshal([]byte(e.Payload), &post); err != nil {
+		return false
+	}
+	return post.UserID == userId
+}
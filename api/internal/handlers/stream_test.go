@@ -0,0 +1,251 @@
+package handlers_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/princecee/lema-ai/config"
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/internal/db/repositories"
+	"github.com/princecee/lema-ai/internal/handlers"
+	"github.com/princecee/lema-ai/internal/middlewares"
+	"github.com/princecee/lema-ai/internal/routes"
+	"github.com/princecee/lema-ai/internal/services"
+	"github.com/princecee/lema-ai/pkg/store"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// sseEvent is an event or, when only Comment is set, a comment read from
+// an event stream.
+type sseEvent struct {
+	ID      string
+	Event   string
+	Data    string
+	Comment string
+}
+
+// sseStream reads the events of a text/event-stream response.
+type sseStream struct {
+	reader *bufio.Reader
+}
+
+func (st *sseStream) next() (sseEvent, error) {
+	var e sseEvent
+	for {
+		line, err := st.reader.ReadString('\n')
+		if err != nil {
+			return e, err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return e, nil
+		}
+
+		field, value, _ := strings.Cut(line, ": ")
+		switch field {
+		case "":
+			e.Comment = value
+		case "id":
+			e.ID = value
+		case "event":
+			e.Event = value
+		case "data":
+			e.Data = value
+		}
+	}
+}
+
+// nextEvent skips heartbeats and returns the next event.
+func (st *sseStream) nextEvent() (sseEvent, error) {
+	for {
+		e, err := st.next()
+		if err != nil || e.Comment == "" {
+			return e, err
+		}
+	}
+}
+
+type StreamHandlerTestSuite struct {
+	suite.Suite
+	db          *gorm.DB
+	server      *httptest.Server
+	relay       *services.OutboxRelay
+	cfg         *config.Config
+	postService *services.PostService
+	users       []*models.User
+}
+
+func (s *StreamHandlerTestSuite) SetupSuite() {
+	cfg := config.NewConfig("test", "silent")
+	cfg.DSN = "file:stream?mode=memory&cache=shared"
+	cfg.STREAM_HEARTBEAT_INTERVAL = 50 * time.Millisecond
+	var logger zerolog.Logger
+
+	db := database.GetDBConn(cfg.DSN, cfg.REPLICA_DSNS, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
+
+	err := database.Migrate(db)
+	if err != nil {
+		s.Fail(err.Error())
+	}
+	s.db = db
+	s.cfg = cfg
+
+	userRepo := repositories.NewUserRepository(db)
+	for i := range 2 {
+		id := uuid.NewString()
+		user := &models.User{
+			ID:       id,
+			Name:     "Jane Doe",
+			Username: id,
+			Email:    id + "@example.com",
+			Phone:    fmt.Sprintf("+1555000000%d", i),
+			Address:  models.Address{ID: uuid.NewString(), Street: "1 Main St", City: "Springfield", State: "IL", Zipcode: "62701"},
+		}
+		if err := userRepo.CreateUser(context.Background(), user); err != nil {
+			s.Fail(err.Error())
+		}
+		s.users = append(s.users, user)
+	}
+
+	stream := services.NewEventStream(100)
+	s.relay = services.NewOutboxRelay(repositories.NewOutboxRepository(db), map[string]services.Sink{"stream": stream}, services.OutboxOptions{
+		PollInterval: 10 * time.Millisecond,
+		BatchSize:    100,
+	}, logger)
+	s.relay.Start(context.Background())
+
+	s.postService = services.NewPostService(repositories.NewPostRepository(db))
+
+	r := chi.NewRouter()
+	r.Use(middlewares.Locale)
+	r.Mount("/api/v1/posts", routes.AddPostRoutes(db, s.postService, stream, store.NewMemoryStore(), cfg, logger))
+
+	s.server = httptest.NewServer(r)
+}
+
+func (s *StreamHandlerTestSuite) TearDownSuite() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.NoError(s.relay.Shutdown(ctx))
+
+	s.NoError(database.Close(s.db))
+	s.server.Close()
+}
+
+// open connects to url and returns its event stream. The connection is
+// closed when the test ends.
+func (s *StreamHandlerTestSuite) open(url, lastEventId string) *sseStream {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	s.T().Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	s.Require().NoError(err)
+	if lastEventId != "" {
+		req.Header.Set("Last-Event-ID", lastEventId)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	s.T().Cleanup(func() { resp.Body.Close() })
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Require().Equal("text/event-stream", resp.Header.Get("Content-Type"))
+
+	return &sseStream{bufio.NewReader(resp.Body)}
+}
+
+func (s *StreamHandlerTestSuite) createPost(user *models.User) *models.Post {
+	post := &models.Post{ID: uuid.NewString(), UserID: user.ID, Title: "Hello", Body: "World"}
+	s.Require().NoError(s.postService.CreatePost(post))
+	return post
+}
+
+// waitForDispatch waits until the event about post is dispatched and
+// returns its ID.
+func (s *StreamHandlerTestSuite) waitForDispatch(event string, post *models.Post) string {
+	var e models.OutboxEvent
+	s.Require().Eventually(func() bool {
+		err := s.db.Where("event = ? AND payload LIKE ? AND dispatched_at IS NOT NULL", event, "%"+post.ID+"%").First(&e).Error
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	return e.ID
+}
+
+func (s *StreamHandlerTestSuite) TestStreamPosts() {
+	url := s.server.URL + "/api/v1/posts/stream?user_id=" + s.users[0].ID
+	stream := s.open(url, "")
+
+	// Posts of other users are filtered out.
+	s.createPost(s.users[1])
+	post := s.createPost(s.users[0])
+
+	e, err := stream.nextEvent()
+	s.Require().NoError(err)
+	s.Equal(models.EventPostCreated, e.Event)
+	s.NotEmpty(e.ID)
+	s.Contains(e.Data, post.ID)
+
+	s.Require().NoError(s.postService.DeletePost(post.ID))
+	e, err = stream.nextEvent()
+	s.Require().NoError(err)
+	s.Equal(models.EventPostDeleted, e.Event)
+	s.Contains(e.Data, post.ID)
+}
+
+func (s *StreamHandlerTestSuite) TestResume() {
+	first := s.createPost(s.users[0])
+	second := s.createPost(s.users[0])
+	lastEventId := s.waitForDispatch(models.EventPostCreated, first)
+	s.waitForDispatch(models.EventPostCreated, second)
+
+	stream := s.open(s.server.URL+"/api/v1/posts/stream", lastEventId)
+	e, err := stream.nextEvent()
+	s.Require().NoError(err)
+	s.Equal(models.EventPostCreated, e.Event)
+	s.Contains(e.Data, second.ID)
+}
+
+func (s *StreamHandlerTestSuite) TestHeartbeat() {
+	stream := s.open(s.server.URL+"/api/v1/posts/stream", "")
+	e, err := stream.next()
+	s.Require().NoError(err)
+	s.Equal("heartbeat", e.Comment)
+}
+
+func (s *StreamHandlerTestSuite) TestInvalidUserID() {
+	resp, err := http.Get(s.server.URL + "/api/v1/posts/stream?user_id=1")
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *StreamHandlerTestSuite) TestClose() {
+	// Closing the stream, as the server does on shutdown, ends the
+	// response.
+	eventStream := services.NewEventStream(10)
+	h := handlers.NewStreamHandler(eventStream, s.cfg, zerolog.Nop())
+	server := httptest.NewServer(http.HandlerFunc(h.StreamPosts))
+	defer server.Close()
+
+	stream := s.open(server.URL, "")
+	eventStream.Close()
+	for {
+		if _, err := stream.next(); err != nil {
+			break
+		}
+	}
+}
+
+func TestStreamHandler(t *testing.T) {
+	suite.Run(t, new(StreamHandlerTestSuite))
+}
@@ -1,12 +1,18 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"reflect"
+	"strconv"
 
 	"github.com/go-chi/chi"
+	"github.com/google/uuid"
 	"github.com/princecee/lema-ai/config"
 	"github.com/princecee/lema-ai/internal/db/models"
 	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/json"
 	"github.com/princecee/lema-ai/pkg/pagination"
 	"github.com/princecee/lema-ai/pkg/response"
 	"github.com/princecee/lema-ai/pkg/validator"
@@ -14,9 +20,12 @@ import (
 )
 
 type UserService interface {
-	GetUsers(page, limt int) (*pagination.GetUsersResult, error)
-	GetUserCount() (int64, error)
+	CreateUser(u *models.User) error
+	UpdateUser(u *models.User) error
+	GetUsers(ctx context.Context, page, limit int) (*pagination.GetUsersResult, error)
+	GetUserCount(ctx context.Context) (int64, error)
 	GetUser(id string) (*models.User, error)
+	StreamUsers(ctx context.Context, page, limit int, fn func(*models.User) error) error
 }
 
 type UserHandler struct {
@@ -30,8 +39,138 @@ func NewUserHandler(userService UserService, cfg *config.Config, l zerolog.Logge
 }
 
 type GetUsersQuery struct {
-	Page  int `validate:"required"`
-	Limit int `validate:"required"`
+	Page  int `json:"page" validate:"required"`
+	Limit int `json:"limit" validate:"required"`
+}
+
+type createUserData struct {
+	Name     string          `json:"name" mod:"trim" validate:"required,notblank,max=100"`
+	Username string          `json:"username" mod:"trim" validate:"required,notblank,max=50,nobannedwords"`
+	Email    string          `json:"email" mod:"trim,lower" validate:"required,deliverable_email"`
+	Phone    string          `json:"phone" mod:"e164" validate:"required,e164"`
+	Address  userAddressData `json:"address" validate:"required"`
+}
+
+type userAddressData struct {
+	Street  string `json:"street" mod:"trim" validate:"required,notblank,max=200"`
+	City    string `json:"city" mod:"trim" validate:"required,notblank,max=100"`
+	State   string `json:"state" mod:"trim" validate:"required,notblank,max=100"`
+	Zipcode string `json:"zipcode" mod:"trim" validate:"required,us_zipcode"`
+}
+
+// updateUserData holds the fields of a partial user update. Omitted fields
+// are left unchanged.
+type updateUserData struct {
+	Name     *string                `json:"name" mod:"trim" validate:"omitnil,notblank,max=100"`
+	Username *string                `json:"username" mod:"trim" validate:"omitnil,notblank,max=50,nobannedwords"`
+	Email    *string                `json:"email" mod:"trim,lower" validate:"omitnil,deliverable_email"`
+	Phone    *string                `json:"phone" mod:"e164" validate:"omitnil,e164"`
+	Address  *updateUserAddressData `json:"address"`
+}
+
+type updateUserAddressData struct {
+	Street  *string `json:"street" mod:"trim" validate:"omitnil,notblank,max=200"`
+	City    *string `json:"city" mod:"trim" validate:"omitnil,notblank,max=100"`
+	State   *string `json:"state" mod:"trim" validate:"omitnil,notblank,max=100"`
+	Zipcode *string `json:"zipcode" mod:"trim" validate:"omitnil,us_zipcode"`
+}
+
+func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	resp := response.Response[any]{}
+
+	data := new(createUserData)
+	err := json.ReadJSONStrict(r.Body, data)
+	defer r.Body.Close()
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	if err := validator.ValidateData(r.Context(), data); err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	user := &models.User{
+		ID:       uuid.NewString(),
+		Name:     data.Name,
+		Username: data.Username,
+		Email:    data.Email,
+		Phone:    data.Phone,
+		Address: models.Address{
+			ID:      uuid.NewString(),
+			Street:  data.Address.Street,
+			City:    data.Address.City,
+			State:   data.Address.State,
+			Zipcode: data.Address.Zipcode,
+		},
+	}
+
+	err = h.userService.CreateUser(user)
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	resp.Message = "User created successfully"
+	resp.Data = user
+	response.SendResponse(w, resp, nil)
+}
+
+func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	resp := response.Response[any]{}
+
+	userId := chi.URLParam(r, "user_id")
+	if !validator.IsValidUUID(userId) {
+		response.SendError(w, r, apperror.InvalidParameter("user_id", "Invalid user ID"))
+		return
+	}
+
+	data := new(updateUserData)
+	err := json.ReadJSONStrict(r.Body, data)
+	defer r.Body.Close()
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	if err := validator.ValidateData(r.Context(), data); err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	user, err := h.userService.GetUser(userId)
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	setIfPresent(&user.Name, data.Name)
+	setIfPresent(&user.Username, data.Username)
+	setIfPresent(&user.Email, data.Email)
+	setIfPresent(&user.Phone, data.Phone)
+	if address := data.Address; address != nil {
+		setIfPresent(&user.Address.Street, address.Street)
+		setIfPresent(&user.Address.City, address.City)
+		setIfPresent(&user.Address.State, address.State)
+		setIfPresent(&user.Address.Zipcode, address.Zipcode)
+	}
+
+	err = h.userService.UpdateUser(user)
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	resp.Message = "User updated successfully"
+	resp.Data = user
+	response.SendResponse(w, resp, nil)
+}
+
+func setIfPresent(dst *string, value *string) {
+	if value != nil {
+		*dst = *value
+	}
 }
 
 func (h *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
@@ -40,43 +179,79 @@ func (h *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
 	query := GetUsersQuery{}
 	page, limit, err := pagination.FormatPaginationQuery(r.URL.Query().Get("page"), r.URL.Query().Get("limit"))
 	if err != nil {
-		resp.Message = err.Error()
-		response.SendErrorResponse(w, resp, http.StatusBadRequest)
+		field := "page"
+		if errors.Is(err, pagination.ErrInvalidLimit) {
+			field = "limit"
+		}
+		response.SendError(w, r, apperror.InvalidParameter(field, err.Error()))
 		return
 	}
 
 	query.Page = page
 	query.Limit = limit
 
-	validationErrors := validator.ValidateData(query)
-	if validationErrors != nil {
-		resp.Message = apperror.ErrBadRequest.Error()
-		resp.Data = validationErrors
-		response.SendErrorResponse(w, resp, http.StatusBadRequest)
+	if err := validator.ValidateData(r.Context(), query); err != nil {
+		response.SendError(w, r, err)
 		return
 	}
 
-	getUsersResp, err := h.userService.GetUsers(query.Page, query.Limit)
+	w.Header().Add("Vary", "Accept")
+	if format := response.Negotiate(r); format != response.JSONContentType {
+		h.streamUsers(w, r, format, query)
+		return
+	}
+
+	getUsersResp, err := h.userService.GetUsers(r.Context(), query.Page, query.Limit)
 	if err != nil {
-		code := apperror.GetErrorStatusCode(err)
-		resp.Message = err.Error()
-		response.SendErrorResponse(w, resp, code)
+		response.SendError(w, r, err)
 		return
 	}
 
 	resp.Message = "Users fetched successfully"
 	resp.Data = getUsersResp
-	response.SendResponse(w, resp, nil)
+	response.SendResponse(w, resp, map[string]string{
+		"Link": pagination.LinkHeader(r.URL, query.Page, query.Limit, getUsersResp.TotalPages),
+	})
+}
+
+// streamUsers writes a page of users as CSV or NDJSON rows. Pagination
+// metadata goes in the Link and X-Total-Count headers since the body has
+// no envelope.
+func (h *UserHandler) streamUsers(w http.ResponseWriter, r *http.Request, format string, query GetUsersQuery) {
+	count, err := h.userService.GetUserCount(r.Context())
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	totalPages := pagination.GetTotalPages(count, query.Limit)
+	rw := response.NewRowWriter(w, format, reflect.TypeOf(models.User{}), map[string]string{
+		"Link":          pagination.LinkHeader(r.URL, query.Page, query.Limit, totalPages),
+		"X-Total-Count": strconv.FormatInt(count, 10),
+	})
+
+	err = h.userService.StreamUsers(r.Context(), query.Page, query.Limit, func(u *models.User) error {
+		if err := rw.WriteRow(u); err != nil {
+			return err
+		}
+		return rw.Flush()
+	})
+	if err == nil {
+		err = rw.Flush()
+	}
+	if err != nil {
+		// The status line has been sent, so the client only sees a
+		// truncated body.
+		h.logger.Error().Err(err).Msg("failed to stream users")
+	}
 }
 
 func (h *UserHandler) GetUsersCount(w http.ResponseWriter, r *http.Request) {
 	resp := response.Response[any]{}
 
-	count, err := h.userService.GetUserCount()
+	count, err := h.userService.GetUserCount(r.Context())
 	if err != nil {
-		code := apperror.GetErrorStatusCode(err)
-		resp.Message = err.Error()
-		response.SendErrorResponse(w, resp, code)
+		response.SendError(w, r, err)
 		return
 	}
 
@@ -90,16 +265,13 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 
 	userId := chi.URLParam(r, "user_id")
 	if !validator.IsValidUUID(userId) {
-		resp.Message = "Invalid user ID"
-		response.SendErrorResponse(w, resp, http.StatusBadRequest)
+		response.SendError(w, r, apperror.InvalidParameter("user_id", "Invalid user ID"))
 		return
 	}
 
 	user, err := h.userService.GetUser(userId)
 	if err != nil {
-		code := apperror.GetErrorStatusCode(err)
-		resp.Message = err.Error()
-		response.SendErrorResponse(w, resp, code)
+		response.SendError(w, r, err)
 		return
 	}
 
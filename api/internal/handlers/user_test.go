@@ -1,7 +1,11 @@
 package handlers_test
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/csv"
+	stdjson "encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -19,6 +23,7 @@ import (
 	"github.com/princecee/lema-ai/pkg/json"
 	"github.com/princecee/lema-ai/pkg/pagination"
 	"github.com/princecee/lema-ai/pkg/response"
+	"github.com/princecee/lema-ai/pkg/store"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/suite"
 	"gorm.io/gorm"
@@ -35,9 +40,9 @@ func (s *UserHandlerTestSuite) SetupSuite() {
 	cfg.DSN = "file::memory:?cache=shared"
 	var logger zerolog.Logger
 
-	db := database.GetDBConn(cfg.DSN, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
+	db := database.GetDBConn(cfg.DSN, cfg.REPLICA_DSNS, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
 
-	err := db.AutoMigrate(&models.User{}, &models.Address{}, &models.Post{})
+	err := db.AutoMigrate(&models.User{}, &models.Address{}, &models.Post{}, &models.OutboxEvent{})
 	if err != nil {
 		s.Fail(err.Error())
 	}
@@ -71,19 +76,14 @@ func (s *UserHandlerTestSuite) SetupSuite() {
 	}
 
 	r := chi.NewRouter()
-	userRouter := routes.AddUserRoutes(db, userService, cfg, logger)
+	userRouter := routes.AddUserRoutes(db, userService, store.NewMemoryStore(), cfg, logger)
 	r.Mount("/api/v1/users", userRouter)
 
 	s.server = httptest.NewServer(r)
 }
 
 func (s *UserHandlerTestSuite) TearDownSuite() {
-	sqlDB, err := s.db.DB()
-	if err != nil {
-		s.Fail(err.Error())
-	}
-
-	sqlDB.Close()
+	s.NoError(database.Close(s.db))
 	s.server.Close()
 }
 
@@ -108,6 +108,7 @@ func (s *UserHandlerTestSuite) TestUserHandler() {
 		s.Equal(int64(5), int64(userLen))
 		s.Equal(int64(1), response.Data.Page)
 		s.Equal(int64(5), response.Data.Limit)
+		s.Contains(resp.Header.Get("Link"), `page=2>; rel="next"`)
 
 		userId = response.Data.Users[0].ID
 	})
@@ -130,6 +131,58 @@ func (s *UserHandlerTestSuite) TestUserHandler() {
 		s.Equal(int64(10), response.Data.Limit)
 	})
 
+	t.Run("Get users as CSV", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, url+"?page=2&limit=5", nil)
+		s.NoError(err)
+		req.Header.Set("Accept", "text/csv")
+
+		resp, err := s.server.Client().Do(req)
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+		defer resp.Body.Close()
+
+		s.Equal("text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+		s.Equal("20", resp.Header.Get("X-Total-Count"))
+		s.Contains(resp.Header.Get("Link"), `page=3>; rel="next"`)
+		s.Contains(resp.Header.Get("Link"), `page=1>; rel="prev"`)
+
+		records, err := csv.NewReader(resp.Body).ReadAll()
+		s.NoError(err)
+		s.Len(records, 6)
+		s.Equal([]string{
+			"id", "name", "email", "username", "phone",
+			"address.id", "address.street", "address.city", "address.state", "address.zipcode", "address.user_id",
+		}, records[0])
+		for _, record := range records[1:] {
+			s.Equal(record[0], record[10])
+			s.NotEmpty(record[9])
+		}
+	})
+
+	t.Run("Get users as NDJSON", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, url+"?page=4&limit=5", nil)
+		s.NoError(err)
+		req.Header.Set("Accept", "application/x-ndjson")
+
+		resp, err := s.server.Client().Do(req)
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+		defer resp.Body.Close()
+
+		s.Equal("application/x-ndjson; charset=utf-8", resp.Header.Get("Content-Type"))
+		s.NotContains(resp.Header.Get("Link"), `rel="next"`)
+
+		scanner := bufio.NewScanner(resp.Body)
+		var users []models.User
+		for scanner.Scan() {
+			var user models.User
+			s.NoError(stdjson.Unmarshal(scanner.Bytes(), &user))
+			users = append(users, user)
+		}
+		s.Len(users, 5)
+		s.NotEmpty(users[0].Address.Street)
+	})
+
 	t.Run("Get users count", func(t *testing.T) {
 		resp, err := s.server.Client().Get(url + "/count")
 		s.NoError(err)
@@ -187,6 +240,123 @@ func (s *UserHandlerTestSuite) TestUserHandler() {
 		s.Equal("Invalid user ID", response.Message)
 		s.Empty(response.Data)
 	})
+
+	newUser := map[string]any{
+		"name":     "  Jane Doe ",
+		"username": "janedoe",
+		"email":    " Jane.Doe@Example.com",
+		"phone":    "(555) 123-4567",
+		"address": map[string]any{
+			"street":  "1 Main St",
+			"city":    "Springfield",
+			"state":   "IL",
+			"zipcode": "62701-1234",
+		},
+	}
+
+	t.Run("Create user", func(t *testing.T) {
+		payload, _ := json.WriteJSON(newUser)
+
+		resp, err := s.server.Client().Post(url, "application/json", bytes.NewBuffer(payload))
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+		defer resp.Body.Close()
+
+		response := response.Response[models.User]{}
+		_ = json.ReadJSON(resp.Body, &response)
+
+		s.Equal(true, *response.Success)
+		s.Equal("User created successfully", response.Message)
+		s.NotEmpty(response.Data.ID)
+		s.Equal("Jane Doe", response.Data.Name)
+		s.Equal("jane.doe@example.com", response.Data.Email)
+		s.Equal("+15551234567", response.Data.Phone)
+		s.Equal("62701-1234", response.Data.Address.Zipcode)
+
+		userId = response.Data.ID
+	})
+
+	t.Run("Create duplicate user", func(t *testing.T) {
+		payload, _ := json.WriteJSON(newUser)
+
+		resp, err := s.server.Client().Post(url, "application/json", bytes.NewBuffer(payload))
+		s.NoError(err)
+		s.Equal(http.StatusConflict, resp.StatusCode)
+		defer resp.Body.Close()
+
+		response := response.Response[any]{}
+		_ = json.ReadJSON(resp.Body, &response)
+		s.Equal("A user with this email, username or phone already exists", response.Message)
+	})
+
+	t.Run("Create user with invalid fields", func(t *testing.T) {
+		payload, _ := json.WriteJSON(map[string]any{
+			"name":     " ",
+			"username": "someone",
+			"email":    "someone@localhost",
+			"phone":    "12345",
+			"address": map[string]any{
+				"street":  "1 Main St",
+				"city":    "Springfield",
+				"state":   "IL",
+				"zipcode": "K1A 0B1",
+			},
+		})
+
+		resp, err := s.server.Client().Post(url, "application/json", bytes.NewBuffer(payload))
+		s.NoError(err)
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+		defer resp.Body.Close()
+
+		response := response.Response[map[string]string]{}
+		_ = json.ReadJSON(resp.Body, &response)
+		s.Equal("bad request", response.Message)
+		s.Equal(map[string]string{
+			"name":            "name is a required field",
+			"email":           "email must be a deliverable email address",
+			"phone":           "phone must be a valid E.164 formatted phone number",
+			"address.zipcode": "zipcode must be a US ZIP code such as 12345 or 12345-6789",
+		}, response.Data)
+	})
+
+	t.Run("Update user", func(t *testing.T) {
+		payload, _ := json.WriteJSON(map[string]any{
+			"phone":   "+44 20 7946 0958",
+			"address": map[string]any{"zipcode": "10001"},
+		})
+
+		req, err := http.NewRequest(http.MethodPatch, url+"/"+userId, bytes.NewBuffer(payload))
+		s.NoError(err)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.server.Client().Do(req)
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+		defer resp.Body.Close()
+
+		response := response.Response[models.User]{}
+		_ = json.ReadJSON(resp.Body, &response)
+		s.Equal("User updated successfully", response.Message)
+		s.Equal("Jane Doe", response.Data.Name)
+		s.Equal("+442079460958", response.Data.Phone)
+		s.Equal("10001", response.Data.Address.Zipcode)
+		s.Equal("Springfield", response.Data.Address.City)
+	})
+
+	t.Run("Update user with blank name", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPatch, url+"/"+userId, bytes.NewBufferString(`{"name": "  "}`))
+		s.NoError(err)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.server.Client().Do(req)
+		s.NoError(err)
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+		defer resp.Body.Close()
+
+		response := response.Response[map[string]string]{}
+		_ = json.ReadJSON(resp.Body, &response)
+		s.Equal("name must not be blank", response.Data["name"])
+	})
 }
 
 func TestUserHandler(t *testing.T) {
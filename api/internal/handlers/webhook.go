@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/princecee/lema-ai/config"
+	"github.com/princecee/lema-ai/internal/db/models"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/json"
+	"github.com/princecee/lema-ai/pkg/response"
+	"github.com/princecee/lema-ai/pkg/validator"
+	"github.com/rs/zerolog"
+)
+
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, w *models.Webhook) error
+	GetWebhook(ctx context.Context, webhookId string) (*models.Webhook, error)
+	GetWebhooks(ctx context.Context) ([]*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookId string) error
+	GetDeliveries(ctx context.Context, webhookId string) ([]*models.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, webhookId, deliveryId string) (*models.WebhookDelivery, error)
+	Redeliver(ctx context.Context, webhookId, deliveryId string) (*models.WebhookDelivery, error)
+}
+
+type WebhookHandler struct {
+	webhookService WebhookService
+	config         *config.Config
+	logger         zerolog.Logger
+}
+
+func NewWebhookHandler(webhookService WebhookService, cfg *config.Config, l zerolog.Logger) *WebhookHandler {
+	return &WebhookHandler{webhookService, cfg, l}
+}
+
+type createWebhookData struct {
+	URL    string   `json:"url" mod:"trim" validate:"required,http_url,max=2000"`
+	Events []string `json:"events" validate:"required,min=1,unique,dive,oneof=post.created post.updated post.deleted user.created user.updated user.deleted"`
+	// Secret signs deliveries. One is generated when it is omitted.
+	Secret string `json:"secret" validate:"omitempty,min=16,max=200"`
+}
+
+// createdWebhook is the only response that includes the secret.
+type createdWebhook struct {
+	models.Webhook
+	Secret string `json:"secret"`
+}
+
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	resp := response.Response[any]{}
+	data := new(createWebhookData)
+
+	err := json.ReadJSONStrict(r.Body, data)
+	defer r.Body.Close()
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	if err := validator.ValidateData(r.Context(), data); err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	webhook := &models.Webhook{
+		URL:    data.URL,
+		Events: data.Events,
+		Secret: data.Secret,
+	}
+
+	if err := h.webhookService.CreateWebhook(r.Context(), webhook); err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	status := http.StatusCreated
+	resp.StatusCode = &status
+	resp.Message = "Webhook created successfully"
+	resp.Data = createdWebhook{*webhook, webhook.Secret}
+	response.SendResponse(w, resp, map[string]string{
+		"Location": "/api/v1/webhooks/" + webhook.ID,
+	})
+}
+
+func (h *WebhookHandler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	resp := response.Response[any]{}
+
+	webhooks, err := h.webhookService.GetWebhooks(r.Context())
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	resp.Message = "Webhooks fetched successfully"
+	resp.Data = webhooks
+	response.SendResponse(w, resp, nil)
+}
+
+func (h *WebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	resp := response.Response[any]{}
+
+	webhookId, ok := webhookIdParam(w, r)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.GetWebhook(r.Context(), webhookId)
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	resp.Message = "Webhook fetched successfully"
+	resp.Data = webhook
+	response.SendResponse(w, resp, nil)
+}
+
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	resp := response.Response[any]{}
+
+	webhookId, ok := webhookIdParam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(r.Context(), webhookId); err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	resp.Message = "Webhook deleted successfully"
+	response.SendResponse(w, resp, nil)
+}
+
+func (h *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	resp := response.Response[any]{}
+
+	webhookId, ok := webhookIdParam(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.webhookService.GetDeliveries(r.Context(), webhookId)
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	resp.Message = "Deliveries fetched successfully"
+	resp.Data = deliveries
+	response.SendResponse(w, resp, nil)
+}
+
+func (h *WebhookHandler) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	resp := response.Response[any]{}
+
+	webhookId, deliveryId, ok := deliveryIdParams(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.GetDelivery(r.Context(), webhookId, deliveryId)
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	resp.Message = "Delivery fetched successfully"
+	resp.Data = delivery
+	response.SendResponse(w, resp, nil)
+}
+
+// Redeliver queues a delivery to be sent again with its original payload.
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	resp := response.Response[any]{}
+
+	webhookId, deliveryId, ok := deliveryIdParams(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(r.Context(), webhookId, deliveryId)
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	status := http.StatusAccepted
+	resp.StatusCode = &status
+	resp.Message = "Delivery queued successfully"
+	resp.Data = delivery
+	response.SendResponse(w, resp, nil)
+}
+
+func webhookIdParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	webhookId := chi.URLParam(r, "webhook_id")
+	if !validator.IsValidUUID(webhookId) {
+		response.SendError(w, r, apperror.InvalidParameter("webhook_id", "Invalid webhook ID"))
+		return "", false
+	}
+	return webhookId, true
+}
+
+func deliveryIdParams(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	webhookId, ok := webhookIdParam(w, r)
+	if !ok {
+		return "", "", false
+	}
+
+	deliveryId := chi.URLParam(r, "delivery_id")
+	if !validator.IsValidUUID(deliveryId) {
+		response.SendError(w, r, apperror.InvalidParameter("delivery_id", "Invalid delivery ID"))
+		return "", "", false
+	}
+	return webhookId, deliveryId, true
+}
@@ -0,0 +1,361 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/princecee/lema-ai/config"
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/internal/db/repositories"
+	"github.com/princecee/lema-ai/internal/middlewares"
+	"github.com/princecee/lema-ai/internal/routes"
+	"github.com/princecee/lema-ai/internal/services"
+	"github.com/princecee/lema-ai/pkg/json"
+	"github.com/princecee/lema-ai/pkg/response"
+	"github.com/princecee/lema-ai/pkg/store"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// receivedWebhook is a request seen by the test receiver.
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+type WebhookHandlerTestSuite struct {
+	suite.Suite
+	db       *gorm.DB
+	server   *httptest.Server
+	receiver *httptest.Server
+	jobQueue *services.JobQueue
+	relay    *services.OutboxRelay
+	user     *models.User
+
+	mu       sync.Mutex
+	received []receivedWebhook
+	// failures is the number of requests the receiver rejects before
+	// accepting again.
+	failures int
+}
+
+func (s *WebhookHandlerTestSuite) SetupSuite() {
+	cfg := config.NewConfig("test", "silent")
+	cfg.DSN = "file:webhooks?mode=memory&cache=shared"
+	cfg.RATE_LIMIT_WEBHOOKS = config.RateLimit{}
+	cfg.RATE_LIMIT_POSTS_WRITE = config.RateLimit{}
+	cfg.API_KEYS = []string{testAPIKey}
+	var logger zerolog.Logger
+
+	db := database.GetDBConn(cfg.DSN, cfg.REPLICA_DSNS, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
+
+	err := database.Migrate(db)
+	if err != nil {
+		s.Fail(err.Error())
+	}
+	s.db = db
+
+	s.user = &models.User{
+		ID:       uuid.NewString(),
+		Name:     "Jane Doe",
+		Username: "jane",
+		Email:    "jane@example.com",
+		Phone:    "+15550000000",
+		Address:  models.Address{ID: uuid.NewString(), Street: "1 Main St", City: "Springfield", State: "IL", Zipcode: "62701"},
+	}
+	if err := repositories.NewUserRepository(db).CreateUser(context.Background(), s.user); err != nil {
+		s.Fail(err.Error())
+	}
+
+	s.receiver = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.received = append(s.received, receivedWebhook{r.Header.Clone(), body})
+		if s.failures > 0 {
+			s.failures--
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	s.jobQueue = services.NewJobQueue(repositories.NewJobRepository(db), services.JobOptions{
+		Workers:      1,
+		PollInterval: 10 * time.Millisecond,
+		LockTimeout:  time.Minute,
+		MaxAttempts:  3,
+		BackoffBase:  time.Millisecond,
+		BackoffMax:   time.Millisecond,
+	}, logger)
+	webhookService := services.NewWebhookService(database.NewTransactor(db), repositories.NewWebhookRepository(db), s.jobQueue, services.WebhookOptions{
+		Timeout:              5 * time.Second,
+		AllowPrivateNetworks: true,
+	}, logger)
+	s.jobQueue.Register(services.WebhookJobKind, webhookService.RunDeliveryJob)
+	s.jobQueue.Start(context.Background())
+
+	s.relay = services.NewOutboxRelay(repositories.NewOutboxRepository(db), map[string]services.Sink{"webhook": webhookService}, services.OutboxOptions{
+		PollInterval: 10 * time.Millisecond,
+		BatchSize:    100,
+	}, logger)
+	s.relay.Start(context.Background())
+
+	postService := services.NewPostService(repositories.NewPostRepository(db))
+
+	r := chi.NewRouter()
+	r.Use(middlewares.Locale)
+	r.Mount("/api/v1/webhooks", routes.AddWebhookRoutes(webhookService, middlewares.NewAPIKeys(cfg.API_KEYS, nil), store.NewMemoryStore(), cfg, logger))
+	r.Mount("/api/v1/posts", routes.AddPostRoutes(db, postService, services.NewEventStream(10), store.NewMemoryStore(), cfg, logger))
+
+	s.server = httptest.NewServer(r)
+	s.server.Client().Transport = apiKeyTransport{testAPIKey}
+}
+
+// apiKeyTransport adds the API key the webhook routes require to every
+// request.
+type apiKeyTransport struct {
+	key string
+}
+
+func (t apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(middlewares.APIKeyHeader, t.key)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (s *WebhookHandlerTestSuite) TearDownSuite() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.NoError(s.relay.Shutdown(ctx))
+	s.NoError(s.jobQueue.Shutdown(ctx))
+
+	s.NoError(database.Close(s.db))
+	s.server.Close()
+	s.receiver.Close()
+}
+
+func (s *WebhookHandlerTestSuite) SetupTest() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.received = nil
+	s.failures = 0
+}
+
+func (s *WebhookHandlerTestSuite) post(path string, payload any) *http.Response {
+	body, _ := json.WriteJSON(payload)
+	resp, err := s.server.Client().Post(s.server.URL+path, "application/json", bytes.NewBuffer(body))
+	s.Require().NoError(err)
+	return resp
+}
+
+func (s *WebhookHandlerTestSuite) createWebhook(events ...string) (webhookId, secret string) {
+	resp := s.post("/api/v1/webhooks", map[string]any{"url": s.receiver.URL, "events": events})
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+
+	result := response.Response[map[string]any]{}
+	_ = json.ReadJSON(resp.Body, &result)
+	webhookId, _ = result.Data["id"].(string)
+	secret, _ = result.Data["secret"].(string)
+	s.Require().NotEmpty(secret)
+	s.T().Cleanup(func() {
+		req, _ := http.NewRequest(http.MethodDelete, s.server.URL+"/api/v1/webhooks/"+webhookId, nil)
+		resp, err := s.server.Client().Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+	})
+	return webhookId, secret
+}
+
+func (s *WebhookHandlerTestSuite) createPost() string {
+	resp := s.post("/api/v1/posts", map[string]any{"title": "Title", "body": "Body", "userId": s.user.ID})
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	result := response.Response[models.Post]{}
+	_ = json.ReadJSON(resp.Body, &result)
+	return result.Data.ID
+}
+
+// waitForDelivery waits until the latest delivery of a webhook reaches
+// status and returns it.
+func (s *WebhookHandlerTestSuite) waitForDelivery(webhookId, status string) *models.WebhookDelivery {
+	var delivery *models.WebhookDelivery
+	s.Require().Eventually(func() bool {
+		resp, err := s.server.Client().Get(s.server.URL + "/api/v1/webhooks/" + webhookId + "/deliveries")
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+
+		result := response.Response[[]*models.WebhookDelivery]{}
+		_ = json.ReadJSON(resp.Body, &result)
+		if len(result.Data) == 0 {
+			return false
+		}
+		delivery = result.Data[0]
+		return delivery.Status == status
+	}, 5*time.Second, 10*time.Millisecond)
+	return delivery
+}
+
+func (s *WebhookHandlerTestSuite) TestSignedDelivery() {
+	webhookId, secret := s.createWebhook(models.EventPostCreated)
+	postId := s.createPost()
+
+	delivery := s.waitForDelivery(webhookId, models.WebhookDeliverySucceeded)
+	s.Equal(models.EventPostCreated, delivery.Event)
+	s.Require().Len(delivery.Attempts, 1)
+	s.Equal(http.StatusNoContent, delivery.Attempts[0].StatusCode)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Require().Len(s.received, 1)
+	got := s.received[0]
+	s.Equal(models.EventPostCreated, got.header.Get(services.WebhookEventHeader))
+	s.Equal(delivery.ID, got.header.Get(services.WebhookDeliveryHeader))
+
+	timestamp, err := strconv.ParseInt(got.header.Get(services.WebhookTimestampHeader), 10, 64)
+	s.Require().NoError(err)
+	s.Equal(services.SignWebhook(secret, timestamp, got.body), got.header.Get(services.WebhookSignatureHeader))
+
+	var event struct {
+		Event string      `json:"event"`
+		Data  models.Post `json:"data"`
+	}
+	s.Require().NoError(json.ReadJSON(io.NopCloser(bytes.NewReader(got.body)), &event))
+	s.Equal(models.EventPostCreated, event.Event)
+	s.Equal(postId, event.Data.ID)
+}
+
+func (s *WebhookHandlerTestSuite) TestRetryAndRedeliver() {
+	s.mu.Lock()
+	s.failures = 1
+	s.mu.Unlock()
+
+	webhookId, _ := s.createWebhook(models.EventPostDeleted)
+	postId := s.createPost()
+
+	req, _ := http.NewRequest(http.MethodDelete, s.server.URL+"/api/v1/posts/"+postId, nil)
+	resp, err := s.server.Client().Do(req)
+	s.Require().NoError(err)
+	resp.Body.Close()
+
+	delivery := s.waitForDelivery(webhookId, models.WebhookDeliverySucceeded)
+	s.Equal(models.EventPostDeleted, delivery.Event)
+	s.Require().Len(delivery.Attempts, 2)
+	s.Equal(http.StatusServiceUnavailable, delivery.Attempts[0].StatusCode)
+	s.NotEmpty(delivery.Attempts[0].Error)
+	s.Empty(delivery.Attempts[1].Error)
+
+	resp = s.post("/api/v1/webhooks/"+webhookId+"/deliveries/"+delivery.ID+"/redeliver", nil)
+	resp.Body.Close()
+	s.Equal(http.StatusAccepted, resp.StatusCode)
+
+	s.Eventually(func() bool {
+		resp, err := s.server.Client().Get(s.server.URL + "/api/v1/webhooks/" + webhookId + "/deliveries/" + delivery.ID)
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+
+		result := response.Response[*models.WebhookDelivery]{}
+		_ = json.ReadJSON(resp.Body, &result)
+		return result.Data != nil && result.Data.Status == models.WebhookDeliverySucceeded && len(result.Data.Attempts) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Require().Len(s.received, 3)
+	s.Equal(s.received[1].body, s.received[2].body)
+}
+
+func (s *WebhookHandlerTestSuite) TestWebhookHandler() {
+	t := s.T()
+
+	t.Run("Require an API key", func(t *testing.T) {
+		resp, err := http.Get(s.server.URL + "/api/v1/webhooks")
+		s.NoError(err)
+		defer resp.Body.Close()
+		s.Equal(http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Hide the secret after creation", func(t *testing.T) {
+		webhookId, _ := s.createWebhook(models.EventUserCreated)
+
+		resp, err := s.server.Client().Get(s.server.URL + "/api/v1/webhooks/" + webhookId)
+		s.NoError(err)
+		defer resp.Body.Close()
+		s.Equal(http.StatusOK, resp.StatusCode)
+
+		result := response.Response[map[string]any]{}
+		_ = json.ReadJSON(resp.Body, &result)
+		s.Equal(webhookId, result.Data["id"])
+		s.NotContains(result.Data, "secret")
+	})
+
+	t.Run("Only deliver subscribed events", func(t *testing.T) {
+		webhookId, _ := s.createWebhook(models.EventUserUpdated)
+		s.createPost()
+
+		resp, err := s.server.Client().Get(s.server.URL + "/api/v1/webhooks/" + webhookId + "/deliveries")
+		s.NoError(err)
+		defer resp.Body.Close()
+
+		result := response.Response[[]*models.WebhookDelivery]{}
+		_ = json.ReadJSON(resp.Body, &result)
+		s.Empty(result.Data)
+	})
+
+	t.Run("Reject unknown events", func(t *testing.T) {
+		resp := s.post("/api/v1/webhooks", map[string]any{"url": s.receiver.URL, "events": []string{"post.liked"}})
+		defer resp.Body.Close()
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Reject non-HTTP URLs", func(t *testing.T) {
+		resp := s.post("/api/v1/webhooks", map[string]any{"url": "ftp://example.com", "events": []string{models.EventPostCreated}})
+		defer resp.Body.Close()
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Redeliver an unknown delivery", func(t *testing.T) {
+		webhookId, _ := s.createWebhook(models.EventPostCreated)
+		resp := s.post("/api/v1/webhooks/"+webhookId+"/deliveries/"+uuid.NewString()+"/redeliver", nil)
+		defer resp.Body.Close()
+		s.Equal(http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Delete a webhook", func(t *testing.T) {
+		webhookId, _ := s.createWebhook(models.EventPostCreated)
+
+		req, _ := http.NewRequest(http.MethodDelete, s.server.URL+"/api/v1/webhooks/"+webhookId, nil)
+		resp, err := s.server.Client().Do(req)
+		s.NoError(err)
+		resp.Body.Close()
+		s.Equal(http.StatusOK, resp.StatusCode)
+
+		resp, err = s.server.Client().Get(s.server.URL + "/api/v1/webhooks/" + webhookId)
+		s.NoError(err)
+		resp.Body.Close()
+		s.Equal(http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestWebhookHandler(t *testing.T) {
+	suite.Run(t, new(WebhookHandlerTestSuite))
+}
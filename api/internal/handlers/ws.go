@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/princecee/lema-ai/config"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/i18n"
+	"github.com/rs/zerolog"
+	"golang.org/x/net/websocket"
+)
+
+type Hub interface {
+	Connect() <-chan []byte
+	Subscribe(client <-chan []byte, topic string) error
+	Unsubscribe(client <-chan []byte, topic string)
+	Disconnect(client <-chan []byte)
+}
+
+// maxFrameBytes bounds the frames clients may send.
+const maxFrameBytes = 4 << 10
+
+// wsFrame is a frame sent by a client, or a reply to one. Clients send
+// {"type":"subscribe","topic":"posts:all"} or "unsubscribe" frames and get
+// "subscribed", "unsubscribed" or "error" frames back.
+type wsFrame struct {
+	Type    string        `json:"type"`
+	Topic   string        `json:"topic,omitempty"`
+	Code    apperror.Code `json:"code,omitempty"`
+	Message string        `json:"message,omitempty"`
+}
+
+type WebSocketHandler struct {
+	hub    Hub
+	config *config.Config
+	logger zerolog.Logger
+}
+
+func NewWebSocketHandler(hub Hub, cfg *config.Config, l zerolog.Logger) *WebSocketHandler {
+	return &WebSocketHandler{hub, cfg, l}
+}
+
+// Connect upgrades the request to a WebSocket and relays the events of the
+// topics the client subscribes to. Callers are authenticated before the
+// upgrade, so any origin is accepted.
+func (h *WebSocketHandler) Connect(w http.ResponseWriter, r *http.Request) {
+	locale := i18n.LocaleFromContext(r.Context())
+	websocket.Server{Handler: func(conn *websocket.Conn) { h.serve(conn, locale) }}.ServeHTTP(w, r)
+}
+
+func (h *WebSocketHandler) serve(conn *websocket.Conn, locale string) {
+	defer conn.Close()
+	conn.MaxPayloadBytes = maxFrameBytes
+
+	client := h.hub.Connect()
+	defer h.hub.Disconnect(client)
+
+	go func() {
+		// Disconnecting closes client, which ends the write loop below.
+		defer h.hub.Disconnect(client)
+		h.read(conn, client, locale)
+	}()
+
+	for frame := range client {
+		if err := h.write(conn, frame); err != nil {
+			return
+		}
+	}
+}
+
+// read handles the frames of a client until it disconnects.
+func (h *WebSocketHandler) read(conn *websocket.Conn, client <-chan []byte, locale string) {
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(conn, &data); err != nil {
+			return
+		}
+
+		var frame, reply wsFrame
+		switch err := json.Unmarshal(data, &frame); {
+		case err != nil:
+			reply = errorFrame(apperror.ErrInvalidJSON, locale)
+		case frame.Type == "subscribe":
+			if err := h.hub.Subscribe(client, frame.Topic); err != nil {
+				reply = errorFrame(err, locale)
+			} else {
+				reply = wsFrame{Type: "subscribed", Topic: frame.Topic}
+			}
+		case frame.Type == "unsubscribe":
+			h.hub.Unsubscribe(client, frame.Topic)
+			reply = wsFrame{Type: "unsubscribed", Topic: frame.Topic}
+		default:
+			reply = errorFrame(apperror.InvalidParameter("type", "Unknown frame type {0}", frame.Type), locale)
+		}
+
+		data, _ = json.Marshal(reply)
+		if err := h.write(conn, data); err != nil {
+			return
+		}
+	}
+}
+
+func (h *WebSocketHandler) write(conn *websocket.Conn, data []byte) error {
+	conn.SetWriteDeadline(time.Now().Add(h.config.WS_WRITE_TIMEOUT))
+	return websocket.Message.Send(conn, string(data))
+}
+
+func errorFrame(err error, locale string) wsFrame {
+	appErr := apperror.FromError(err).Localize(locale)
+	return wsFrame{Type: "error", Code: appErr.Code, Message: appErr.Message}
+}
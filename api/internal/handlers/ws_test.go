@@ -0,0 +1,195 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/princecee/lema-ai/config"
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/internal/db/repositories"
+	"github.com/princecee/lema-ai/internal/handlers"
+	"github.com/princecee/lema-ai/internal/middlewares"
+	"github.com/princecee/lema-ai/internal/routes"
+	"github.com/princecee/lema-ai/internal/services"
+	"github.com/princecee/lema-ai/pkg/store"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/websocket"
+	"gorm.io/gorm"
+)
+
+const testAPIKey = "test-api-key"
+
+// wsMessage holds the fields of every frame the server sends.
+type wsMessage struct {
+	Type    string         `json:"type"`
+	Topic   string         `json:"topic"`
+	ID      string         `json:"id"`
+	Event   string         `json:"event"`
+	Data    map[string]any `json:"data"`
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+}
+
+type WebSocketHandlerTestSuite struct {
+	suite.Suite
+	db     *gorm.DB
+	server *httptest.Server
+	// api serves the whole API, to check that the middlewares in front of
+	// the WebSocket route keep the connection hijackable.
+	api    *httptest.Server
+	stream *services.EventStream
+	hub    *services.Hub
+}
+
+func (s *WebSocketHandlerTestSuite) SetupSuite() {
+	cfg := config.NewConfig("test", "silent")
+	cfg.API_KEYS = []string{testAPIKey}
+	logger := zerolog.Nop()
+
+	s.stream = services.NewEventStream(10)
+	s.hub = services.NewHub(s.stream, services.HubOptions{SendBuffer: 16}, logger)
+	s.hub.Start(context.Background())
+
+	r := chi.NewRouter()
+	r.Use(middlewares.Locale)
+	r.Mount("/api/v1/ws", routes.AddWebSocketRoutes(s.hub, middlewares.NewAPIKeys(cfg.API_KEYS, nil), cfg, logger))
+	s.server = httptest.NewServer(r)
+
+	cfg.DSN = "file::memory:?cache=shared"
+	s.db = database.GetDBConn(cfg.DSN, cfg.REPLICA_DSNS, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
+	jobQueue := services.NewJobQueue(repositories.NewJobRepository(s.db), services.JobOptions{}, logger)
+	exportService := services.NewExportService(database.NewTransactor(s.db), repositories.NewExportRepository(s.db), jobQueue, services.ExportOptions{}, logger)
+	webhookService := services.NewWebhookService(database.NewTransactor(s.db), repositories.NewWebhookRepository(s.db), jobQueue, services.WebhookOptions{}, logger)
+	health := handlers.NewHealthHandler(nil, cfg, logger)
+	s.api = httptest.NewServer(routes.NewRouter(s.db, store.NewMemoryStore(), health, exportService, webhookService, s.stream, s.hub, cfg, logger))
+}
+
+func (s *WebSocketHandlerTestSuite) TearDownSuite() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.NoError(s.hub.Shutdown(ctx))
+	s.server.Close()
+	s.api.Close()
+	s.NoError(database.Close(s.db))
+}
+
+// dial opens a WebSocket with the given headers. It is closed when the
+// test ends.
+func (s *WebSocketHandlerTestSuite) dial(header http.Header) *websocket.Conn {
+	return s.dialServer(s.server, header)
+}
+
+func (s *WebSocketHandlerTestSuite) dialServer(server *httptest.Server, header http.Header) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/ws"
+	wsConfig, err := websocket.NewConfig(url, server.URL)
+	s.Require().NoError(err)
+	for k, v := range header {
+		wsConfig.Header[k] = v
+	}
+
+	conn, err := websocket.DialConfig(wsConfig)
+	s.Require().NoError(err)
+	s.T().Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func (s *WebSocketHandlerTestSuite) send(conn *websocket.Conn, frame string) {
+	s.Require().NoError(websocket.Message.Send(conn, frame))
+}
+
+func (s *WebSocketHandlerTestSuite) receive(conn *websocket.Conn) wsMessage {
+	var msg wsMessage
+	s.Require().NoError(conn.SetReadDeadline(time.Now().Add(5 * time.Second)))
+	s.Require().NoError(websocket.JSON.Receive(conn, &msg))
+	return msg
+}
+
+func (s *WebSocketHandlerTestSuite) TestUnauthorized() {
+	resp, err := http.Get(s.server.URL + "/api/v1/ws")
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Equal(http.StatusUnauthorized, resp.StatusCode)
+
+	resp, err = http.Get(s.server.URL + "/api/v1/ws?api_key=wrong")
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Equal(http.StatusUnauthorized, resp.StatusCode)
+}
+
+func (s *WebSocketHandlerTestSuite) TestSubscribe() {
+	userId := uuid.NewString()
+	conn := s.dial(http.Header{middlewares.APIKeyHeader: {testAPIKey}})
+
+	s.send(conn, `{"type":"subscribe","topic":"user:`+userId+`:posts"}`)
+	s.Equal(wsMessage{Type: "subscribed", Topic: "user:" + userId + ":posts"}, s.receive(conn))
+
+	postId := uuid.NewString()
+	event := &models.OutboxEvent{
+		ID:      uuid.NewString(),
+		Event:   models.EventPostCreated,
+		Payload: `{"id":"` + postId + `","user_id":"` + userId + `"}`,
+	}
+	s.Require().NoError(s.stream.Publish(context.Background(), event))
+
+	msg := s.receive(conn)
+	s.Equal("event", msg.Type)
+	s.Equal("user:"+userId+":posts", msg.Topic)
+	s.Equal(event.ID, msg.ID)
+	s.Equal(models.EventPostCreated, msg.Event)
+	s.Equal(postId, msg.Data["id"])
+
+	s.send(conn, `{"type":"unsubscribe","topic":"user:`+userId+`:posts"}`)
+	s.Equal(wsMessage{Type: "unsubscribed", Topic: "user:" + userId + ":posts"}, s.receive(conn))
+}
+
+func (s *WebSocketHandlerTestSuite) TestInvalidFrames() {
+	conn := s.dial(http.Header{"Authorization": {"Bearer " + testAPIKey}, "Accept-Language": {"fr"}})
+
+	s.send(conn, `{"type":"subscribe","topic":"comments"}`)
+	msg := s.receive(conn)
+	s.Equal("error", msg.Type)
+	s.Equal("invalid_parameter", msg.Code)
+	s.Equal("Sujet inconnu comments", msg.Message)
+
+	s.send(conn, `{"type":"publish"}`)
+	s.Equal("invalid_parameter", s.receive(conn).Code)
+
+	s.send(conn, `not json`)
+	s.Equal("invalid_json", s.receive(conn).Code)
+
+	// The connection stays usable.
+	s.send(conn, `{"type":"subscribe","topic":"posts:all"}`)
+	s.Equal("subscribed", s.receive(conn).Type)
+}
+
+func (s *WebSocketHandlerTestSuite) TestThroughRouter() {
+	conn := s.dialServer(s.api, http.Header{
+		middlewares.APIKeyHeader:         {testAPIKey},
+		middlewares.IdempotencyKeyHeader: {uuid.NewString()},
+		"Accept-Language":                {"fr"},
+	})
+
+	s.send(conn, `{"type":"subscribe","topic":"posts:all"}`)
+	s.Equal(wsMessage{Type: "subscribed", Topic: "posts:all"}, s.receive(conn))
+
+	event := &models.OutboxEvent{ID: uuid.NewString(), Event: models.EventPostCreated, Payload: `{"id":"` + uuid.NewString() + `"}`}
+	s.Require().NoError(s.stream.Publish(context.Background(), event))
+	msg := s.receive(conn)
+	s.Equal("event", msg.Type)
+	s.Equal(event.ID, msg.ID)
+
+	s.send(conn, `{"type":"subscribe","topic":"comments"}`)
+	s.Equal("Sujet inconnu comments", s.receive(conn).Message)
+}
+
+func TestWebSocketHandler(t *testing.T) {
+	suite.Run(t, new(WebSocketHandlerTestSuite))
+}
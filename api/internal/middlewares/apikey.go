@@ -0,0 +1,192 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/princecee/lema-ai/internal/db/models"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/response"
+	"gorm.io/gorm"
+)
+
+const (
+	APIKeyHeader = "X-API-Key"
+	// APIKeyParam carries the key of clients that cannot set headers, such
+	// as browsers opening a WebSocket.
+	APIKeyParam = "api_key"
+
+	// apiKeyMissTTL is how long a key found in neither set is remembered
+	// as invalid, so a client retrying a made-up key does not cost a
+	// database lookup per request. A key created with lemactl meanwhile is
+	// accepted once it runs out.
+	apiKeyMissTTL = time.Minute
+	// maxAPIKeyMisses bounds the invalid keys remembered.
+	maxAPIKeyMisses = 10000
+)
+
+// StoredAPIKeys looks up the keys created with lemactl by hash.
+type StoredAPIKeys interface {
+	GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+}
+
+// APIKeys checks keys against a fixed set, whose keys are admins, then
+// against the stored keys, if any. Fixed keys are compared by hash in
+// constant time so the time taken does not tell which one matched.
+type APIKeys struct {
+	hashes [][32]byte
+	stored StoredAPIKeys
+
+	mu     sync.Mutex
+	misses map[[32]byte]time.Time
+}
+
+func NewAPIKeys(keys []string, stored StoredAPIKeys) *APIKeys {
+	hashes := make([][32]byte, len(keys))
+	for i, key := range keys {
+		hashes[i] = sha256.Sum256([]byte(key))
+	}
+	return &APIKeys{hashes: hashes, stored: stored, misses: make(map[[32]byte]time.Time)}
+}
+
+// Identify returns the identity of the caller holding key, a hash of the
+// key, or false if key is not one of the keys. A stored key that cannot be
+// looked up counts as invalid. Keys that are not stored are remembered for
+// apiKeyMissTTL.
+func (k *APIKeys) Identify(ctx context.Context, key string) (Identity, bool) {
+	if key == "" {
+		return Identity{}, false
+	}
+	hash := sha256.Sum256([]byte(key))
+	id := Identity{Kind: IdentityAPIKey, ID: hex.EncodeToString(hash[:8])}
+
+	valid := 0
+	for i := range k.hashes {
+		valid |= subtle.ConstantTimeCompare(hash[:], k.hashes[i][:])
+	}
+	if valid == 1 {
+		id.Role = models.RoleAdmin
+		return id, true
+	}
+
+	if k.stored == nil || k.missed(hash) {
+		return Identity{}, false
+	}
+	stored, err := k.stored.GetAPIKeyByHash(ctx, hex.EncodeToString(hash[:]))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		k.miss(hash)
+	}
+	if err != nil {
+		return Identity{}, false
+	}
+	id.Role = stored.Role
+	return id, true
+}
+
+func (k *APIKeys) missed(hash [32]byte) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	expiresAt, ok := k.misses[hash]
+	if ok && !time.Now().Before(expiresAt) {
+		delete(k.misses, hash)
+		return false
+	}
+	return ok
+}
+
+func (k *APIKeys) miss(hash [32]byte) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	if len(k.misses) >= maxAPIKeyMisses {
+		for h, expiresAt := range k.misses {
+			if !now.Before(expiresAt) {
+				delete(k.misses, h)
+			}
+		}
+	}
+	if len(k.misses) >= maxAPIKeyMisses {
+		// Every miss is recent: start over rather than grow.
+		clear(k.misses)
+	}
+	k.misses[hash] = now.Add(apiKeyMissTTL)
+}
+
+// APIKey rejects requests that do not carry one of keys in the X-API-Key
+// header, as an Authorization bearer token or in the api_key query
+// parameter. The caller is identified by a hash of its key, so rate limits
+// apply per key. No request is accepted when there are no keys.
+func APIKey(keys *APIKeys) func(http.Handler) http.Handler {
+	f := func(h http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			id, ok := keys.Identify(r.Context(), requestAPIKey(r))
+			if !ok {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				response.SendError(w, r, apperror.ErrUnauthorized.WithMessage("Missing or invalid API key"))
+				return
+			}
+
+			h.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), id)))
+		}
+		return http.HandlerFunc(fn)
+	}
+	return f
+}
+
+// IdentifyAPIKey identifies the callers carrying one of keys, as APIKey
+// does, so rate limits apply per key rather than per IP. Unlike APIKey it
+// lets every request through: requests without a valid key stay anonymous.
+func IdentifyAPIKey(keys *APIKeys) func(http.Handler) http.Handler {
+	f := func(h http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if id, ok := keys.Identify(r.Context(), requestAPIKey(r)); ok {
+				r = r.WithContext(WithIdentity(r.Context(), id))
+			}
+			h.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+	return f
+}
+
+// RequireRole rejects the callers whose API key role does not grant role.
+// It must come after APIKey.
+func RequireRole(role string) func(http.Handler) http.Handler {
+	f := func(h http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			id, _ := IdentityFromContext(r.Context())
+			if !models.RoleAllows(id.Role, role) {
+				response.SendError(w, r, ForbiddenRole(id.Role))
+				return
+			}
+			h.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+	return f
+}
+
+// ForbiddenRole is the error of a caller whose role does not allow its
+// request.
+func ForbiddenRole(role string) *apperror.AppError {
+	return apperror.ErrForbidden.WithMessage("API key role {0} may not do this", role)
+}
+
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return r.URL.Query().Get(APIKeyParam)
+}
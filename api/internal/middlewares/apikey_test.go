@@ -0,0 +1,165 @@
+package middlewares_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/internal/middlewares"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// storedKeys holds the roles of stored keys by the hash of the key.
+type storedKeys map[string]string
+
+func (k storedKeys) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	role, ok := k[hash]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &models.APIKey{Hash: hash, Role: role}, nil
+}
+
+// countedKeys counts the lookups of storedKeys, failing them with err if
+// set.
+type countedKeys struct {
+	storedKeys
+	lookups int
+	err     error
+}
+
+func (k *countedKeys) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	k.lookups++
+	if k.err != nil {
+		return nil, k.err
+	}
+	return k.storedKeys.GetAPIKeyByHash(ctx, hash)
+}
+
+type APIKeyTestSuite struct {
+	suite.Suite
+	handler  http.Handler
+	identity middlewares.Identity
+}
+
+func (s *APIKeyTestSuite) SetupTest() {
+	s.identity = middlewares.Identity{}
+	stored := storedKeys{models.HashAPIKey("stored-key"): models.RoleReader}
+	s.handler = middlewares.APIKey(middlewares.NewAPIKeys([]string{"first-key", "second-key"}, stored))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.identity, _ = middlewares.IdentityFromContext(r.Context())
+	}))
+}
+
+func (s *APIKeyTestSuite) do(target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *APIKeyTestSuite) TestAPIKey() {
+	t := s.T()
+
+	t.Run("Accepts a key from any source", func(t *testing.T) {
+		var ids []string
+		for _, tc := range []struct {
+			target string
+			header http.Header
+		}{
+			{"/", http.Header{"X-Api-Key": {"first-key"}}},
+			{"/", http.Header{"Authorization": {"Bearer first-key"}}},
+			{"/?api_key=first-key", nil},
+		} {
+			rec := s.do(tc.target, tc.header)
+			s.Equal(http.StatusOK, rec.Code)
+			s.Equal(middlewares.IdentityAPIKey, s.identity.Kind)
+			ids = append(ids, s.identity.ID)
+		}
+		s.NotEmpty(ids[0])
+		s.Equal([]string{ids[0], ids[0], ids[0]}, ids)
+
+		s.do("/", http.Header{"X-Api-Key": {"second-key"}})
+		s.NotEqual(ids[0], s.identity.ID)
+		s.Equal(models.RoleAdmin, s.identity.Role)
+	})
+
+	t.Run("Accepts stored keys with their role", func(t *testing.T) {
+		rec := s.do("/", http.Header{"X-Api-Key": {"stored-key"}})
+		s.Equal(http.StatusOK, rec.Code)
+		s.Equal(models.HashAPIKey("stored-key")[:16], s.identity.ID)
+		s.Equal(models.RoleReader, s.identity.Role)
+	})
+
+	t.Run("Rejects missing and unknown keys", func(t *testing.T) {
+		for _, header := range []http.Header{nil, {"X-Api-Key": {"other-key"}}, {"Authorization": {"Basic Zmlyc3Qta2V5"}}} {
+			s.SetupTest()
+			rec := s.do("/", header)
+			s.Equal(http.StatusUnauthorized, rec.Code)
+			s.Equal("Bearer", rec.Header().Get("WWW-Authenticate"))
+			s.Empty(s.identity.ID)
+		}
+	})
+
+	t.Run("Remembers unknown keys", func(t *testing.T) {
+		stored := &countedKeys{storedKeys: storedKeys{models.HashAPIKey("stored-key"): models.RoleReader}}
+		keys := middlewares.NewAPIKeys([]string{"first-key"}, stored)
+		for i := 0; i < 3; i++ {
+			_, ok := keys.Identify(context.Background(), "made-up-key")
+			s.False(ok)
+			_, ok = keys.Identify(context.Background(), "stored-key")
+			s.True(ok)
+		}
+		s.Equal(4, stored.lookups)
+
+		stored.err, stored.lookups = errors.New("database is down"), 0
+		for i := 0; i < 3; i++ {
+			_, ok := keys.Identify(context.Background(), "other-key")
+			s.False(ok)
+		}
+		s.Equal(3, stored.lookups, "failed lookups are not remembered")
+	})
+
+	t.Run("Rejects every key when none is configured", func(t *testing.T) {
+		h := middlewares.APIKey(middlewares.NewAPIKeys(nil, nil))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(middlewares.APIKeyHeader, "")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		s.Equal(http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestRequireRole(t *testing.T) {
+	stored := storedKeys{
+		models.HashAPIKey("writer-key"): models.RoleWriter,
+		models.HashAPIKey("reader-key"): models.RoleReader,
+	}
+	h := middlewares.APIKey(middlewares.NewAPIKeys([]string{"admin-key"}, stored))(
+		middlewares.RequireRole(models.RoleWriter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	for key, code := range map[string]int{
+		"admin-key":  http.StatusOK,
+		"writer-key": http.StatusOK,
+		"reader-key": http.StatusForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set(middlewares.APIKeyHeader, key)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != code {
+			t.Errorf("%s: got %d, want %d", key, rec.Code, code)
+		}
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	suite.Run(t, new(APIKeyTestSuite))
+}
@@ -0,0 +1,143 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/pkg/i18n"
+	"github.com/princecee/lema-ai/pkg/store"
+)
+
+// maxCachedBody is the size above which a response is not cached, so the
+// large bodies of streamed CSV and NDJSON lists are not held in memory.
+const maxCachedBody = 1 << 20
+
+// uncachedHeaders are the headers not replayed from the cache: hop-by-hop
+// headers, which only apply to the connection, and those set for the
+// client of the first response.
+var uncachedHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization", "Te", "Trailer",
+	"Transfer-Encoding", "Upgrade", "Set-Cookie", "X-Cache",
+}
+
+type cachedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// Cache serves successful GET responses from the store for ttl, with the
+// headers set by the handler. The cache is best-effort: store errors fall
+// through to the handler. A zero ttl disables caching. Requests pinned to
+// the primary by ReadYourWrites skip the cache, which may hold an older
+// read from a replica.
+func Cache(st store.Store, ttl time.Duration) func(http.Handler) http.Handler {
+	f := func(h http.Handler) http.Handler {
+		if ttl <= 0 {
+			return h
+		}
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet || r.Header.Get("Cache-Control") == "no-cache" || database.ReadsPrimary(r.Context()) {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			key := cacheKey(r)
+			if cached, ok := getCachedResponse(st, key); ok {
+				for name, values := range cached.Header {
+					w.Header()[name] = values
+				}
+				w.Header().Set("X-Cache", "HIT")
+				w.WriteHeader(cached.Status)
+				_, _ = w.Write(cached.Body)
+				return
+			}
+
+			// Headers set by the middlewares before this one, such as
+			// CORS headers, belong to this request only.
+			before := w.Header().Clone()
+			w.Header().Set("X-Cache", "MISS")
+			buf := &cappedBuffer{max: maxCachedBody}
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(buf)
+			h.ServeHTTP(ww, r)
+
+			if ww.Status() != http.StatusOK || buf.overflow {
+				return
+			}
+			header := http.Header{}
+			for name, values := range ww.Header() {
+				if !slices.Contains(uncachedHeaders, name) && !slices.Equal(before[name], values) {
+					header[name] = values
+				}
+			}
+			setCachedResponse(st, key, cachedResponse{
+				Status: ww.Status(),
+				Header: header,
+				Body:   buf.Bytes(),
+			}, ttl)
+		}
+		return http.HandlerFunc(fn)
+	}
+	return f
+}
+
+// cappedBuffer buffers up to max bytes, then drops what it holds and
+// ignores the rest.
+type cappedBuffer struct {
+	bytes.Buffer
+	max      int
+	overflow bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if b.overflow {
+		return len(p), nil
+	}
+	if b.Len()+len(p) > b.max {
+		b.overflow = true
+		b.Buffer = bytes.Buffer{}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+func cacheKey(r *http.Request) string {
+	locale := i18n.LocaleFromContext(r.Context())
+	return fmt.Sprintf("cache:%s:%s:%s", r.Header.Get("Accept"), locale, r.URL.RequestURI())
+}
+
+func getCachedResponse(st store.Store, key string) (cachedResponse, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	var cached cachedResponse
+	b, err := st.Get(ctx, key)
+	if err != nil {
+		return cached, false
+	}
+
+	if err := json.Unmarshal(b, &cached); err != nil {
+		return cached, false
+	}
+	return cached, true
+}
+
+func setCachedResponse(st store.Store, key string, cached cachedResponse, ttl time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	b, err := json.Marshal(cached)
+	if err != nil {
+		return
+	}
+	_ = st.Set(ctx, key, b, ttl)
+}
@@ -0,0 +1,140 @@
+package middlewares_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/middlewares"
+	"github.com/princecee/lema-ai/pkg/response"
+	"github.com/princecee/lema-ai/pkg/store"
+	"github.com/stretchr/testify/suite"
+)
+
+type CacheTestSuite struct {
+	suite.Suite
+	store   store.Store
+	calls   int
+	handler http.Handler
+}
+
+func (s *CacheTestSuite) SetupTest() {
+	s.store = store.NewMemoryStore()
+	s.calls = 0
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.calls++
+		if r.URL.Query().Get("fail") != "" {
+			response.SendErrorResponse(w, response.Response[any]{Message: "not found"}, http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("stream") != "" {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			for i := 0; i < 40000; i++ {
+				fmt.Fprintf(w, `{"id":%d,"name":"Streamed user"}`+"\n", i)
+			}
+			return
+		}
+		w.Header().Set("Link", `</users?page=2>; rel="next"`)
+		w.Header().Set("X-Total-Count", "42")
+		response.SendResponse(w, response.Response[any]{Message: fmt.Sprintf("call %d", s.calls)}, nil)
+	})
+	s.handler = middlewares.Cache(s.store, time.Minute)(h)
+}
+
+func (s *CacheTestSuite) TearDownTest() {
+	s.store.Close()
+}
+
+func (s *CacheTestSuite) get(path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *CacheTestSuite) TestCache() {
+	t := s.T()
+
+	t.Run("Serves repeated requests from the store", func(t *testing.T) {
+		first := s.get("/users?page=1", nil)
+		s.Equal("MISS", first.Header().Get("X-Cache"))
+
+		second := s.get("/users?page=1", nil)
+		s.Equal("HIT", second.Header().Get("X-Cache"))
+		s.Equal(http.StatusOK, second.Code)
+		s.Equal("application/json", second.Header().Get("Content-Type"))
+		s.Equal(`</users?page=2>; rel="next"`, second.Header().Get("Link"))
+		s.Equal("42", second.Header().Get("X-Total-Count"))
+		s.Equal(first.Body.String(), second.Body.String())
+		s.Equal(1, s.calls)
+	})
+
+	t.Run("Does not replay the headers of earlier middlewares", func(t *testing.T) {
+		h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
+			s.handler.ServeHTTP(w, r)
+		})
+		get := func(origin string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, "/users?page=3", nil)
+			req.Header.Set("Origin", origin)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			return rec
+		}
+
+		get("https://a.example.com")
+		rec := get("https://b.example.com")
+		s.Equal("HIT", rec.Header().Get("X-Cache"))
+		s.Equal("https://b.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+		s.Equal(2, s.calls)
+	})
+
+	t.Run("Keys on the query string", func(t *testing.T) {
+		rec := s.get("/users?page=2", nil)
+		s.Equal("MISS", rec.Header().Get("X-Cache"))
+		s.Equal(3, s.calls)
+	})
+
+	t.Run("Does not cache errors", func(t *testing.T) {
+		s.get("/users?fail=1", nil)
+		rec := s.get("/users?fail=1", nil)
+		s.Equal(http.StatusNotFound, rec.Code)
+		s.Equal("MISS", rec.Header().Get("X-Cache"))
+		s.Equal(5, s.calls)
+	})
+
+	t.Run("Does not cache large bodies", func(t *testing.T) {
+		first := s.get("/users?stream=1", nil)
+		second := s.get("/users?stream=1", nil)
+		s.Equal("MISS", second.Header().Get("X-Cache"))
+		s.Equal(first.Body.Len(), second.Body.Len())
+		s.Equal(7, s.calls)
+	})
+
+	t.Run("Honors Cache-Control no-cache", func(t *testing.T) {
+		rec := s.get("/users?page=1", map[string]string{"Cache-Control": "no-cache"})
+		s.Empty(rec.Header().Get("X-Cache"))
+		s.Equal(8, s.calls)
+	})
+
+	t.Run("Skips requests pinned to the primary", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/users?page=1", nil)
+		req = req.WithContext(database.ReadPrimary(req.Context()))
+		rec := httptest.NewRecorder()
+		s.handler.ServeHTTP(rec, req)
+		s.Empty(rec.Header().Get("X-Cache"))
+		s.Equal(9, s.calls)
+	})
+}
+
+func TestCache(t *testing.T) {
+	suite.Run(t, new(CacheTestSuite))
+}
@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/response"
+)
+
+// ContentType rejects requests with a body whose Content-Type is not one of
+// the allowed media types with 415 Unsupported Media Type.
+func ContentType(allowed ...string) func(http.Handler) http.Handler {
+	f := func(h http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength == 0 {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err == nil {
+				for _, t := range allowed {
+					if strings.EqualFold(mediaType, t) {
+						h.ServeHTTP(w, r)
+						return
+					}
+				}
+			}
+
+			w.Header().Set("Accept-Post", strings.Join(allowed, ", "))
+			response.SendError(w, r, apperror.ErrUnsupportedMedia.WithMessage("Content-Type must be {0}", strings.Join(allowed, " or ")))
+		}
+		return http.HandlerFunc(fn)
+	}
+	return f
+}
@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/go-chi/cors"
+	"github.com/princecee/lema-ai/config"
+)
+
+// CORS applies the configured cross-origin policy. Allowed origins may
+// contain a single "*" wildcard each, e.g. "https://*.example.com". In
+// development, leaving CORS_ALLOWED_ORIGINS unset allows every origin and
+// header, but still only exposes CORS_EXPOSED_HEADERS.
+func CORS(cfg *config.Config) func(http.Handler) http.Handler {
+	opts := cors.Options{
+		AllowedOrigins:   cfg.CORS_ALLOWED_ORIGINS,
+		AllowedMethods:   cfg.CORS_ALLOWED_METHODS,
+		AllowedHeaders:   cfg.CORS_ALLOWED_HEADERS,
+		ExposedHeaders:   cfg.CORS_EXPOSED_HEADERS,
+		AllowCredentials: cfg.CORS_ALLOW_CREDENTIALS,
+		MaxAge:           int(cfg.CORS_MAX_AGE.Seconds()),
+	}
+
+	switch {
+	case cfg.ENV == config.EnvDevelopment && len(opts.AllowedOrigins) == 0:
+		opts.AllowedOrigins = []string{"*"}
+		opts.AllowedHeaders = []string{"*"}
+		opts.AllowCredentials = false
+	case len(opts.AllowedOrigins) == 0:
+		// The cors package treats an empty origin list as "*", so reject
+		// every origin explicitly instead.
+		opts.AllowOriginFunc = func(r *http.Request, origin string) bool { return false }
+	}
+
+	return cors.Handler(opts)
+}
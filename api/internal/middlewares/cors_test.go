@@ -0,0 +1,97 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/princecee/lema-ai/config"
+	"github.com/princecee/lema-ai/internal/middlewares"
+	"github.com/stretchr/testify/suite"
+)
+
+type CORSTestSuite struct {
+	suite.Suite
+}
+
+func (s *CORSTestSuite) preflight(cfg *config.Config, origin string) *httptest.ResponseRecorder {
+	h := middlewares.CORS(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/v1/posts", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *CORSTestSuite) TestCORS() {
+	t := s.T()
+
+	t.Run("Development without origins allows all", func(t *testing.T) {
+		cfg := config.NewConfig(config.EnvDevelopment, "silent")
+		cfg.ENV = config.EnvDevelopment
+		cfg.CORS_ALLOWED_ORIGINS = nil
+
+		rec := s.preflight(cfg, "https://anything.example")
+		s.Equal("*", rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Production without origins allows none", func(t *testing.T) {
+		cfg := config.NewConfig(config.EnvProduction, "silent")
+		cfg.ENV = config.EnvProduction
+		cfg.CORS_ALLOWED_ORIGINS = nil
+
+		rec := s.preflight(cfg, "https://anything.example")
+		s.Empty(rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Wildcard origins", func(t *testing.T) {
+		cfg := config.NewConfig(config.EnvProduction, "silent")
+		cfg.ENV = config.EnvProduction
+		cfg.CORS_ALLOWED_ORIGINS = []string{"https://*.lema.dev"}
+		cfg.CORS_ALLOW_CREDENTIALS = true
+		cfg.CORS_MAX_AGE = time.Hour
+
+		rec := s.preflight(cfg, "https://staging.lema.dev")
+		s.Equal("https://staging.lema.dev", rec.Header().Get("Access-Control-Allow-Origin"))
+		s.Equal("true", rec.Header().Get("Access-Control-Allow-Credentials"))
+		s.Equal("3600", rec.Header().Get("Access-Control-Max-Age"))
+
+		rec = s.preflight(cfg, "https://lema.evil.dev")
+		s.Empty(rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Disallowed methods", func(t *testing.T) {
+		cfg := config.NewConfig(config.EnvProduction, "silent")
+		cfg.ENV = config.EnvProduction
+		cfg.CORS_ALLOWED_ORIGINS = []string{"https://lema.dev"}
+		cfg.CORS_ALLOWED_METHODS = []string{http.MethodGet}
+
+		rec := s.preflight(cfg, "https://lema.dev")
+		s.Empty(rec.Header().Get("Access-Control-Allow-Methods"))
+	})
+
+	t.Run("Exposes pagination and cache headers", func(t *testing.T) {
+		cfg := config.NewConfig(config.EnvDevelopment, "silent")
+		cfg.ENV = config.EnvDevelopment
+		cfg.CORS_ALLOWED_ORIGINS = nil
+		h := middlewares.CORS(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		req.Header.Set("Origin", "https://lema.dev")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		exposed := rec.Header().Get("Access-Control-Expose-Headers")
+		for _, header := range []string{"Link", "X-Total-Count", "X-Cache"} {
+			s.Contains(exposed, header)
+		}
+	})
+}
+
+func TestCORS(t *testing.T) {
+	suite.Run(t, new(CORSTestSuite))
+}
@@ -0,0 +1,210 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/httprate"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/response"
+	"github.com/princecee/lema-ai/pkg/store"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+const (
+	maxIdempotencyKeyLength   = 255
+	idempotencyStateRunning   = "running"
+	idempotencyStateCompleted = "completed"
+	// idempotencyLockTTL bounds how long a key stays locked if the replica
+	// handling the first request dies before storing its response.
+	idempotencyLockTTL = time.Minute
+)
+
+type idempotencyRecord struct {
+	State       string `json:"state"`
+	RequestHash string `json:"request_hash"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// Idempotency makes POST requests carrying an Idempotency-Key header safe
+// to retry. The first request with a key runs normally and its response is
+// stored for ttl; retries with the same key and body get that response
+// replayed, while reusing the key with a different body is rejected.
+// Server errors and rate limited requests are not stored so the client may
+// retry them.
+func Idempotency(st store.Store, ttl time.Duration) func(http.Handler) http.Handler {
+	f := func(h http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if r.Method != http.MethodPost || key == "" {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				maxLength := strconv.Itoa(maxIdempotencyKeyLength)
+				response.SendError(w, r, apperror.InvalidParameter(IdempotencyKeyHeader,
+					"{0} must be at most {1} characters", IdempotencyKeyHeader, maxLength))
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					limit := strconv.FormatInt(maxBytesErr.Limit, 10)
+					response.SendError(w, r, apperror.ErrPayloadTooLarge.WithMessage("Request body must not be larger than {0} bytes", limit))
+					return
+				}
+				response.SendError(w, r, apperror.ErrBadRequest.WithMessage("Invalid request body"))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			storeKey := idempotencyStoreKey(r, key)
+			record := idempotencyRecord{
+				State:       idempotencyStateRunning,
+				RequestHash: requestHash(r, body),
+			}
+
+			acquired, err := setIdempotencyRecord(st, storeKey, record, min(ttl, idempotencyLockTTL), true)
+			if err != nil {
+				response.SendError(w, r, err)
+				return
+			}
+
+			if !acquired {
+				replayIdempotentResponse(w, r, st, storeKey, record.RequestHash)
+				return
+			}
+
+			completed := false
+			defer func() {
+				// Release the key on server errors and panics.
+				if !completed {
+					deleteIdempotencyRecord(st, storeKey)
+				}
+			}()
+
+			buf := new(bytes.Buffer)
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(buf)
+			h.ServeHTTP(ww, r)
+
+			if ww.Status() >= http.StatusInternalServerError || ww.Status() == http.StatusTooManyRequests {
+				return
+			}
+
+			record.State = idempotencyStateCompleted
+			record.Status = ww.Status()
+			record.ContentType = ww.Header().Get("Content-Type")
+			record.Body = buf.Bytes()
+			_, err = setIdempotencyRecord(st, storeKey, record, ttl, false)
+			completed = err == nil
+		}
+		return http.HandlerFunc(fn)
+	}
+	return f
+}
+
+func replayIdempotentResponse(w http.ResponseWriter, r *http.Request, st store.Store, storeKey, hash string) {
+	record, err := getIdempotencyRecord(st, storeKey)
+	if errors.Is(err, store.ErrNotFound) {
+		// The lock expired or was released since it was checked, so the
+		// first request has not completed.
+		response.SendError(w, r, apperror.ErrRequestInProgress)
+		return
+	}
+	if err != nil {
+		response.SendError(w, r, err)
+		return
+	}
+
+	if record.RequestHash != hash {
+		response.SendError(w, r, apperror.ErrIdempotencyKeyReused)
+		return
+	}
+
+	if record.State != idempotencyStateCompleted {
+		response.SendError(w, r, apperror.ErrRequestInProgress)
+		return
+	}
+
+	w.Header().Set("Content-Type", record.ContentType)
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(record.Status)
+	_, _ = w.Write(record.Body)
+}
+
+// idempotencyStoreKey scopes keys to the caller, or to its IP for
+// anonymous callers, so that clients cannot replay each other's responses.
+func idempotencyStoreKey(r *http.Request, key string) string {
+	if id, ok := IdentityFromContext(r.Context()); ok {
+		return fmt.Sprintf("idempotency:%s:%s:%s", id.Kind, id.ID, key)
+	}
+
+	ip, err := httprate.KeyByIP(r)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return fmt.Sprintf("idempotency:ip:%s:%s", ip, key)
+}
+
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func getIdempotencyRecord(st store.Store, key string) (idempotencyRecord, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	var record idempotencyRecord
+	b, err := st.Get(ctx, key)
+	if err != nil {
+		return record, err
+	}
+
+	err = json.Unmarshal(b, &record)
+	return record, err
+}
+
+func setIdempotencyRecord(st store.Store, key string, record idempotencyRecord, ttl time.Duration, onlyIfAbsent bool) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	b, err := json.Marshal(record)
+	if err != nil {
+		return false, err
+	}
+
+	if onlyIfAbsent {
+		return st.SetNX(ctx, key, b, ttl)
+	}
+	return true, st.Set(ctx, key, b, ttl)
+}
+
+func deleteIdempotencyRecord(st store.Store, key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	_ = st.Delete(ctx, key)
+}
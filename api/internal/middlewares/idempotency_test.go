@@ -0,0 +1,169 @@
+package middlewares_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/princecee/lema-ai/internal/middlewares"
+	"github.com/princecee/lema-ai/pkg/json"
+	"github.com/princecee/lema-ai/pkg/response"
+	"github.com/princecee/lema-ai/pkg/store"
+	"github.com/stretchr/testify/suite"
+)
+
+type IdempotencyTestSuite struct {
+	suite.Suite
+	store   store.Store
+	calls   int
+	status  int
+	handler http.Handler
+}
+
+func (s *IdempotencyTestSuite) SetupTest() {
+	s.store = store.NewMemoryStore()
+	s.calls = 0
+	s.status = http.StatusOK
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.calls++
+		body, _ := io.ReadAll(r.Body)
+		resp := response.Response[any]{
+			Message:    fmt.Sprintf("created %d", s.calls),
+			Data:       string(body),
+			StatusCode: &s.status,
+		}
+		response.SendResponse(w, resp, nil)
+	})
+	s.handler = middlewares.Idempotency(s.store, time.Hour)(h)
+}
+
+func (s *IdempotencyTestSuite) TearDownTest() {
+	s.store.Close()
+}
+
+func (s *IdempotencyTestSuite) post(key, body string) *httptest.ResponseRecorder {
+	return s.postFrom("192.0.2.1:1234", "/api/v1/posts", key, body)
+}
+
+func (s *IdempotencyTestSuite) postFrom(remoteAddr, target, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.RemoteAddr = remoteAddr
+	if key != "" {
+		req.Header.Set(middlewares.IdempotencyKeyHeader, key)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *IdempotencyTestSuite) TestReplaysStoredResponse() {
+	first := s.post("key-1", `{"title":"a"}`)
+	s.Equal(http.StatusOK, first.Code)
+	s.Empty(first.Header().Get(middlewares.IdempotentReplayedHeader))
+
+	second := s.post("key-1", `{"title":"a"}`)
+	s.Equal(http.StatusOK, second.Code)
+	s.Equal("true", second.Header().Get(middlewares.IdempotentReplayedHeader))
+	s.Equal(first.Body.String(), second.Body.String())
+	s.Equal(1, s.calls)
+}
+
+func (s *IdempotencyTestSuite) TestRejectsKeyReuseWithDifferentBody() {
+	s.post("key-1", `{"title":"a"}`)
+
+	rec := s.post("key-1", `{"title":"b"}`)
+	s.Equal(http.StatusUnprocessableEntity, rec.Code)
+
+	response := response.Response[any]{}
+	_ = json.ReadJSON(rec.Result().Body, &response)
+	s.Equal(false, *response.Success)
+	s.Equal("Idempotency-Key was already used with a different request", response.Message)
+	s.Equal(1, s.calls)
+}
+
+func (s *IdempotencyTestSuite) TestRequestsWithoutKeyAreNotDeduplicated() {
+	s.post("", `{"title":"a"}`)
+	s.post("", `{"title":"a"}`)
+	s.Equal(2, s.calls)
+}
+
+func (s *IdempotencyTestSuite) TestServerErrorsAreNotStored() {
+	s.status = http.StatusInternalServerError
+	s.Equal(http.StatusInternalServerError, s.post("key-1", `{}`).Code)
+
+	s.status = http.StatusOK
+	rec := s.post("key-1", `{}`)
+	s.Equal(http.StatusOK, rec.Code)
+	s.Empty(rec.Header().Get(middlewares.IdempotentReplayedHeader))
+	s.Equal(2, s.calls)
+}
+
+func (s *IdempotencyTestSuite) TestRateLimitedRequestsAreNotStored() {
+	s.status = http.StatusTooManyRequests
+	s.Equal(http.StatusTooManyRequests, s.post("key-1", `{}`).Code)
+
+	s.status = http.StatusOK
+	rec := s.post("key-1", `{}`)
+	s.Equal(http.StatusOK, rec.Code)
+	s.Empty(rec.Header().Get(middlewares.IdempotentReplayedHeader))
+	s.Equal(2, s.calls)
+}
+
+func (s *IdempotencyTestSuite) TestClientErrorsAreReplayed() {
+	s.status = http.StatusBadRequest
+	s.post("key-1", `{}`)
+
+	s.status = http.StatusOK
+	rec := s.post("key-1", `{}`)
+	s.Equal(http.StatusBadRequest, rec.Code)
+	s.Equal("true", rec.Header().Get(middlewares.IdempotentReplayedHeader))
+	s.Equal(1, s.calls)
+}
+
+func (s *IdempotencyTestSuite) TestRejectsLongKeys() {
+	rec := s.post(strings.Repeat("k", 256), `{}`)
+	s.Equal(http.StatusBadRequest, rec.Code)
+	s.Equal(0, s.calls)
+}
+
+func (s *IdempotencyTestSuite) TestKeysAreScopedByIP() {
+	s.postFrom("192.0.2.1:1234", "/api/v1/posts", "key-1", `{}`)
+	rec := s.postFrom("192.0.2.2:1234", "/api/v1/posts", "key-1", `{}`)
+	s.Equal(http.StatusOK, rec.Code)
+	s.Empty(rec.Header().Get(middlewares.IdempotentReplayedHeader))
+	s.Equal(2, s.calls)
+}
+
+func (s *IdempotencyTestSuite) TestRejectsKeyReuseWithDifferentQuery() {
+	s.postFrom("192.0.2.1:1234", "/api/v1/import?dry_run=true", "key-1", `{}`)
+	rec := s.postFrom("192.0.2.1:1234", "/api/v1/import?dry_run=false", "key-1", `{}`)
+	s.Equal(http.StatusUnprocessableEntity, rec.Code)
+	s.Equal(1, s.calls)
+}
+
+// lostLockStore loses every lock as soon as it is taken.
+type lostLockStore struct {
+	store.Store
+}
+
+func (st lostLockStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return false, nil
+}
+
+func (s *IdempotencyTestSuite) TestLostLockIsInProgress() {
+	s.handler = middlewares.Idempotency(lostLockStore{s.store}, time.Hour)(s.handler)
+	rec := s.post("key-1", `{}`)
+	s.Equal(http.StatusConflict, rec.Code)
+	s.Equal(0, s.calls)
+}
+
+func TestIdempotency(t *testing.T) {
+	suite.Run(t, new(IdempotencyTestSuite))
+}
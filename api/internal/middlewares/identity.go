@@ -0,0 +1,25 @@
+package middlewares
+
+import "context"
+
+const IdentityAPIKey = "api_key"
+
+// Identity is the authenticated caller of a request. Callers are only
+// identified by API key; the users of the API are data, not accounts to
+// sign in with. Role is the role of the key, see models.Roles.
+type Identity struct {
+	Kind string
+	ID   string
+	Role string
+}
+
+type identityCtxKey struct{}
+
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityCtxKey{}, id)
+}
+
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityCtxKey{}).(Identity)
+	return id, ok && id.ID != ""
+}
@@ -0,0 +1,20 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/princecee/lema-ai/pkg/i18n"
+)
+
+// Locale resolves the response locale from the Accept-Language header and
+// stores it in the request context for error and validation messages.
+func Locale(h http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		locale := i18n.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+
+		w.Header().Set("Content-Language", locale)
+		w.Header().Add("Vary", "Accept-Language")
+		h.ServeHTTP(w, r.WithContext(i18n.WithLocale(r.Context(), locale)))
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,126 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/httprate"
+	"github.com/princecee/lema-ai/config"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/response"
+	"github.com/princecee/lema-ai/pkg/store"
+)
+
+// storeTimeout bounds every round-trip to the shared store.
+const storeTimeout = time.Second
+
+// RateLimit limits requests within a route group. Each group has its own
+// budget, keyed by the authenticated identity when one is available and by
+// client IP otherwise.
+func RateLimit(st store.Store, group string, limit config.RateLimit) func(http.Handler) http.Handler {
+	if limit.Requests <= 0 || limit.Window <= 0 {
+		return func(h http.Handler) http.Handler { return h }
+	}
+
+	limiter := httprate.NewRateLimiter(
+		limit.Requests,
+		limit.Window,
+		httprate.WithKeyFuncs(rateLimitKey(group)),
+		httprate.WithLimitCounter(&storeLimitCounter{store: st}),
+		httprate.WithResponseHeaders(httprate.ResponseHeaders{
+			Limit:      "RateLimit-Limit",
+			Remaining:  "RateLimit-Remaining",
+			RetryAfter: "Retry-After",
+		}),
+		httprate.WithLimitHandler(onRateLimited),
+		httprate.WithErrorHandler(onRateLimitError),
+	)
+	policy := fmt.Sprintf("%d;w=%d", limit.Requests, int(limit.Window.Seconds()))
+
+	f := func(h http.Handler) http.Handler {
+		next := limiter.Handler(h)
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			// httprate reports the reset as a unix timestamp; the RateLimit
+			// header fields expect the seconds left in the current window.
+			now := time.Now().UTC()
+			reset := now.Truncate(limit.Window).Add(limit.Window).Sub(now)
+			w.Header().Set("RateLimit-Reset", fmt.Sprintf("%d", int(math.Ceil(reset.Seconds()))))
+			w.Header().Set("RateLimit-Policy", policy)
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+	return f
+}
+
+func rateLimitKey(group string) httprate.KeyFunc {
+	return func(r *http.Request) (string, error) {
+		if id, ok := IdentityFromContext(r.Context()); ok {
+			return fmt.Sprintf("%s:%s:%s", group, id.Kind, id.ID), nil
+		}
+
+		ip, err := httprate.KeyByIP(r)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s:ip:%s", group, ip), nil
+	}
+}
+
+// storeLimitCounter keeps httprate's sliding window counters in a Store
+// so every replica shares the same budget.
+type storeLimitCounter struct {
+	store  store.Store
+	window time.Duration
+}
+
+func (c *storeLimitCounter) Config(requestLimit int, windowLength time.Duration) {
+	c.window = windowLength
+}
+
+func (c *storeLimitCounter) Increment(key string, currentWindow time.Time) error {
+	return c.IncrementBy(key, currentWindow, 1)
+}
+
+func (c *storeLimitCounter) IncrementBy(key string, currentWindow time.Time, amount int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	// Counters are read for the current and previous window, so they are
+	// kept for two windows plus some slack.
+	_, err := c.store.IncrBy(ctx, c.counterKey(key, currentWindow), int64(amount), 3*c.window)
+	return err
+}
+
+func (c *storeLimitCounter) Get(key string, currentWindow, previousWindow time.Time) (int, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	curr, err := c.store.GetInt(ctx, c.counterKey(key, currentWindow))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	prev, err := c.store.GetInt(ctx, c.counterKey(key, previousWindow))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return int(curr), int(prev), nil
+}
+
+func (c *storeLimitCounter) counterKey(key string, window time.Time) string {
+	return fmt.Sprintf("ratelimit:%s:%d", key, window.Unix())
+}
+
+func onRateLimited(w http.ResponseWriter, r *http.Request) {
+	response.SendError(w, r, apperror.ErrTooManyRequests)
+}
+
+func onRateLimitError(w http.ResponseWriter, r *http.Request, err error) {
+	response.SendError(w, r, apperror.ErrInternalServer)
+}
@@ -0,0 +1,174 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-chi/chi"
+	"github.com/princecee/lema-ai/config"
+	"github.com/princecee/lema-ai/internal/middlewares"
+	"github.com/princecee/lema-ai/pkg/json"
+	"github.com/princecee/lema-ai/pkg/response"
+	"github.com/princecee/lema-ai/pkg/store"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+)
+
+type RateLimitTestSuite struct {
+	suite.Suite
+	router chi.Router
+}
+
+func (s *RateLimitTestSuite) SetupTest() {
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		response.SendResponse(w, response.Response[any]{Message: "ok"}, nil)
+	}
+
+	st := store.NewMemoryStore()
+	r := chi.NewRouter()
+	r.Use(func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key := r.Header.Get("X-Test-Identity"); key != "" {
+				r = r.WithContext(middlewares.WithIdentity(r.Context(), middlewares.Identity{
+					Kind: middlewares.IdentityAPIKey,
+					ID:   key,
+				}))
+			}
+			h.ServeHTTP(w, r)
+		})
+	})
+	r.With(middlewares.RateLimit(st, "read", config.RateLimit{Requests: 3, Window: time.Minute})).Get("/read", ok)
+	r.With(middlewares.RateLimit(st, "write", config.RateLimit{Requests: 1, Window: time.Minute})).Post("/write", ok)
+	r.With(middlewares.RateLimit(st, "off", config.RateLimit{})).Get("/off", ok)
+	s.router = r
+}
+
+func (s *RateLimitTestSuite) do(method, path, identity string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	if identity != "" {
+		req.Header.Set("X-Test-Identity", identity)
+	}
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *RateLimitTestSuite) TestRateLimit() {
+	t := s.T()
+
+	t.Run("Sets RateLimit headers", func(t *testing.T) {
+		rec := s.do(http.MethodGet, "/read", "")
+		s.Equal(http.StatusOK, rec.Code)
+		s.Equal("3", rec.Header().Get("RateLimit-Limit"))
+		s.Equal("2", rec.Header().Get("RateLimit-Remaining"))
+		s.NotEmpty(rec.Header().Get("RateLimit-Reset"))
+		s.Equal("3;w=60", rec.Header().Get("RateLimit-Policy"))
+	})
+
+	t.Run("Rejects over the limit with a JSON body", func(t *testing.T) {
+		s.Equal(http.StatusOK, s.do(http.MethodGet, "/read", "").Code)
+		s.Equal(http.StatusOK, s.do(http.MethodGet, "/read", "").Code)
+
+		rec := s.do(http.MethodGet, "/read", "")
+		s.Equal(http.StatusTooManyRequests, rec.Code)
+		s.Equal("0", rec.Header().Get("RateLimit-Remaining"))
+		s.NotEmpty(rec.Header().Get("Retry-After"))
+
+		response := response.Response[any]{}
+		_ = json.ReadJSON(rec.Result().Body, &response)
+		s.Equal(false, *response.Success)
+		s.Equal("too many requests", response.Message)
+	})
+
+	t.Run("Groups have separate budgets", func(t *testing.T) {
+		s.Equal(http.StatusOK, s.do(http.MethodPost, "/write", "").Code)
+		s.Equal(http.StatusTooManyRequests, s.do(http.MethodPost, "/write", "").Code)
+	})
+
+	t.Run("Identities behind the same IP have separate budgets", func(t *testing.T) {
+		s.Equal(http.StatusOK, s.do(http.MethodPost, "/write", "alice").Code)
+		s.Equal(http.StatusOK, s.do(http.MethodPost, "/write", "bob").Code)
+		s.Equal(http.StatusTooManyRequests, s.do(http.MethodPost, "/write", "alice").Code)
+	})
+
+	t.Run("Zero limit disables rate limiting", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			rec := s.do(http.MethodGet, "/off", "")
+			s.Equal(http.StatusOK, rec.Code)
+			s.Empty(rec.Header().Get("RateLimit-Limit"))
+		}
+	})
+}
+
+func TestRateLimitSharedAcrossReplicas(t *testing.T) {
+	mr := miniredis.RunT(t)
+	st := store.NewRedisStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	defer st.Close()
+
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	limit := config.RateLimit{Requests: 2, Window: time.Minute}
+
+	// Two routers stand in for two API replicas sharing one Redis server.
+	replicas := []http.Handler{
+		middlewares.RateLimit(st, "write", limit)(http.HandlerFunc(ok)),
+		middlewares.RateLimit(st, "write", limit)(http.HandlerFunc(ok)),
+	}
+
+	codes := []int{}
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		rec := httptest.NewRecorder()
+		replicas[i%2].ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+	}
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusTooManyRequests {
+		t.Fatalf("expected the third request to be limited across replicas, got %v", codes)
+	}
+}
+
+func TestRateLimitPerAPIKey(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	h := middlewares.IdentifyAPIKey(middlewares.NewAPIKeys([]string{"key-a", "key-b"}, nil))(
+		middlewares.RateLimit(store.NewMemoryStore(), "write", config.RateLimit{Requests: 1, Window: time.Minute})(http.HandlerFunc(ok)))
+
+	do := func(key string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		if key != "" {
+			req.Header.Set(middlewares.APIKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Two keys behind one IP get a budget each.
+	if code := do("key-a"); code != http.StatusOK {
+		t.Fatalf("first request with key-a: got %d", code)
+	}
+	if code := do("key-b"); code != http.StatusOK {
+		t.Fatalf("first request with key-b: got %d", code)
+	}
+	if code := do("key-a"); code != http.StatusTooManyRequests {
+		t.Fatalf("second request with key-a: got %d", code)
+	}
+
+	// Unknown keys share the budget of the IP.
+	if code := do("unknown"); code != http.StatusOK {
+		t.Fatalf("first request with an unknown key: got %d", code)
+	}
+	if code := do(""); code != http.StatusTooManyRequests {
+		t.Fatalf("second request without a key: got %d", code)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	suite.Run(t, new(RateLimitTestSuite))
+}
@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/httprate"
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/pkg/store"
+)
+
+// ReadPrimaryKey is the key of st marking a caller that wrote recently.
+func ReadPrimaryKey(id Identity) string {
+	return "read_primary:" + id.Kind + ":" + id.ID
+}
+
+// ReadYourWrites sends the reads of clients that wrote in the last window
+// to the primary, since the replicas may not have their writes yet. Any
+// request other than GET, HEAD and OPTIONS counts as a write, unless
+// isRead, if set, reports that it only reads: its own reads go to the
+// primary and the client is marked in st for window. Clients are told
+// apart by their identity, or by IP when anonymous, like the rate limits,
+// so clients that keep no cookies are marked too. It must come after
+// IdentifyAPIKey.
+func ReadYourWrites(st store.Store, window time.Duration, isRead func(*http.Request) bool) func(http.Handler) http.Handler {
+	f := func(h http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			key := readPrimaryKey(r)
+			switch {
+			case r.Method == http.MethodGet, r.Method == http.MethodHead, r.Method == http.MethodOptions,
+				isRead != nil && isRead(r):
+				if _, err := st.Get(r.Context(), key); err == nil {
+					r = r.WithContext(database.ReadPrimary(r.Context()))
+				}
+			default:
+				if window > 0 {
+					// The mark is best-effort, like the response cache.
+					_ = st.Set(r.Context(), key, []byte("1"), window)
+				}
+				r = r.WithContext(database.ReadPrimary(r.Context()))
+			}
+			h.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+	return f
+}
+
+func readPrimaryKey(r *http.Request) string {
+	if id, ok := IdentityFromContext(r.Context()); ok {
+		return ReadPrimaryKey(id)
+	}
+
+	ip, err := httprate.KeyByIP(r)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return ReadPrimaryKey(Identity{Kind: "ip", ID: ip})
+}
@@ -0,0 +1,59 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/middlewares"
+	"github.com/princecee/lema-ai/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadYourWrites(t *testing.T) {
+	var readsPrimary bool
+	isRead := func(r *http.Request) bool { return r.URL.Path == "/api/v1/graphql" }
+	h := middlewares.ReadYourWrites(store.NewMemoryStore(), 5*time.Second, isRead)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		readsPrimary = database.ReadsPrimary(r.Context())
+	}))
+	serve := func(method, path, remoteAddr string, id *middlewares.Identity) {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = remoteAddr
+		if id != nil {
+			req = req.WithContext(middlewares.WithIdentity(req.Context(), *id))
+		}
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	serve(http.MethodGet, "/api/v1/posts", "192.0.2.1:1234", nil)
+	assert.False(t, readsPrimary)
+
+	serve(http.MethodPost, "/api/v1/graphql", "192.0.2.1:1234", nil)
+	assert.False(t, readsPrimary, "reads sent as POSTs are not writes")
+	serve(http.MethodGet, "/api/v1/posts", "192.0.2.1:1234", nil)
+	assert.False(t, readsPrimary)
+
+	serve(http.MethodPost, "/api/v1/posts", "192.0.2.1:1234", nil)
+	assert.True(t, readsPrimary)
+
+	serve(http.MethodGet, "/api/v1/posts", "192.0.2.1:5678", nil)
+	assert.True(t, readsPrimary, "the writer is told apart by IP without cookies")
+	serve(http.MethodPost, "/api/v1/graphql", "192.0.2.1:5678", nil)
+	assert.True(t, readsPrimary)
+	serve(http.MethodGet, "/api/v1/posts", "192.0.2.2:1234", nil)
+	assert.False(t, readsPrimary)
+
+	alice := &middlewares.Identity{Kind: middlewares.IdentityAPIKey, ID: "alice"}
+	bob := &middlewares.Identity{Kind: middlewares.IdentityAPIKey, ID: "bob"}
+	serve(http.MethodDelete, "/api/v1/posts/1", "192.0.2.3:1234", alice)
+	assert.True(t, readsPrimary)
+
+	serve(http.MethodGet, "/api/v1/posts", "192.0.2.4:1234", alice)
+	assert.True(t, readsPrimary, "identified callers are marked by identity")
+	serve(http.MethodGet, "/api/v1/posts", "192.0.2.3:1234", bob)
+	assert.False(t, readsPrimary)
+	serve(http.MethodGet, "/api/v1/posts", "192.0.2.3:1234", nil)
+	assert.False(t, readsPrimary)
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/go-chi/chi"
+	"github.com/princecee/lema-ai/config"
+	"github.com/princecee/lema-ai/internal/handlers"
+	"github.com/princecee/lema-ai/internal/middlewares"
+	"github.com/princecee/lema-ai/pkg/store"
+	"github.com/rs/zerolog"
+)
+
+func AddExportRoutes(exportService handlers.ExportService, st store.Store, cfg *config.Config, l zerolog.Logger) chi.Router {
+	r := chi.NewRouter()
+	h := handlers.NewExportHandler(exportService, cfg, l)
+
+	r.Get("/{export_id}", h.GetExport)
+	r.Get("/{export_id}/download", h.DownloadExport)
+
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.RateLimit(st, "exports", cfg.RATE_LIMIT_EXPORTS))
+		r.Use(middlewares.ContentType("application/json"))
+		r.Post("/", h.CreateExport)
+	})
+
+	return r
+}
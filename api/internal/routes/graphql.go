@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/go-chi/chi"
+	"github.com/princecee/lema-ai/config"
+	"github.com/princecee/lema-ai/internal/handlers"
+	"github.com/princecee/lema-ai/internal/middlewares"
+	"github.com/princecee/lema-ai/pkg/store"
+	"github.com/rs/zerolog"
+)
+
+func AddGraphQLRoutes(userService handlers.UserService, postService handlers.GraphQLPostService, st store.Store, cfg *config.Config, l zerolog.Logger) chi.Router {
+	r := chi.NewRouter()
+	h := handlers.NewGraphQLHandler(userService, postService, cfg, l)
+
+	r.Use(middlewares.ReadYourWrites(st, cfg.READ_YOUR_WRITES_WINDOW, handlers.IsGraphQLQuery))
+	r.Use(middlewares.RateLimit(st, "graphql", cfg.RATE_LIMIT_GRAPHQL))
+	r.Use(middlewares.ContentType("application/json"))
+	r.Post("/", h.Query)
+
+	return r
+}
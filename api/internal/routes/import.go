@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/go-chi/chi"
+	"github.com/princecee/lema-ai/config"
+	"github.com/princecee/lema-ai/internal/handlers"
+	"github.com/princecee/lema-ai/internal/middlewares"
+	"github.com/princecee/lema-ai/pkg/response"
+	"github.com/princecee/lema-ai/pkg/store"
+	"github.com/rs/zerolog"
+)
+
+func AddImportRoutes(importService handlers.ImportService, st store.Store, cfg *config.Config, l zerolog.Logger) chi.Router {
+	r := chi.NewRouter()
+	h := handlers.NewImportHandler(importService, cfg, l)
+
+	r.Use(middlewares.RateLimit(st, "import", cfg.RATE_LIMIT_IMPORT))
+	r.Use(middlewares.ContentType(response.CSVContentType, response.NDJSONContentType))
+	r.Post("/users", h.ImportUsers)
+	r.Post("/posts", h.ImportPosts)
+
+	return r
+}
@@ -4,18 +4,31 @@ import (
 	"github.com/go-chi/chi"
 	"github.com/princecee/lema-ai/config"
 	"github.com/princecee/lema-ai/internal/handlers"
+	"github.com/princecee/lema-ai/internal/middlewares"
+	"github.com/princecee/lema-ai/pkg/store"
 	"github.com/rs/zerolog"
 	"gorm.io/gorm"
 )
 
-func AddPostRoutes(db *gorm.DB, postService handlers.PostService, cfg *config.Config, l zerolog.Logger) chi.Router {
+func AddPostRoutes(db *gorm.DB, postService handlers.PostService, stream handlers.EventStream, st store.Store, cfg *config.Config, l zerolog.Logger) chi.Router {
 	r := chi.NewRouter()
 	h := handlers.NewPostHandler(postService, cfg, l)
+	sh := handlers.NewStreamHandler(stream, cfg, l)
 
-	r.Post("/", h.CreatePost)
-	r.Get("/", h.GetPosts)
-	r.Get("/{post_id}", h.GetPost)
-	r.Delete("/{post_id}", h.DeletePost)
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.RateLimit(st, "posts:read", cfg.RATE_LIMIT_POSTS_READ))
+		r.Get("/", h.GetPosts)
+		r.Get("/stream", sh.StreamPosts)
+		r.Get("/{post_id}", h.GetPost)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.RateLimit(st, "posts:write", cfg.RATE_LIMIT_POSTS_WRITE))
+		r.Use(middlewares.ContentType("application/json"))
+		r.Post("/", h.CreatePost)
+		r.Patch("/{post_id}", h.UpdatePost)
+		r.Delete("/{post_id}", h.DeletePost)
+	})
 
 	return r
 }
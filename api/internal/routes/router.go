@@ -0,0 +1,89 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/princecee/lema-ai/config"
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/repositories"
+	"github.com/princecee/lema-ai/internal/handlers"
+	"github.com/princecee/lema-ai/internal/middlewares"
+	"github.com/princecee/lema-ai/internal/services"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/response"
+	"github.com/princecee/lema-ai/pkg/store"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+)
+
+func NewRouter(db *gorm.DB, st store.Store, health *handlers.HealthHandler, exportService handlers.ExportService, webhookService handlers.WebhookService, stream handlers.EventStream, hub handlers.Hub, cfg *config.Config, l zerolog.Logger) chi.Router {
+	userRepo := repositories.NewUserRepository(db)
+	postRepo := repositories.NewPostRepository(db)
+	apiKeys := middlewares.NewAPIKeys(cfg.API_KEYS, repositories.NewAPIKeyRepository(db))
+
+	userService := services.NewUserService(userRepo)
+	postService := services.NewPostService(postRepo)
+	importService := services.NewImportService(database.NewTransactor(db), userRepo, postRepo)
+
+	docs := handlers.NewDocsHandler(cfg, l)
+	userRouter := AddUserRoutes(db, userService, st, cfg, l)
+	postRouter := AddPostRoutes(db, postService, stream, st, cfg, l)
+	importRouter := AddImportRoutes(importService, st, cfg, l)
+	exportRouter := AddExportRoutes(exportService, st, cfg, l)
+	webhookRouter := AddWebhookRoutes(webhookService, apiKeys, st, cfg, l)
+	wsRouter := AddWebSocketRoutes(hub, apiKeys, cfg, l)
+	graphQLRouter := AddGraphQLRoutes(userService, postService, st, cfg, l)
+	r := chi.NewRouter()
+
+	r.Use(middleware.CleanPath)
+	r.Use(middleware.Heartbeat("/ping"))
+	r.Use(middleware.Recoverer)
+	r.Use(middlewares.Locale)
+	r.Use(middlewares.IdentifyAPIKey(apiKeys))
+	r.Use(middlewares.CORS(cfg))
+
+	// Body limits nest, so imports get their own group instead of raising
+	// the 1mb limit of the rest of the API.
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.RequestSize(1 << 20)) // 1mb body limit
+		r.Use(middlewares.Idempotency(st, cfg.IDEMPOTENCY_TTL))
+		// GraphQL queries are POSTs too, so GraphQL tells ReadYourWrites
+		// which requests write itself.
+		r.Mount("/api/v1/graphql", graphQLRouter)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.ReadYourWrites(st, cfg.READ_YOUR_WRITES_WINDOW, nil))
+			r.Get("/healthz", health.Liveness)
+			r.Get("/readyz", health.Readiness)
+			r.Get("/api/v1/openapi.json", docs.OpenAPI)
+			r.Get("/api/v1/docs", docs.SwaggerUI)
+			r.Mount("/api/v1/users", userRouter)
+			r.Mount("/api/v1/posts", postRouter)
+			r.Mount("/api/v1/exports", exportRouter)
+			r.Mount("/api/v1/webhooks", webhookRouter)
+			r.Mount("/api/v1/ws", wsRouter)
+		})
+	})
+
+	// Imports are not made idempotent: the middleware buffers the body to
+	// hash it and stores the response for replay, which does not suit
+	// uploads of up to IMPORT_MAX_BYTES that may run for longer than its
+	// lock.
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.RequestSize(int64(cfg.IMPORT_MAX_BYTES)))
+		r.Use(middlewares.ReadYourWrites(st, cfg.READ_YOUR_WRITES_WINDOW, nil))
+		r.Mount("/api/v1/import", importRouter)
+	})
+
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		response.SendError(w, r, apperror.ErrNotFound.WithMessage("{0} {1} not found", r.Method, r.URL.Path))
+	})
+
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		response.SendError(w, r, apperror.ErrMethodNotAllowed.WithMessage("{0} {1} not allowed", r.Method, r.URL.Path))
+	})
+
+	return r
+}
@@ -4,17 +4,30 @@ import (
 	"github.com/go-chi/chi"
 	"github.com/princecee/lema-ai/config"
 	"github.com/princecee/lema-ai/internal/handlers"
+	"github.com/princecee/lema-ai/internal/middlewares"
+	"github.com/princecee/lema-ai/pkg/store"
 	"github.com/rs/zerolog"
 	"gorm.io/gorm"
 )
 
-func AddUserRoutes(db *gorm.DB, userService handlers.UserService, cfg *config.Config, l zerolog.Logger) chi.Router {
+func AddUserRoutes(db *gorm.DB, userService handlers.UserService, st store.Store, cfg *config.Config, l zerolog.Logger) chi.Router {
 	r := chi.NewRouter()
 	h := handlers.NewUserHandler(userService, cfg, l)
 
-	r.Get("/", h.GetUsers)
-	r.Get("/count", h.GetUsersCount)
-	r.Get("/{user_id}", h.GetUser)
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.RateLimit(st, "users:read", cfg.RATE_LIMIT_USERS_READ))
+		r.Use(middlewares.Cache(st, cfg.RESPONSE_CACHE_TTL))
+		r.Get("/", h.GetUsers)
+		r.Get("/count", h.GetUsersCount)
+		r.Get("/{user_id}", h.GetUser)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.RateLimit(st, "users:write", cfg.RATE_LIMIT_USERS_WRITE))
+		r.Use(middlewares.ContentType("application/json"))
+		r.Post("/", h.CreateUser)
+		r.Patch("/{user_id}", h.UpdateUser)
+	})
 
 	return r
 }
@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"github.com/go-chi/chi"
+	"github.com/princecee/lema-ai/config"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/internal/handlers"
+	"github.com/princecee/lema-ai/internal/middlewares"
+	"github.com/princecee/lema-ai/pkg/store"
+	"github.com/rs/zerolog"
+)
+
+func AddWebhookRoutes(webhookService handlers.WebhookService, apiKeys *middlewares.APIKeys, st store.Store, cfg *config.Config, l zerolog.Logger) chi.Router {
+	r := chi.NewRouter()
+	h := handlers.NewWebhookHandler(webhookService, cfg, l)
+
+	// Webhooks make the server send requests and keep the responses, so
+	// only admin API keys may manage them.
+	r.Use(middlewares.APIKey(apiKeys))
+	r.Use(middlewares.RequireRole(models.RoleAdmin))
+	r.Get("/", h.GetWebhooks)
+	r.Get("/{webhook_id}", h.GetWebhook)
+	r.Get("/{webhook_id}/deliveries", h.GetDeliveries)
+	r.Get("/{webhook_id}/deliveries/{delivery_id}", h.GetDelivery)
+
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.RateLimit(st, "webhooks", cfg.RATE_LIMIT_WEBHOOKS))
+		r.Use(middlewares.ContentType("application/json"))
+		r.Post("/", h.CreateWebhook)
+		r.Delete("/{webhook_id}", h.DeleteWebhook)
+		r.Post("/{webhook_id}/deliveries/{delivery_id}/redeliver", h.Redeliver)
+	})
+
+	return r
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/go-chi/chi"
+	"github.com/princecee/lema-ai/config"
+	"github.com/princecee/lema-ai/internal/handlers"
+	"github.com/princecee/lema-ai/internal/middlewares"
+	"github.com/rs/zerolog"
+)
+
+func AddWebSocketRoutes(hub handlers.Hub, apiKeys *middlewares.APIKeys, cfg *config.Config, l zerolog.Logger) chi.Router {
+	r := chi.NewRouter()
+	h := handlers.NewWebSocketHandler(hub, cfg, l)
+
+	r.Use(middlewares.APIKey(apiKeys))
+	r.Get("/", h.Connect)
+
+	return r
+}
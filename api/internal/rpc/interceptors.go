@@ -0,0 +1,200 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/internal/middlewares"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/i18n"
+	"github.com/princecee/lema-ai/pkg/store"
+	"github.com/rs/zerolog"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// APIKeyMetadata is the metadata key carrying the API key of a call. The
+// key may also be sent as an authorization bearer token.
+const APIKeyMetadata = "x-api-key"
+
+// errorDomain is the domain of the ErrorInfo detail attached to errors.
+const errorDomain = "lema-ai"
+
+// Logging logs every call with its status code and duration.
+func Logging(l zerolog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		event := l.Info()
+		if code == codes.Internal || code == codes.Unknown {
+			event = l.Error()
+		}
+		event.Str("method", info.FullMethod).
+			Str("code", code.String()).
+			Dur("duration", time.Since(start)).
+			Msg("grpc call")
+		return resp, err
+	}
+}
+
+// Recoverer turns a panicking handler into an internal error rather than
+// crashing the server.
+func Recoverer(l zerolog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				l.Error().Interface("panic", v).Str("method", info.FullMethod).Msg("grpc handler panicked")
+				err = status.Error(codes.Internal, apperror.ErrInternalServer.Message)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// Locale resolves the locale of error messages from the accept-language
+// metadata, like the Locale middleware does from the header.
+func Locale(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	locale := i18n.ParseAcceptLanguage(firstMetadata(ctx, "accept-language"))
+	return handler(i18n.WithLocale(ctx, locale), req)
+}
+
+// Auth rejects calls that do not carry one of keys in the x-api-key
+// metadata or as an authorization bearer token, and stores the caller's
+// identity in the context. Keys with the reader role may only make calls
+// that read, see isRead.
+func Auth(keys *middlewares.APIKeys) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		key := firstMetadata(ctx, APIKeyMetadata)
+		if key == "" {
+			if token, ok := strings.CutPrefix(firstMetadata(ctx, "authorization"), "Bearer "); ok {
+				key = strings.TrimSpace(token)
+			}
+		}
+
+		id, ok := keys.Identify(ctx, key)
+		if !ok {
+			return nil, apperror.ErrUnauthorized.WithMessage("Missing or invalid API key")
+		}
+		if !isRead(info.FullMethod) && !models.RoleAllows(id.Role, models.RoleWriter) {
+			return nil, middlewares.ForbiddenRole(id.Role)
+		}
+		return handler(middlewares.WithIdentity(ctx, id), req)
+	}
+}
+
+// ReadYourWrites sends the reads of callers that wrote in the last window
+// to the primary, since the replicas may not have their writes yet. It is
+// the counterpart of the REST middleware and keeps the same mark in st
+// under the caller's identity, so a write made over one API pins the reads
+// made over the other. It must come after Auth. Calls whose method does
+// not start with Get, List or Count count as writes and their own reads go
+// to the primary.
+func ReadYourWrites(st store.Store, window time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		id, ok := middlewares.IdentityFromContext(ctx)
+		if !ok {
+			return handler(ctx, req)
+		}
+		key := middlewares.ReadPrimaryKey(id)
+
+		if isRead(info.FullMethod) {
+			if _, err := st.Get(ctx, key); err == nil {
+				ctx = database.ReadPrimary(ctx)
+			}
+			return handler(ctx, req)
+		}
+
+		if window > 0 {
+			// The mark is best-effort, like the response cache.
+			_ = st.Set(ctx, key, []byte("1"), window)
+		}
+		return handler(database.ReadPrimary(ctx), req)
+	}
+}
+
+// isRead reports whether the method of fullMethod only reads.
+func isRead(fullMethod string) bool {
+	method := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	for _, prefix := range []string{"Get", "List", "Count"} {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Errors converts the errors of handlers into gRPC statuses. The message
+// is localized, an ErrorInfo detail carries the error code as its reason
+// and violations are sent as a BadRequest detail. Errors that are not
+// application errors are reported as internal errors.
+func Errors(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	resp, err := handler(ctx, req)
+	if err == nil {
+		return resp, nil
+	}
+	if _, ok := err.(interface{ GRPCStatus() *status.Status }); ok {
+		return nil, err
+	}
+
+	appErr := apperror.FromError(err).Localize(i18n.LocaleFromContext(ctx))
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: string(appErr.Code), Domain: errorDomain}}
+	if len(appErr.Violations) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, v := range appErr.Violations {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       v.Field,
+				Description: v.Message,
+			})
+		}
+		details = append(details, badRequest)
+	}
+
+	st, detailErr := status.New(statusCode(appErr.Code), appErr.Message).WithDetails(details...)
+	if detailErr != nil {
+		return nil, status.Error(statusCode(appErr.Code), appErr.Message)
+	}
+	return nil, st.Err()
+}
+
+// statusCode maps an application error code to the closest gRPC code.
+func statusCode(code apperror.Code) codes.Code {
+	switch code {
+	case apperror.CodeNotFound:
+		return codes.NotFound
+	case apperror.CodeBadRequest, apperror.CodeInvalidParameter, apperror.CodeInvalidJSON,
+		apperror.CodeValidation, apperror.CodeUnsupportedMedia:
+		return codes.InvalidArgument
+	case apperror.CodeConflict, apperror.CodeIdempotencyKeyReused:
+		return codes.AlreadyExists
+	case apperror.CodeUnprocessable:
+		return codes.FailedPrecondition
+	case apperror.CodeRequestInProgress:
+		return codes.Aborted
+	case apperror.CodeTooManyRequests, apperror.CodePayloadTooLarge:
+		return codes.ResourceExhausted
+	case apperror.CodeUnauthorized:
+		return codes.Unauthenticated
+	case apperror.CodeForbidden:
+		return codes.PermissionDenied
+	case apperror.CodeMethodNotAllowed:
+		return codes.Unimplemented
+	default:
+		return codes.Internal
+	}
+}
+
+func firstMetadata(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,135 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/princecee/lema-ai/internal/db/models"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	lemav1 "github.com/princecee/lema-ai/pkg/pb/lema/v1"
+	"github.com/princecee/lema-ai/pkg/validator"
+)
+
+type PostService interface {
+	CreatePost(p *models.Post) error
+	UpdatePost(p *models.Post) error
+	GetPost(postId string) (*models.Post, error)
+	GetPosts(ctx context.Context, userId string) ([]*models.Post, error)
+	DeletePost(postId string) error
+}
+
+type PostServer struct {
+	lemav1.UnimplementedPostServiceServer
+	postService PostService
+}
+
+func NewPostServer(postService PostService) *PostServer {
+	return &PostServer{postService: postService}
+}
+
+type createPostData struct {
+	Title  string `json:"title" mod:"trim" validate:"required,notblank,max=200,nobannedwords"`
+	Body   string `json:"body" mod:"trim" validate:"required,notblank,max=10000,nobannedwords"`
+	UserID string `json:"user_id" validate:"required,uuid"`
+}
+
+type updatePostData struct {
+	Title *string `json:"title" mod:"trim" validate:"omitnil,notblank,max=200,nobannedwords"`
+	Body  *string `json:"body" mod:"trim" validate:"omitnil,notblank,max=10000,nobannedwords"`
+}
+
+func (s *PostServer) ListPosts(ctx context.Context, req *lemav1.ListPostsRequest) (*lemav1.ListPostsResponse, error) {
+	if !validator.IsValidUUID(req.GetUserId()) {
+		return nil, apperror.InvalidParameter("user_id", "Invalid user ID")
+	}
+
+	posts, err := s.postService.GetPosts(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &lemav1.ListPostsResponse{Posts: make([]*lemav1.Post, len(posts))}
+	for i, p := range posts {
+		resp.Posts[i] = toPost(p)
+	}
+	return resp, nil
+}
+
+func (s *PostServer) GetPost(ctx context.Context, req *lemav1.GetPostRequest) (*lemav1.Post, error) {
+	if !validator.IsValidUUID(req.GetId()) {
+		return nil, apperror.InvalidParameter("id", "Invalid post ID")
+	}
+
+	post, err := s.postService.GetPost(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return toPost(post), nil
+}
+
+func (s *PostServer) CreatePost(ctx context.Context, req *lemav1.CreatePostRequest) (*lemav1.Post, error) {
+	data := &createPostData{
+		Title:  req.GetTitle(),
+		Body:   req.GetBody(),
+		UserID: req.GetUserId(),
+	}
+	if err := validator.ValidateData(ctx, data); err != nil {
+		return nil, err
+	}
+
+	post := &models.Post{
+		ID:     uuid.NewString(),
+		Title:  data.Title,
+		Body:   data.Body,
+		UserID: data.UserID,
+	}
+	if err := s.postService.CreatePost(post); err != nil {
+		return nil, err
+	}
+	return toPost(post), nil
+}
+
+func (s *PostServer) UpdatePost(ctx context.Context, req *lemav1.UpdatePostRequest) (*lemav1.Post, error) {
+	if !validator.IsValidUUID(req.GetId()) {
+		return nil, apperror.InvalidParameter("id", "Invalid post ID")
+	}
+
+	data := &updatePostData{Title: req.Title, Body: req.Body}
+	if err := validator.ValidateData(ctx, data); err != nil {
+		return nil, err
+	}
+
+	post, err := s.postService.GetPost(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	setIfPresent(&post.Title, data.Title)
+	setIfPresent(&post.Body, data.Body)
+
+	if err := s.postService.UpdatePost(post); err != nil {
+		return nil, err
+	}
+	return toPost(post), nil
+}
+
+func (s *PostServer) DeletePost(ctx context.Context, req *lemav1.DeletePostRequest) (*lemav1.DeletePostResponse, error) {
+	if !validator.IsValidUUID(req.GetId()) {
+		return nil, apperror.InvalidParameter("id", "Invalid post ID")
+	}
+
+	if err := s.postService.DeletePost(req.GetId()); err != nil {
+		return nil, err
+	}
+	return &lemav1.DeletePostResponse{}, nil
+}
+
+func toPost(p *models.Post) *lemav1.Post {
+	return &lemav1.Post{
+		Id:        p.ID,
+		UserId:    p.UserID,
+		Title:     p.Title,
+		Body:      p.Body,
+		CreatedAt: p.CreatedAt,
+	}
+}
@@ -0,0 +1,31 @@
+// Package rpc serves the gRPC API. Its services mirror the REST endpoints
+// and share their service layer.
+package rpc
+
+import (
+	"github.com/princecee/lema-ai/config"
+	"github.com/princecee/lema-ai/internal/middlewares"
+	lemav1 "github.com/princecee/lema-ai/pkg/pb/lema/v1"
+	"github.com/princecee/lema-ai/pkg/store"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+)
+
+// NewServer returns a gRPC server serving the user and post services.
+// Every call must carry one of apiKeys, like the WebSocket endpoint. The
+// callers that wrote recently are tracked in st, see ReadYourWrites.
+func NewServer(userService UserService, postService PostService, apiKeys *middlewares.APIKeys, st store.Store, cfg *config.Config, l zerolog.Logger) *grpc.Server {
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		Logging(l),
+		Recoverer(l),
+		Locale,
+		Errors,
+		Auth(apiKeys),
+		ReadYourWrites(st, cfg.READ_YOUR_WRITES_WINDOW),
+	))
+
+	lemav1.RegisterUserServiceServer(s, NewUserServer(userService))
+	lemav1.RegisterPostServiceServer(s, NewPostServer(postService))
+
+	return s
+}
@@ -0,0 +1,241 @@
+package rpc_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/princecee/lema-ai/config"
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/internal/db/repositories"
+	"github.com/princecee/lema-ai/internal/middlewares"
+	"github.com/princecee/lema-ai/internal/rpc"
+	"github.com/princecee/lema-ai/internal/services"
+	lemav1 "github.com/princecee/lema-ai/pkg/pb/lema/v1"
+	"github.com/princecee/lema-ai/pkg/store"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm"
+)
+
+const testAPIKey = "test-api-key"
+
+type ServerTestSuite struct {
+	suite.Suite
+	db     *gorm.DB
+	server *grpc.Server
+	conn   *grpc.ClientConn
+	users  lemav1.UserServiceClient
+	posts  lemav1.PostServiceClient
+	phones int
+}
+
+func (s *ServerTestSuite) SetupSuite() {
+	cfg := config.NewConfig("test", "silent")
+	cfg.DSN = "file:rpc?mode=memory&cache=shared"
+	cfg.API_KEYS = []string{testAPIKey}
+
+	db := database.GetDBConn(cfg.DSN, cfg.REPLICA_DSNS, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
+	err := database.Migrate(db)
+	if err != nil {
+		s.Fail(err.Error())
+	}
+	s.db = db
+
+	s.server = rpc.NewServer(
+		services.NewUserService(repositories.NewUserRepository(db)),
+		services.NewPostService(repositories.NewPostRepository(db)),
+		middlewares.NewAPIKeys(cfg.API_KEYS, repositories.NewAPIKeyRepository(db)),
+		store.NewMemoryStore(), cfg, zerolog.Nop(),
+	)
+	listener := bufconn.Listen(1 << 20)
+	go s.server.Serve(listener)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	s.Require().NoError(err)
+	s.conn = conn
+	s.users = lemav1.NewUserServiceClient(conn)
+	s.posts = lemav1.NewPostServiceClient(conn)
+}
+
+func (s *ServerTestSuite) TearDownSuite() {
+	s.conn.Close()
+	s.server.Stop()
+
+	s.NoError(database.Close(s.db))
+}
+
+// ctx returns a context authenticating calls with the test key, plus the
+// given metadata pairs.
+func (s *ServerTestSuite) ctx(pairs ...string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), append([]string{rpc.APIKeyMetadata, testAPIKey}, pairs...)...)
+}
+
+func (s *ServerTestSuite) createUser() *lemav1.User {
+	id := uuid.NewString()
+	s.phones++
+	user, err := s.users.CreateUser(s.ctx(), &lemav1.CreateUserRequest{
+		Name:     "Jane Doe",
+		Username: id,
+		Email:    id + "@example.com",
+		Phone:    fmt.Sprintf("+1555%07d", s.phones),
+		Address:  &lemav1.CreateAddress{Street: "1 Main St", City: "Springfield", State: "IL", Zipcode: "62701"},
+	})
+	s.Require().NoError(err)
+	return user
+}
+
+func (s *ServerTestSuite) TestAuth() {
+	_, err := s.users.CountUsers(context.Background(), &lemav1.CountUsersRequest{})
+	s.Equal(codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer wrong")
+	_, err = s.users.CountUsers(ctx, &lemav1.CountUsersRequest{})
+	s.Equal(codes.Unauthenticated, status.Code(err))
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+testAPIKey)
+	_, err = s.users.CountUsers(ctx, &lemav1.CountUsersRequest{})
+	s.NoError(err)
+}
+
+func (s *ServerTestSuite) TestRoles() {
+	_, key, err := services.NewAPIKeyService(repositories.NewAPIKeyRepository(s.db)).CreateAPIKey(context.Background(), "dashboard", models.RoleReader)
+	s.Require().NoError(err)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), rpc.APIKeyMetadata, key)
+
+	_, err = s.users.CountUsers(ctx, &lemav1.CountUsersRequest{})
+	s.NoError(err)
+
+	_, err = s.posts.DeletePost(ctx, &lemav1.DeletePostRequest{Id: uuid.NewString()})
+	s.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (s *ServerTestSuite) TestUsers() {
+	user := s.createUser()
+	s.NotEmpty(user.Id)
+	s.Equal("Springfield", user.Address.City)
+
+	got, err := s.users.GetUser(s.ctx(), &lemav1.GetUserRequest{Id: user.Id})
+	s.Require().NoError(err)
+	s.True(proto.Equal(user, got))
+
+	updated, err := s.users.UpdateUser(s.ctx(), &lemav1.UpdateUserRequest{
+		Id:      user.Id,
+		Name:    proto.String("  John Doe "),
+		Address: &lemav1.UpdateAddress{City: proto.String("Chicago")},
+	})
+	s.Require().NoError(err)
+	s.Equal("John Doe", updated.Name)
+	s.Equal(user.Email, updated.Email)
+	s.Equal("Chicago", updated.Address.City)
+	s.Equal(user.Address.Street, updated.Address.Street)
+
+	list, err := s.users.ListUsers(s.ctx(), &lemav1.ListUsersRequest{})
+	s.Require().NoError(err)
+	s.EqualValues(1, list.Page)
+	s.EqualValues(10, list.Limit)
+	s.NotEmpty(list.Users)
+
+	count, err := s.users.CountUsers(s.ctx(), &lemav1.CountUsersRequest{})
+	s.Require().NoError(err)
+	s.Equal(list.Count, count.Count)
+}
+
+func (s *ServerTestSuite) TestPosts() {
+	user := s.createUser()
+
+	post, err := s.posts.CreatePost(s.ctx(), &lemav1.CreatePostRequest{UserId: user.Id, Title: "Hello", Body: "World"})
+	s.Require().NoError(err)
+	s.NotEmpty(post.CreatedAt)
+
+	updated, err := s.posts.UpdatePost(s.ctx(), &lemav1.UpdatePostRequest{Id: post.Id, Body: proto.String("Everyone")})
+	s.Require().NoError(err)
+	s.Equal("Hello", updated.Title)
+	s.Equal("Everyone", updated.Body)
+
+	list, err := s.posts.ListPosts(s.ctx(), &lemav1.ListPostsRequest{UserId: user.Id})
+	s.Require().NoError(err)
+	s.Require().Len(list.Posts, 1)
+	s.True(proto.Equal(updated, list.Posts[0]))
+
+	_, err = s.posts.DeletePost(s.ctx(), &lemav1.DeletePostRequest{Id: post.Id})
+	s.Require().NoError(err)
+
+	_, err = s.posts.GetPost(s.ctx(), &lemav1.GetPostRequest{Id: post.Id})
+	s.Equal(codes.NotFound, status.Code(err))
+}
+
+func (s *ServerTestSuite) TestErrors() {
+	_, err := s.posts.CreatePost(s.ctx(), &lemav1.CreatePostRequest{UserId: "nope", Title: " ", Body: "World"})
+	st := status.Convert(err)
+	s.Equal(codes.InvalidArgument, st.Code())
+
+	var fields []string
+	var reason string
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			reason = d.Reason
+		case *errdetails.BadRequest:
+			for _, v := range d.FieldViolations {
+				fields = append(fields, v.Field)
+			}
+		}
+	}
+	s.Equal("validation_failed", reason)
+	s.ElementsMatch([]string{"title", "user_id"}, fields)
+
+	_, err = s.users.GetUser(s.ctx("accept-language", "fr"), &lemav1.GetUserRequest{Id: "nope"})
+	st = status.Convert(err)
+	s.Equal(codes.InvalidArgument, st.Code())
+	s.Equal("Identifiant d'utilisateur invalide", st.Message())
+
+	_, err = s.users.GetUser(s.ctx(), &lemav1.GetUserRequest{Id: uuid.NewString()})
+	s.Equal(codes.NotFound, status.Code(err))
+
+	user := s.createUser()
+	_, err = s.users.UpdateUser(s.ctx(), &lemav1.UpdateUserRequest{Id: s.createUser().Id, Email: proto.String(user.Email)})
+	s.Equal(codes.AlreadyExists, status.Code(err))
+}
+
+func TestServer(t *testing.T) {
+	suite.Run(t, new(ServerTestSuite))
+}
+
+func TestReadYourWrites(t *testing.T) {
+	interceptor := rpc.ReadYourWrites(store.NewMemoryStore(), 5*time.Second)
+	call := func(ctx context.Context, method string) bool {
+		var readsPrimary bool
+		info := &grpc.UnaryServerInfo{FullMethod: "/lema.v1.UserService/" + method}
+		_, err := interceptor(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+			readsPrimary = database.ReadsPrimary(ctx)
+			return nil, nil
+		})
+		assert.NoError(t, err)
+		return readsPrimary
+	}
+
+	alice := middlewares.WithIdentity(context.Background(), middlewares.Identity{Kind: middlewares.IdentityAPIKey, ID: "alice"})
+	bob := middlewares.WithIdentity(context.Background(), middlewares.Identity{Kind: middlewares.IdentityAPIKey, ID: "bob"})
+
+	assert.False(t, call(alice, "GetUser"))
+	assert.True(t, call(alice, "CreateUser"))
+	assert.True(t, call(alice, "ListUsers"))
+	assert.False(t, call(bob, "ListUsers"))
+}
@@ -0,0 +1,222 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/princecee/lema-ai/internal/db/models"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/pagination"
+	lemav1 "github.com/princecee/lema-ai/pkg/pb/lema/v1"
+	"github.com/princecee/lema-ai/pkg/validator"
+)
+
+type UserService interface {
+	CreateUser(u *models.User) error
+	UpdateUser(u *models.User) error
+	GetUsers(ctx context.Context, page, limit int) (*pagination.GetUsersResult, error)
+	GetUserCount(ctx context.Context) (int64, error)
+	GetUser(id string) (*models.User, error)
+}
+
+type UserServer struct {
+	lemav1.UnimplementedUserServiceServer
+	userService UserService
+}
+
+func NewUserServer(userService UserService) *UserServer {
+	return &UserServer{userService: userService}
+}
+
+// createUserData and updateUserData validate requests with the rules of
+// the REST payloads. Their json tags name fields as the protobuf messages
+// do, so violations point at the fields clients set.
+type createUserData struct {
+	Name     string          `json:"name" mod:"trim" validate:"required,notblank,max=100"`
+	Username string          `json:"username" mod:"trim" validate:"required,notblank,max=50,nobannedwords"`
+	Email    string          `json:"email" mod:"trim,lower" validate:"required,deliverable_email"`
+	Phone    string          `json:"phone" mod:"e164" validate:"required,e164"`
+	Address  userAddressData `json:"address" validate:"required"`
+}
+
+type userAddressData struct {
+	Street  string `json:"street" mod:"trim" validate:"required,notblank,max=200"`
+	City    string `json:"city" mod:"trim" validate:"required,notblank,max=100"`
+	State   string `json:"state" mod:"trim" validate:"required,notblank,max=100"`
+	Zipcode string `json:"zipcode" mod:"trim" validate:"required,us_zipcode"`
+}
+
+type updateUserData struct {
+	Name     *string                `json:"name" mod:"trim" validate:"omitnil,notblank,max=100"`
+	Username *string                `json:"username" mod:"trim" validate:"omitnil,notblank,max=50,nobannedwords"`
+	Email    *string                `json:"email" mod:"trim,lower" validate:"omitnil,deliverable_email"`
+	Phone    *string                `json:"phone" mod:"e164" validate:"omitnil,e164"`
+	Address  *updateUserAddressData `json:"address"`
+}
+
+type updateUserAddressData struct {
+	Street  *string `json:"street" mod:"trim" validate:"omitnil,notblank,max=200"`
+	City    *string `json:"city" mod:"trim" validate:"omitnil,notblank,max=100"`
+	State   *string `json:"state" mod:"trim" validate:"omitnil,notblank,max=100"`
+	Zipcode *string `json:"zipcode" mod:"trim" validate:"omitnil,us_zipcode"`
+}
+
+func (s *UserServer) ListUsers(ctx context.Context, req *lemav1.ListUsersRequest) (*lemav1.ListUsersResponse, error) {
+	page, limit := int(req.GetPage()), int(req.GetLimit())
+	if page == 0 {
+		page = 1
+	}
+	if limit == 0 {
+		limit = 10
+	}
+	if page < 0 {
+		return nil, apperror.InvalidParameter("page", pagination.ErrInvalidPage.Error())
+	}
+	if limit < 0 {
+		return nil, apperror.InvalidParameter("limit", pagination.ErrInvalidLimit.Error())
+	}
+
+	result, err := s.userService.GetUsers(ctx, page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*lemav1.User, len(result.Users))
+	for i, u := range result.Users {
+		users[i] = toUser(u)
+	}
+	return &lemav1.ListUsersResponse{
+		Users:      users,
+		Count:      result.Count,
+		TotalPages: result.TotalPages,
+		Page:       result.Page,
+		Limit:      result.Limit,
+		HasNext:    result.HasNext,
+		HasPrev:    result.HasPrev,
+	}, nil
+}
+
+func (s *UserServer) CountUsers(ctx context.Context, req *lemav1.CountUsersRequest) (*lemav1.CountUsersResponse, error) {
+	count, err := s.userService.GetUserCount(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &lemav1.CountUsersResponse{Count: count}, nil
+}
+
+func (s *UserServer) GetUser(ctx context.Context, req *lemav1.GetUserRequest) (*lemav1.User, error) {
+	if !validator.IsValidUUID(req.GetId()) {
+		return nil, apperror.InvalidParameter("id", "Invalid user ID")
+	}
+
+	user, err := s.userService.GetUser(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return toUser(user), nil
+}
+
+func (s *UserServer) CreateUser(ctx context.Context, req *lemav1.CreateUserRequest) (*lemav1.User, error) {
+	data := &createUserData{
+		Name:     req.GetName(),
+		Username: req.GetUsername(),
+		Email:    req.GetEmail(),
+		Phone:    req.GetPhone(),
+		Address: userAddressData{
+			Street:  req.GetAddress().GetStreet(),
+			City:    req.GetAddress().GetCity(),
+			State:   req.GetAddress().GetState(),
+			Zipcode: req.GetAddress().GetZipcode(),
+		},
+	}
+	if err := validator.ValidateData(ctx, data); err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		ID:       uuid.NewString(),
+		Name:     data.Name,
+		Username: data.Username,
+		Email:    data.Email,
+		Phone:    data.Phone,
+		Address: models.Address{
+			ID:      uuid.NewString(),
+			Street:  data.Address.Street,
+			City:    data.Address.City,
+			State:   data.Address.State,
+			Zipcode: data.Address.Zipcode,
+		},
+	}
+	if err := s.userService.CreateUser(user); err != nil {
+		return nil, err
+	}
+	return toUser(user), nil
+}
+
+func (s *UserServer) UpdateUser(ctx context.Context, req *lemav1.UpdateUserRequest) (*lemav1.User, error) {
+	if !validator.IsValidUUID(req.GetId()) {
+		return nil, apperror.InvalidParameter("id", "Invalid user ID")
+	}
+
+	data := &updateUserData{
+		Name:     req.Name,
+		Username: req.Username,
+		Email:    req.Email,
+		Phone:    req.Phone,
+	}
+	if address := req.GetAddress(); address != nil {
+		data.Address = &updateUserAddressData{
+			Street:  address.Street,
+			City:    address.City,
+			State:   address.State,
+			Zipcode: address.Zipcode,
+		}
+	}
+	if err := validator.ValidateData(ctx, data); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.GetUser(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	setIfPresent(&user.Name, data.Name)
+	setIfPresent(&user.Username, data.Username)
+	setIfPresent(&user.Email, data.Email)
+	setIfPresent(&user.Phone, data.Phone)
+	if address := data.Address; address != nil {
+		setIfPresent(&user.Address.Street, address.Street)
+		setIfPresent(&user.Address.City, address.City)
+		setIfPresent(&user.Address.State, address.State)
+		setIfPresent(&user.Address.Zipcode, address.Zipcode)
+	}
+
+	if err := s.userService.UpdateUser(user); err != nil {
+		return nil, err
+	}
+	return toUser(user), nil
+}
+
+func toUser(u *models.User) *lemav1.User {
+	return &lemav1.User{
+		Id:       u.ID,
+		Name:     u.Name,
+		Email:    u.Email,
+		Username: u.Username,
+		Phone:    u.Phone,
+		Address: &lemav1.Address{
+			Id:      u.Address.ID,
+			Street:  u.Address.Street,
+			City:    u.Address.City,
+			State:   u.Address.State,
+			Zipcode: u.Address.Zipcode,
+		},
+	}
+}
+
+func setIfPresent(dst *string, value *string) {
+	if value != nil {
+		*dst = *value
+	}
+}
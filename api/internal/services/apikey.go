@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/princecee/lema-ai/internal/db/models"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"gorm.io/gorm"
+)
+
+// apiKeyPrefixLen is the number of characters of a key kept to tell it
+// apart from the others.
+const apiKeyPrefixLen = 8
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, k *models.APIKey) error
+	GetAPIKeys(ctx context.Context) ([]*models.APIKey, error)
+	UpdateAPIKeyRole(ctx context.Context, keyId, role string) error
+	DeleteAPIKey(ctx context.Context, keyId string) error
+}
+
+// APIKeyService manages the API keys stored in the database, which the
+// server accepts alongside those of API_KEYS.
+type APIKeyService struct {
+	apiKeyRepo APIKeyRepository
+}
+
+func NewAPIKeyService(apiKeyRepo APIKeyRepository) *APIKeyService {
+	return &APIKeyService{apiKeyRepo}
+}
+
+// GenerateAPIKey returns a new random key.
+func GenerateAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CreateAPIKey stores a new key with role and returns it. Only its hash is
+// stored, so the key cannot be shown again.
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, name, role string) (*models.APIKey, string, error) {
+	if !slices.Contains(models.Roles, role) {
+		return nil, "", apperror.ErrInvalidParameter
+	}
+
+	key, err := GenerateAPIKey()
+	if err != nil {
+		return nil, "", apperror.ErrInternalServer
+	}
+	k := &models.APIKey{
+		ID:        uuid.NewString(),
+		Name:      name,
+		Role:      role,
+		Hash:      models.HashAPIKey(key),
+		Prefix:    key[:apiKeyPrefixLen],
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.apiKeyRepo.CreateAPIKey(ctx, k); err != nil {
+		return nil, "", apperror.ErrInternalServer
+	}
+
+	return k, key, nil
+}
+
+func (s *APIKeyService) GetAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	keys, err := s.apiKeyRepo.GetAPIKeys(ctx)
+	if err != nil {
+		return nil, apperror.ErrInternalServer
+	}
+
+	return keys, nil
+}
+
+// SetRole changes the role of a key. It applies to the next request made
+// with the key.
+func (s *APIKeyService) SetRole(ctx context.Context, keyId, role string) error {
+	if !slices.Contains(models.Roles, role) {
+		return apperror.ErrInvalidParameter
+	}
+
+	return apiKeyWriteError(s.apiKeyRepo.UpdateAPIKeyRole(ctx, keyId, role))
+}
+
+// DeleteAPIKey revokes a key. It applies to the next request made with the
+// key.
+func (s *APIKeyService) DeleteAPIKey(ctx context.Context, keyId string) error {
+	return apiKeyWriteError(s.apiKeyRepo.DeleteAPIKey(ctx, keyId))
+}
+
+func apiKeyWriteError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return apperror.ErrNotFound
+	default:
+		return apperror.ErrInternalServer
+	}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/rs/zerolog"
+)
+
+// Backuper takes backups of the database, see database.Backups.
+type Backuper interface {
+	Create(ctx context.Context) (*models.Backup, error)
+}
+
+// BackupService takes a backup every interval.
+type BackupService struct {
+	backups  Backuper
+	interval time.Duration
+	logger   zerolog.Logger
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewBackupService returns a service taking a backup every interval. It
+// does nothing when interval is zero.
+func NewBackupService(backups Backuper, interval time.Duration, l zerolog.Logger) *BackupService {
+	return &BackupService{backups: backups, interval: interval, logger: l}
+}
+
+// Start launches the backup loop. The first backup is taken after one
+// interval.
+func (s *BackupService) Start(ctx context.Context) {
+	if s.interval <= 0 {
+		return
+	}
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.backup(ctx)
+			}
+		}
+	}()
+}
+
+// Shutdown stops the backup loop. A backup in progress is abandoned.
+func (s *BackupService) Shutdown(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *BackupService) backup(ctx context.Context) {
+	backup, err := s.backups.Create(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Error().Err(err).Msg("failed to back up the database")
+		}
+		return
+	}
+	s.logger.Info().Str("backup", backup.Name).Int64("size", backup.Size).Msg("database backed up")
+}
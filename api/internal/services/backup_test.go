@@ -0,0 +1,54 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/internal/services"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingBackuper counts backups, failing every other one.
+type countingBackuper struct {
+	calls atomic.Int32
+}
+
+func (b *countingBackuper) Create(ctx context.Context) (*models.Backup, error) {
+	if b.calls.Add(1)%2 == 0 {
+		return nil, errors.New("disk full")
+	}
+	return &models.Backup{Name: "backup"}, nil
+}
+
+func TestBackupServiceRunsEveryInterval(t *testing.T) {
+	backuper := &countingBackuper{}
+	s := services.NewBackupService(backuper, 10*time.Millisecond, zerolog.Nop())
+	s.Start(context.Background())
+
+	// A failed backup does not stop the next ones.
+	assert.Eventually(t, func() bool { return backuper.calls.Load() >= 3 }, time.Second, 5*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, s.Shutdown(ctx))
+
+	calls := backuper.calls.Load()
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, calls, backuper.calls.Load())
+}
+
+func TestBackupServiceDisabled(t *testing.T) {
+	backuper := &countingBackuper{}
+	s := services.NewBackupService(backuper, 0, zerolog.Nop())
+	s.Start(context.Background())
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Zero(t, backuper.calls.Load())
+	assert.NoError(t, s.Shutdown(context.Background()))
+}
@@ -0,0 +1,424 @@
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	stdcsv "encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/pkg/csv"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+)
+
+type ExportRepository interface {
+	CreateExport(ctx context.Context, e *models.Export) error
+	UpdateExport(ctx context.Context, e *models.Export) error
+	GetExport(ctx context.Context, exportId string) (*models.Export, error)
+	UpdateExportProgress(ctx context.Context, exportId string, processed int64) error
+	GetExpiredExports(ctx context.Context, now time.Time) ([]*models.Export, error)
+	DeleteExport(ctx context.Context, exportId string) error
+	CountUsers(ctx context.Context, filters models.ExportFilters) (int64, error)
+	StreamUsers(ctx context.Context, filters models.ExportFilters, fn func(*models.User) error) error
+	CountPosts(ctx context.Context, filters models.ExportFilters) (int64, error)
+	StreamPosts(ctx context.Context, filters models.ExportFilters, fn func(*models.Post) error) error
+}
+
+// JobEnqueuer schedules background jobs, see JobQueue.
+type JobEnqueuer interface {
+	Enqueue(ctx context.Context, kind string, payload any) (*models.Job, error)
+}
+
+// ExportJobKind is the kind of the jobs that write exports.
+const ExportJobKind = "export"
+
+type exportJobPayload struct {
+	ExportID string `json:"export_id"`
+}
+
+// ExportOptions configures where exports are written and how long they are
+// kept.
+type ExportOptions struct {
+	// Dir is the directory export files are written to.
+	Dir string
+	// TTL is how long a finished export can be downloaded.
+	TTL time.Duration
+	// CleanupInterval is how often expired exports are deleted, never if
+	// it is zero or less.
+	CleanupInterval time.Duration
+}
+
+// ExportService writes exports in the background. Each export is a job
+// handled by RunExportJob, so failed exports are retried by the job queue.
+type ExportService struct {
+	transactor Transactor
+	exportRepo ExportRepository
+	jobs       JobEnqueuer
+	opts       ExportOptions
+	logger     zerolog.Logger
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+func NewExportService(transactor Transactor, exportRepo ExportRepository, jobs JobEnqueuer, opts ExportOptions, l zerolog.Logger) *ExportService {
+	return &ExportService{
+		transactor: transactor,
+		exportRepo: exportRepo,
+		jobs:       jobs,
+		opts:       opts,
+		logger:     l,
+	}
+}
+
+// progressInterval is the number of rows written between progress updates.
+const progressInterval = 100
+
+func (s *ExportService) CreateExport(ctx context.Context, e *models.Export) error {
+	e.ID = uuid.NewString()
+	e.Status = models.ExportStatusPending
+	e.CreatedAt = time.Now().UTC()
+
+	err := s.transactor.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.exportRepo.CreateExport(ctx, e); err != nil {
+			return err
+		}
+		_, err := s.jobs.Enqueue(ctx, ExportJobKind, exportJobPayload{ExportID: e.ID})
+		return err
+	})
+	if err != nil {
+		return apperror.ErrInternalServer
+	}
+
+	return nil
+}
+
+func (s *ExportService) GetExport(ctx context.Context, exportId string) (*models.Export, error) {
+	e, err := s.exportRepo.GetExport(ctx, exportId)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, apperror.ErrNotFound
+		default:
+			return nil, apperror.ErrInternalServer
+		}
+	}
+
+	return e, nil
+}
+
+// OpenExport opens the file of a completed export. The caller must close
+// it.
+func (s *ExportService) OpenExport(ctx context.Context, exportId string) (*models.Export, *os.File, error) {
+	e, err := s.GetExport(ctx, exportId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if e.Status != models.ExportStatusCompleted {
+		return nil, nil, apperror.ErrConflict.WithMessage("Export {0} is not ready", e.ID)
+	}
+
+	f, err := os.Open(s.path(e))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, apperror.ErrNotFound
+		}
+		return nil, nil, apperror.ErrInternalServer
+	}
+
+	return e, f, nil
+}
+
+// Start launches the loop deleting expired exports. A CleanupInterval of
+// zero or less disables it.
+func (s *ExportService) Start(ctx context.Context) error {
+	if err := os.MkdirAll(s.opts.Dir, 0o755); err != nil {
+		return err
+	}
+	if s.opts.CleanupInterval <= 0 {
+		return nil
+	}
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.opts.CleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			s.cleanup(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Shutdown stops the cleanup loop.
+func (s *ExportService) Shutdown(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *ExportService) cleanup(ctx context.Context) {
+	exports, err := s.exportRepo.GetExpiredExports(ctx, time.Now().UTC())
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Error().Err(err).Msg("failed to list expired exports")
+		}
+		return
+	}
+
+	for _, e := range exports {
+		if err := os.Remove(s.path(e)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			s.logger.Error().Err(err).Str("export_id", e.ID).Msg("failed to remove export file")
+			continue
+		}
+		if err := s.exportRepo.DeleteExport(ctx, e.ID); err != nil {
+			s.logger.Error().Err(err).Str("export_id", e.ID).Msg("failed to delete export")
+		}
+	}
+}
+
+// RunExportJob writes the export of a job. A failed export is pending
+// again until the job queue gives up on it.
+func (s *ExportService) RunExportJob(ctx context.Context, job *models.Job) error {
+	var payload exportJobPayload
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return err
+	}
+
+	e, err := s.exportRepo.GetExport(ctx, payload.ExportID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The export expired or was deleted before it ran.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if e.Status == models.ExportStatusCompleted || e.Status == models.ExportStatusFailed {
+		return nil
+	}
+
+	// Status updates must land even when the job is being interrupted.
+	saveCtx := context.WithoutCancel(ctx)
+	e.Status = models.ExportStatusRunning
+	err = s.write(ctx, e)
+	if err != nil {
+		e.Status = models.ExportStatusPending
+		if ctx.Err() == nil && job.Attempts >= job.MaxAttempts {
+			now := time.Now().UTC()
+			expiresAt := now.Add(s.opts.TTL)
+			e.CompletedAt, e.ExpiresAt = &now, &expiresAt
+			e.Status = models.ExportStatusFailed
+			e.Error = "The export could not be written"
+		}
+		if err := s.exportRepo.UpdateExport(saveCtx, e); err != nil {
+			s.logger.Error().Err(err).Str("export_id", e.ID).Msg("failed to save export")
+		}
+		return err
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(s.opts.TTL)
+	e.CompletedAt, e.ExpiresAt = &now, &expiresAt
+	e.Status = models.ExportStatusCompleted
+	return s.exportRepo.UpdateExport(saveCtx, e)
+}
+
+// write streams the rows of an export into a gzip file. The file is written
+// under a temporary name so a partial file is never served.
+func (s *ExportService) write(ctx context.Context, e *models.Export) (err error) {
+	path := s.path(e)
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		f.Close()
+		if err != nil {
+			os.Remove(tmp)
+		}
+	}()
+
+	gz := gzip.NewWriter(f)
+	var enc rowEncoder
+	var total int64
+	var stream func(fn func(row any) error) error
+
+	switch e.Entity {
+	case models.ExportEntityUsers:
+		enc = newRowEncoder(gz, e.Format, reflect.TypeOf(models.User{}))
+		total, err = s.exportRepo.CountUsers(ctx, e.Filters)
+		stream = func(fn func(row any) error) error {
+			return s.exportRepo.StreamUsers(ctx, e.Filters, func(u *models.User) error { return fn(u) })
+		}
+	default:
+		enc = newRowEncoder(gz, e.Format, reflect.TypeOf(models.Post{}))
+		total, err = s.exportRepo.CountPosts(ctx, e.Filters)
+		stream = func(fn func(row any) error) error {
+			return s.exportRepo.StreamPosts(ctx, e.Filters, func(p *models.Post) error { return fn(p) })
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	e.Total, e.Processed = total, 0
+	if err := s.exportRepo.UpdateExport(ctx, e); err != nil {
+		return err
+	}
+
+	err = stream(func(row any) error {
+		if err := enc.Encode(row); err != nil {
+			return err
+		}
+		e.Processed++
+		if e.Processed%progressInterval == 0 {
+			return s.exportRepo.UpdateExportProgress(ctx, e.ID, e.Processed)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	e.Size = info.Size()
+
+	return os.Rename(tmp, path)
+}
+
+// path returns the location of an export file, e.g. "<dir>/<id>.csv.gz".
+func (s *ExportService) path(e *models.Export) string {
+	return filepath.Join(s.opts.Dir, e.ID+"."+e.Format+".gz")
+}
+
+// rowEncoder writes rows in an export format.
+type rowEncoder interface {
+	Encode(row any) error
+	// Close writes anything the format needs after the last row.
+	Close() error
+}
+
+func newRowEncoder(w io.Writer, format string, rowType reflect.Type) rowEncoder {
+	switch format {
+	case models.ExportFormatCSV:
+		ce := &csvEncoder{w: stdcsv.NewWriter(w), columns: csv.Columns(rowType)}
+		ce.err = ce.w.Write(csv.Header(ce.columns))
+		return ce
+	case models.ExportFormatNDJSON:
+		return &ndjsonEncoder{enc: json.NewEncoder(w)}
+	default:
+		return &jsonArrayEncoder{w: w}
+	}
+}
+
+type csvEncoder struct {
+	w       *stdcsv.Writer
+	columns []csv.Column
+	err     error
+}
+
+func (ce *csvEncoder) Encode(row any) error {
+	if ce.err != nil {
+		return ce.err
+	}
+	return ce.w.Write(csv.Record(ce.columns, row))
+}
+
+func (ce *csvEncoder) Close() error {
+	if ce.err != nil {
+		return ce.err
+	}
+	ce.w.Flush()
+	return ce.w.Error()
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (ne *ndjsonEncoder) Encode(row any) error {
+	return ne.enc.Encode(row)
+}
+
+func (ne *ndjsonEncoder) Close() error {
+	return nil
+}
+
+// jsonArrayEncoder writes rows as the elements of one JSON array.
+type jsonArrayEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (je *jsonArrayEncoder) Encode(row any) error {
+	b, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n"
+	if je.count == 0 {
+		sep = "[\n"
+	}
+	je.count++
+
+	if _, err := io.WriteString(je.w, sep); err != nil {
+		return err
+	}
+	_, err = je.w.Write(b)
+	return err
+}
+
+func (je *jsonArrayEncoder) Close() error {
+	end := "\n]\n"
+	if je.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(je.w, end)
+	return err
+}
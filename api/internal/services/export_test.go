@@ -0,0 +1,139 @@
+package services_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/princecee/lema-ai/config"
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/internal/db/repositories"
+	"github.com/princecee/lema-ai/internal/services"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type ExportServiceTestSuite struct {
+	suite.Suite
+	db *gorm.DB
+}
+
+func (s *ExportServiceTestSuite) SetupSuite() {
+	cfg := config.NewConfig("test", "silent")
+	cfg.DSN = "file:export_service?mode=memory&cache=shared"
+	db := database.GetDBConn(cfg.DSN, cfg.REPLICA_DSNS, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
+
+	err := database.Migrate(db)
+	if err != nil {
+		s.Fail(err.Error())
+	}
+
+	s.db = db
+}
+
+func (s *ExportServiceTestSuite) TearDownSuite() {
+	s.NoError(database.Close(s.db))
+}
+
+func (s *ExportServiceTestSuite) newExportService(dir string, maxAttempts int) *services.ExportService {
+	jobQueue := services.NewJobQueue(repositories.NewJobRepository(s.db), services.JobOptions{
+		Workers:      1,
+		PollInterval: 10 * time.Millisecond,
+		LockTimeout:  time.Minute,
+		MaxAttempts:  maxAttempts,
+		BackoffBase:  time.Millisecond,
+		BackoffMax:   time.Millisecond,
+	}, zerolog.Nop())
+	exportService := services.NewExportService(database.NewTransactor(s.db), repositories.NewExportRepository(s.db), jobQueue, services.ExportOptions{
+		Dir:             dir,
+		TTL:             time.Hour,
+		CleanupInterval: time.Minute,
+	}, zerolog.Nop())
+	jobQueue.Register(services.ExportJobKind, exportService.RunExportJob)
+
+	s.Require().NoError(exportService.Start(context.Background()))
+	jobQueue.Start(context.Background())
+	s.T().Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.NoError(jobQueue.Shutdown(ctx))
+		s.NoError(exportService.Shutdown(ctx))
+	})
+
+	return exportService
+}
+
+func (s *ExportServiceTestSuite) TestExportService() {
+	t := s.T()
+	dir := t.TempDir()
+
+	past := time.Now().UTC().Add(-time.Minute)
+	expired := &models.Export{
+		ID: uuid.NewString(), Entity: models.ExportEntityUsers, Format: models.ExportFormatCSV,
+		Status: models.ExportStatusCompleted, ExpiresAt: &past,
+	}
+	s.Require().NoError(s.db.Create(expired).Error)
+
+	expiredPath := filepath.Join(dir, expired.ID+".csv.gz")
+	s.Require().NoError(os.WriteFile(expiredPath, []byte("stale"), 0o644))
+
+	exportService := s.newExportService(dir, 3)
+
+	t.Run("Delete expired exports and their files", func(t *testing.T) {
+		s.Eventually(func() bool {
+			_, err := os.Stat(expiredPath)
+			return os.IsNotExist(err)
+		}, 5*time.Second, 10*time.Millisecond)
+
+		s.Eventually(func() bool {
+			_, err := exportService.GetExport(context.Background(), expired.ID)
+			return err != nil
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Write exports through the job queue", func(t *testing.T) {
+		export := &models.Export{Entity: models.ExportEntityPosts, Format: models.ExportFormatNDJSON}
+		s.Require().NoError(exportService.CreateExport(context.Background(), export))
+
+		s.Eventually(func() bool {
+			e, err := exportService.GetExport(context.Background(), export.ID)
+			return err == nil && e.Status == models.ExportStatusCompleted
+		}, 5*time.Second, 10*time.Millisecond)
+
+		_, f, err := exportService.OpenExport(context.Background(), export.ID)
+		s.Require().NoError(err)
+		f.Close()
+	})
+}
+
+func (s *ExportServiceTestSuite) TestFailedExport() {
+	// Files cannot be created in a directory that is removed after Start.
+	dir := filepath.Join(s.T().TempDir(), "exports")
+	exportService := s.newExportService(dir, 2)
+	s.Require().NoError(os.RemoveAll(dir))
+
+	export := &models.Export{Entity: models.ExportEntityUsers, Format: models.ExportFormatCSV}
+	s.Require().NoError(exportService.CreateExport(context.Background(), export))
+
+	s.Eventually(func() bool {
+		e, err := exportService.GetExport(context.Background(), export.ID)
+		return err == nil && e.Status == models.ExportStatusFailed
+	}, 5*time.Second, 10*time.Millisecond)
+
+	var job models.Job
+	s.Eventually(func() bool {
+		err := s.db.Where("kind = ? AND payload LIKE ?", services.ExportJobKind, "%"+export.ID+"%").First(&job).Error
+		return err == nil && job.Status == models.JobStatusDead
+	}, 5*time.Second, 10*time.Millisecond)
+	s.Equal(2, job.Attempts)
+	s.NotEmpty(job.LastError)
+}
+
+func TestExportService(t *testing.T) {
+	suite.Run(t, new(ExportServiceTestSuite))
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/princecee/lema-ai/internal/db/models"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/rs/zerolog"
+)
+
+// Topics hub clients can subscribe to. Besides these, "user:{id}" carries
+// the events of a user and "user:{id}:posts" those of the user's posts.
+const (
+	TopicPosts = "posts:all"
+	TopicUsers = "users:all"
+)
+
+// hubStreamBuffer is the number of events the hub may lag behind the event
+// stream before it is dropped and resubscribes.
+const hubStreamBuffer = 256
+
+// HubMessage is the frame sent to a client for an event on one of its
+// topics.
+type HubMessage struct {
+	Type  string          `json:"type"`
+	Topic string          `json:"topic"`
+	ID    string          `json:"id"`
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// HubOptions configures the hub.
+type HubOptions struct {
+	// SendBuffer is the number of frames a client may lag behind before it
+	// is dropped.
+	SendBuffer int
+}
+
+type hubClient struct {
+	send   chan []byte
+	topics map[string]struct{}
+}
+
+type hubSubscription struct {
+	client    <-chan []byte
+	topic     string
+	subscribe bool
+}
+
+// Hub fans the events of an EventStream out to connected clients by
+// topic. Clients are identified by the channel Connect returns, which
+// carries their frames. State is only touched by the hub goroutine;
+// the other methods hand their work to it.
+type Hub struct {
+	stream  *EventStream
+	opts    HubOptions
+	logger  zerolog.Logger
+	connect chan *hubClient
+	leave   chan (<-chan []byte)
+	subs    chan hubSubscription
+	done    chan struct{}
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+
+	clients map[<-chan []byte]*hubClient
+	topics  map[string]map[*hubClient]struct{}
+}
+
+func NewHub(stream *EventStream, opts HubOptions, l zerolog.Logger) *Hub {
+	opts.SendBuffer = max(opts.SendBuffer, 1)
+	return &Hub{
+		stream:  stream,
+		opts:    opts,
+		logger:  l,
+		connect: make(chan *hubClient),
+		leave:   make(chan (<-chan []byte)),
+		subs:    make(chan hubSubscription),
+		done:    make(chan struct{}),
+		clients: map[<-chan []byte]*hubClient{},
+		topics:  map[string]map[*hubClient]struct{}{},
+	}
+}
+
+// Start launches the hub goroutine. It stops when ctx is done, Shutdown is
+// called or the event stream is closed, disconnecting every client.
+func (h *Hub) Start(ctx context.Context) {
+	ctx, h.cancel = context.WithCancel(ctx)
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		defer close(h.done)
+		h.run(ctx)
+	}()
+}
+
+// Shutdown stops the hub goroutine.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	if h.cancel == nil {
+		return nil
+	}
+	h.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Connect adds a client and returns the channel of its frames. The channel
+// is closed when the client is disconnected, dropped for lagging behind or
+// the hub stops.
+func (h *Hub) Connect() <-chan []byte {
+	c := &hubClient{send: make(chan []byte, h.opts.SendBuffer), topics: map[string]struct{}{}}
+	select {
+	case h.connect <- c:
+	case <-h.done:
+		close(c.send)
+	}
+	return c.send
+}
+
+// Disconnect removes a client.
+func (h *Hub) Disconnect(client <-chan []byte) {
+	select {
+	case h.leave <- client:
+	case <-h.done:
+	}
+}
+
+// Subscribe sends the client the events of topic.
+func (h *Hub) Subscribe(client <-chan []byte, topic string) error {
+	if !validTopic(topic) {
+		return apperror.InvalidParameter("topic", "Unknown topic {0}", topic)
+	}
+	select {
+	case h.subs <- hubSubscription{client, topic, true}:
+	case <-h.done:
+	}
+	return nil
+}
+
+// Unsubscribe stops sending the client the events of topic.
+func (h *Hub) Unsubscribe(client <-chan []byte, topic string) {
+	select {
+	case h.subs <- hubSubscription{client, topic, false}:
+	case <-h.done:
+	}
+}
+
+func (h *Hub) run(ctx context.Context) {
+	_, events, cancel := h.stream.Subscribe("", hubStreamBuffer)
+	defer func() { cancel() }()
+	defer h.removeAll()
+
+	var lastEventId string
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case c := <-h.connect:
+			h.clients[c.send] = c
+		case client := <-h.leave:
+			if c, ok := h.clients[client]; ok {
+				h.remove(c)
+			}
+		case s := <-h.subs:
+			c, ok := h.clients[s.client]
+			if !ok {
+				continue
+			}
+			if s.subscribe {
+				h.subscribe(c, s.topic)
+			} else {
+				h.unsubscribe(c, s.topic)
+			}
+		case e, ok := <-events:
+			if ok {
+				lastEventId = e.ID
+				h.broadcast(e)
+				continue
+			}
+
+			select {
+			case <-h.stream.Done():
+				return
+			default:
+			}
+			// The hub lagged behind the stream; catch up from the last
+			// event it sent.
+			h.logger.Warn().Msg("hub fell behind the event stream")
+			cancel()
+			var missed []*models.OutboxEvent
+			missed, events, cancel = h.stream.Subscribe(lastEventId, hubStreamBuffer)
+			for _, e := range missed {
+				lastEventId = e.ID
+				h.broadcast(e)
+			}
+		}
+	}
+}
+
+func (h *Hub) subscribe(c *hubClient, topic string) {
+	c.topics[topic] = struct{}{}
+	if h.topics[topic] == nil {
+		h.topics[topic] = map[*hubClient]struct{}{}
+	}
+	h.topics[topic][c] = struct{}{}
+}
+
+func (h *Hub) unsubscribe(c *hubClient, topic string) {
+	delete(c.topics, topic)
+	delete(h.topics[topic], c)
+	if len(h.topics[topic]) == 0 {
+		delete(h.topics, topic)
+	}
+}
+
+func (h *Hub) remove(c *hubClient) {
+	for topic := range c.topics {
+		h.unsubscribe(c, topic)
+	}
+	delete(h.clients, c.send)
+	close(c.send)
+}
+
+func (h *Hub) removeAll() {
+	for _, c := range h.clients {
+		h.remove(c)
+	}
+}
+
+// broadcast sends an event to the clients of its topics. A client whose
+// buffer is full is dropped rather than holding back the others.
+func (h *Hub) broadcast(e *models.OutboxEvent) {
+	for _, topic := range eventTopics(e) {
+		clients := h.topics[topic]
+		if len(clients) == 0 {
+			continue
+		}
+
+		frame, err := json.Marshal(HubMessage{Type: "event", Topic: topic, ID: e.ID, Event: e.Event, Data: json.RawMessage(e.Payload)})
+		if err != nil {
+			h.logger.Error().Err(err).Str("event_id", e.ID).Msg("failed to encode hub message")
+			return
+		}
+		for c := range clients {
+			select {
+			case c.send <- frame:
+			default:
+				h.logger.Debug().Msg("dropped slow hub client")
+				h.remove(c)
+			}
+		}
+	}
+}
+
+// eventTopics returns the topics an event is sent on.
+func eventTopics(e *models.OutboxEvent) []string {
+	var data struct {
+		ID     string `json:"id"`
+		UserID string `json:"user_id"`
+	}
+	if err := json.Unmarshal([]byte(e.Payload), &data); err != nil {
+		return nil
+	}
+
+	switch {
+	case strings.HasPrefix(e.Event, "post."):
+		return []string{TopicPosts, "user:" + data.UserID + ":posts"}
+	case strings.HasPrefix(e.Event, "user."):
+		return []string{TopicUsers, "user:" + data.ID}
+	}
+	return nil
+}
+
+func validTopic(topic string) bool {
+	if topic == TopicPosts || topic == TopicUsers {
+		return true
+	}
+
+	rest, ok := strings.CutPrefix(topic, "user:")
+	if !ok {
+		return false
+	}
+	rest = strings.TrimSuffix(rest, ":posts")
+	return uuid.Validate(rest) == nil
+}
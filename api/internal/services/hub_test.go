@@ -0,0 +1,145 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/internal/services"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+)
+
+type HubTestSuite struct {
+	suite.Suite
+	stream *services.EventStream
+	hub    *services.Hub
+}
+
+func (s *HubTestSuite) SetupTest() {
+	s.stream = services.NewEventStream(10)
+	s.hub = services.NewHub(s.stream, services.HubOptions{SendBuffer: 2}, zerolog.Nop())
+	s.hub.Start(context.Background())
+}
+
+func (s *HubTestSuite) TearDownTest() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.NoError(s.hub.Shutdown(ctx))
+}
+
+func (s *HubTestSuite) publishPost(userId string) *models.OutboxEvent {
+	e := &models.OutboxEvent{
+		ID:      uuid.NewString(),
+		Event:   models.EventPostCreated,
+		Payload: `{"id":"` + uuid.NewString() + `","user_id":"` + userId + `"}`,
+	}
+	s.Require().NoError(s.stream.Publish(context.Background(), e))
+	return e
+}
+
+// receive returns the next message of a client, or fails when none comes
+// or the client was disconnected.
+func (s *HubTestSuite) receive(client <-chan []byte) services.HubMessage {
+	var msg services.HubMessage
+	select {
+	case frame, ok := <-client:
+		s.Require().True(ok, "client disconnected")
+		s.Require().NoError(json.Unmarshal(frame, &msg))
+	case <-time.After(5 * time.Second):
+		s.FailNow("no message received")
+	}
+	return msg
+}
+
+// closed reports whether a client was disconnected once its pending
+// messages are read.
+func (s *HubTestSuite) closed(client <-chan []byte) bool {
+	for {
+		select {
+		case _, ok := <-client:
+			if !ok {
+				return true
+			}
+		case <-time.After(5 * time.Second):
+			return false
+		}
+	}
+}
+
+func (s *HubTestSuite) TestTopics() {
+	userId := uuid.NewString()
+	own := s.hub.Connect()
+	all := s.hub.Connect()
+	s.Require().NoError(s.hub.Subscribe(own, "user:"+userId+":posts"))
+	s.Require().NoError(s.hub.Subscribe(all, services.TopicPosts))
+
+	other := s.publishPost(uuid.NewString())
+	mine := s.publishPost(userId)
+
+	msg := s.receive(own)
+	s.Equal("event", msg.Type)
+	s.Equal("user:"+userId+":posts", msg.Topic)
+	s.Equal(mine.ID, msg.ID)
+	s.Equal(models.EventPostCreated, msg.Event)
+	s.JSONEq(mine.Payload, string(msg.Data))
+
+	s.Equal(other.ID, s.receive(all).ID)
+	s.Equal(mine.ID, s.receive(all).ID)
+
+	s.hub.Unsubscribe(all, services.TopicPosts)
+	s.publishPost(userId)
+	s.receive(own)
+	s.Empty(all)
+}
+
+func (s *HubTestSuite) TestUnknownTopic() {
+	client := s.hub.Connect()
+	for _, topic := range []string{"posts", "user:1", "user:" + uuid.NewString() + ":comments"} {
+		err := s.hub.Subscribe(client, topic)
+		s.ErrorIs(err, apperror.ErrInvalidParameter, topic)
+	}
+	s.NoError(s.hub.Subscribe(client, "user:"+uuid.NewString()))
+}
+
+func (s *HubTestSuite) TestSlowClient() {
+	slow := s.hub.Connect()
+	fast := s.hub.Connect()
+	s.Require().NoError(s.hub.Subscribe(slow, services.TopicPosts))
+	s.Require().NoError(s.hub.Subscribe(fast, services.TopicPosts))
+
+	// The third event overflows the slow client, which is dropped; the
+	// fast one keeps up.
+	for range 3 {
+		s.publishPost(uuid.NewString())
+		s.receive(fast)
+	}
+	s.True(s.closed(slow))
+
+	s.publishPost(uuid.NewString())
+	s.receive(fast)
+}
+
+func (s *HubTestSuite) TestDisconnect() {
+	client := s.hub.Connect()
+	s.hub.Disconnect(client)
+	s.True(s.closed(client))
+}
+
+func (s *HubTestSuite) TestStreamClosed() {
+	// Closing the stream, as the server does on shutdown, disconnects
+	// every client.
+	client := s.hub.Connect()
+	s.stream.Close()
+	s.True(s.closed(client))
+
+	s.True(s.closed(s.hub.Connect()))
+}
+
+func TestHub(t *testing.T) {
+	suite.Run(t, new(HubTestSuite))
+}
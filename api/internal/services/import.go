@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/princecee/lema-ai/internal/db/models"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/i18n"
+	"github.com/princecee/lema-ai/pkg/importer"
+	"gorm.io/gorm"
+)
+
+// Transactor runs fn in a database transaction. Repository calls made with
+// the context passed to fn are part of the transaction.
+type Transactor interface {
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type ImportUserRepository interface {
+	CreateUser(ctx context.Context, u *models.User) error
+	UpdateUser(ctx context.Context, u *models.User) error
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	UserExists(ctx context.Context, userId string) (bool, error)
+}
+
+type ImportPostRepository interface {
+	CreatePost(ctx context.Context, p *models.Post) error
+	GetPost(ctx context.Context, postId string) (*models.Post, error)
+}
+
+type ImportService struct {
+	transactor Transactor
+	userRepo   ImportUserRepository
+	postRepo   ImportPostRepository
+}
+
+func NewImportService(transactor Transactor, userRepo ImportUserRepository, postRepo ImportPostRepository) *ImportService {
+	return &ImportService{transactor, userRepo, postRepo}
+}
+
+// errDryRun rolls back the transaction of a dry-run batch.
+var errDryRun = errors.New("dry run")
+
+// ImportUsers writes a batch of users in one transaction. Users are matched
+// by email: existing users are skipped, or updated when session.Upsert is
+// set.
+func (s *ImportService) ImportUsers(ctx context.Context, rows []importer.Row[*models.User], session *importer.Session) error {
+	return importBatch(ctx, s.transactor, rows, session, func(ctx context.Context, u *models.User) (importer.Result, error) {
+		existing, err := s.userRepo.GetUserByEmail(ctx, u.Email)
+		switch {
+		case err == nil:
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			existing = nil
+		default:
+			return importer.Result{}, apperror.ErrInternalServer
+		}
+
+		// In a dry run earlier batches were rolled back, so rows they
+		// would have created are only known to the session.
+		seenID, seen := session.Seen(u.Email)
+		if existing == nil && seen {
+			existing = &models.User{ID: seenID}
+		}
+
+		if existing != nil && !session.Upsert {
+			return importer.Result{
+				Status:  importer.StatusSkipped,
+				ID:      existing.ID,
+				Message: i18n.T(i18n.LocaleFromContext(ctx), "A user with this email already exists"),
+			}, nil
+		}
+
+		if existing != nil {
+			u.ID = existing.ID
+			u.Address.ID = existing.Address.ID
+			u.Address.UserID = existing.ID
+			if u.Address.ID == "" {
+				u.Address.ID = uuid.NewString()
+			}
+			if err := s.userRepo.UpdateUser(ctx, u); err != nil {
+				return importer.Result{}, userWriteError(err)
+			}
+			session.MarkSeen(u.Email, u.ID)
+			return importer.Result{Status: importer.StatusUpdated, ID: u.ID}, nil
+		}
+
+		u.ID = uuid.NewString()
+		u.Address.ID = uuid.NewString()
+		if err := s.userRepo.CreateUser(ctx, u); err != nil {
+			return importer.Result{}, userWriteError(err)
+		}
+		session.MarkSeen(u.Email, u.ID)
+		return importer.Result{Status: importer.StatusCreated, ID: u.ID}, nil
+	})
+}
+
+// ImportPosts writes a batch of posts in one transaction. Posts that carry
+// the ID of an existing post are skipped.
+func (s *ImportService) ImportPosts(ctx context.Context, rows []importer.Row[*models.Post], session *importer.Session) error {
+	return importBatch(ctx, s.transactor, rows, session, func(ctx context.Context, p *models.Post) (importer.Result, error) {
+		if p.ID != "" {
+			_, err := s.postRepo.GetPost(ctx, p.ID)
+			_, seen := session.Seen(p.ID)
+			switch {
+			case err == nil || seen:
+				return importer.Result{
+					Status:  importer.StatusSkipped,
+					ID:      p.ID,
+					Message: i18n.T(i18n.LocaleFromContext(ctx), "A post with this ID already exists"),
+				}, nil
+			case !errors.Is(err, gorm.ErrRecordNotFound):
+				return importer.Result{}, apperror.ErrInternalServer
+			}
+		} else {
+			p.ID = uuid.NewString()
+		}
+
+		exists, err := s.userRepo.UserExists(ctx, p.UserID)
+		if err != nil {
+			return importer.Result{}, apperror.ErrInternalServer
+		}
+		if !exists {
+			const message = "User {0} does not exist"
+			return importer.Result{}, apperror.ErrUnprocessable.WithMessage(message, p.UserID).
+				WithViolations(apperror.NewViolation("user_id", "exists", message, p.UserID))
+		}
+
+		p.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+		if err := s.postRepo.CreatePost(ctx, p); err != nil {
+			return importer.Result{}, apperror.ErrInternalServer
+		}
+		session.MarkSeen(p.ID, p.ID)
+		return importer.Result{Status: importer.StatusCreated, ID: p.ID}, nil
+	})
+}
+
+// importBatch applies importRow to each row in one transaction. Each row
+// runs in a nested transaction, so a failed row is rolled back on its own
+// and reported without aborting the batch.
+func importBatch[T any](
+	ctx context.Context,
+	transactor Transactor,
+	rows []importer.Row[T],
+	session *importer.Session,
+	importRow func(ctx context.Context, value T) (importer.Result, error),
+) error {
+	type outcome struct {
+		line   int
+		result importer.Result
+		err    error
+	}
+	outcomes := make([]outcome, 0, len(rows))
+
+	err := transactor.Transaction(ctx, func(ctx context.Context) error {
+		for _, row := range rows {
+			var result importer.Result
+			err := transactor.Transaction(ctx, func(ctx context.Context) error {
+				var err error
+				result, err = importRow(ctx, row.Value)
+				return err
+			})
+			result.Row = row.Line
+			outcomes = append(outcomes, outcome{row.Line, result, err})
+		}
+
+		if session.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return apperror.ErrInternalServer
+	}
+
+	for _, o := range outcomes {
+		if o.err != nil {
+			session.Fail(ctx, o.line, o.err)
+			continue
+		}
+		session.Add(o.result)
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/rs/zerolog"
+)
+
+type JobRepository interface {
+	CreateJob(ctx context.Context, j *models.Job) error
+	ClaimJob(ctx context.Context, now time.Time, lockTimeout time.Duration) (*models.Job, error)
+	CompleteJob(ctx context.Context, j *models.Job, now time.Time) error
+	RetryJob(ctx context.Context, j *models.Job, runAt time.Time, lastError string) error
+	ReleaseJob(ctx context.Context, j *models.Job) error
+	BuryJob(ctx context.Context, j *models.Job, lastError string) error
+	RenewJob(ctx context.Context, j *models.Job, now time.Time) error
+}
+
+// JobHandler runs a job. Returning an error schedules a retry, or moves the
+// job to the dead-letter state once job.MaxAttempts is reached. Handlers
+// must return when ctx is cancelled; the job is then queued again without
+// counting the attempt.
+type JobHandler func(ctx context.Context, job *models.Job) error
+
+// defaultPollInterval is the poll interval of the job queue and the outbox
+// relay when none is set.
+const defaultPollInterval = time.Second
+
+// JobOptions configures the job queue.
+type JobOptions struct {
+	// Workers is the number of jobs run concurrently.
+	Workers int
+	// PollInterval is how often idle workers look for due jobs. It
+	// defaults to defaultPollInterval.
+	PollInterval time.Duration
+	// LockTimeout is how long a job may go without its lock being renewed
+	// before it is assumed lost and claimed again. Running jobs renew it
+	// every third of LockTimeout.
+	LockTimeout time.Duration
+	// MaxAttempts is the default number of attempts of a job.
+	MaxAttempts int
+	// BackoffBase is the delay before the first retry. Each later retry
+	// doubles it, up to BackoffMax.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+}
+
+// JobQueue runs jobs persisted in the database with a pool of workers.
+type JobQueue struct {
+	jobRepo  JobRepository
+	opts     JobOptions
+	logger   zerolog.Logger
+	handlers map[string]JobHandler
+	wake     chan struct{}
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func NewJobQueue(jobRepo JobRepository, opts JobOptions, l zerolog.Logger) *JobQueue {
+	opts.Workers = max(opts.Workers, 1)
+	opts.MaxAttempts = max(opts.MaxAttempts, 1)
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+	return &JobQueue{
+		jobRepo:  jobRepo,
+		opts:     opts,
+		logger:   l,
+		handlers: map[string]JobHandler{},
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Register sets the handler of a kind of job. It must be called before
+// Start.
+func (q *JobQueue) Register(kind string, h JobHandler) {
+	q.handlers[kind] = h
+}
+
+// Enqueue stores a job that runs as soon as a worker is free. The payload
+// is encoded as JSON. Called with a transaction context, the job is only
+// run if the transaction commits.
+func (q *JobQueue) Enqueue(ctx context.Context, kind string, payload any) (*models.Job, error) {
+	return q.EnqueueAt(ctx, kind, payload, time.Now().UTC())
+}
+
+// EnqueueAt stores a job that runs no earlier than runAt.
+func (q *JobQueue) EnqueueAt(ctx context.Context, kind string, payload any, runAt time.Time) (*models.Job, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.Job{
+		ID:          uuid.NewString(),
+		Kind:        kind,
+		Payload:     string(b),
+		Status:      models.JobStatusQueued,
+		MaxAttempts: q.opts.MaxAttempts,
+		RunAt:       runAt.UTC(),
+	}
+	if err := q.jobRepo.CreateJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// Start launches the workers.
+func (q *JobQueue) Start(ctx context.Context) {
+	ctx, q.cancel = context.WithCancel(ctx)
+	for range q.opts.Workers {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			q.work(ctx)
+		}()
+	}
+}
+
+// Shutdown stops the workers and waits for running jobs to return.
+func (q *JobQueue) Shutdown(ctx context.Context) error {
+	if q.cancel == nil {
+		return nil
+	}
+	q.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *JobQueue) work(ctx context.Context) {
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the queue before waiting again.
+		for ctx.Err() == nil && q.runNext(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-q.wake:
+		}
+	}
+}
+
+// runNext claims and runs one job. It reports whether a job was found.
+func (q *JobQueue) runNext(ctx context.Context) bool {
+	job, err := q.jobRepo.ClaimJob(ctx, time.Now().UTC(), q.opts.LockTimeout)
+	if err != nil {
+		if ctx.Err() == nil {
+			q.logger.Error().Err(err).Msg("failed to claim job")
+		}
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	// Bookkeeping must land even when the workers are being stopped.
+	saveCtx := context.WithoutCancel(ctx)
+	log := q.logger.With().Str("job_id", job.ID).Str("kind", job.Kind).Int("attempt", job.Attempts).Logger()
+
+	handler, ok := q.handlers[job.Kind]
+	if !ok {
+		q.bury(saveCtx, log, job, fmt.Errorf("no handler for job kind %q", job.Kind))
+		return true
+	}
+	if job.Attempts > job.MaxAttempts {
+		// The job was reclaimed after its last attempt lost its lock.
+		q.bury(saveCtx, log, job, errors.New("lock expired on the last attempt"))
+		return true
+	}
+
+	jobCtx, stop := context.WithCancelCause(ctx)
+	renewed := q.keepLocked(jobCtx, stop, log, job)
+	err = runJob(jobCtx, handler, job)
+	stop(nil)
+	<-renewed
+	if errors.Is(context.Cause(jobCtx), models.ErrJobLockLost) {
+		// Another worker claimed the job again and owns its outcome.
+		log.Warn().Msg("job lock lost, abandoning attempt")
+		return true
+	}
+
+	switch {
+	case err == nil:
+		if err := q.jobRepo.CompleteJob(saveCtx, job, time.Now().UTC()); err != nil {
+			log.Error().Err(err).Msg("failed to complete job")
+		}
+	case ctx.Err() != nil:
+		if err := q.jobRepo.ReleaseJob(saveCtx, job); err != nil {
+			log.Error().Err(err).Msg("failed to release job")
+		}
+	case job.Attempts >= job.MaxAttempts:
+		q.bury(saveCtx, log, job, err)
+	default:
+		runAt := time.Now().UTC().Add(q.backoff(job.Attempts))
+		log.Warn().Err(err).Time("run_at", runAt).Msg("job failed, retrying")
+		if err := q.jobRepo.RetryJob(saveCtx, job, runAt, err.Error()); err != nil {
+			log.Error().Err(err).Msg("failed to retry job")
+		}
+	}
+	return true
+}
+
+func (q *JobQueue) bury(ctx context.Context, log zerolog.Logger, job *models.Job, cause error) {
+	log.Error().Err(cause).Msg("job failed permanently")
+	if err := q.jobRepo.BuryJob(ctx, job, cause.Error()); err != nil {
+		log.Error().Err(err).Msg("failed to bury job")
+	}
+}
+
+// keepLocked renews the lock of job every third of LockTimeout until ctx
+// is done, so jobs running longer than LockTimeout, such as large exports,
+// are not claimed again while they run. If the job was claimed again
+// anyway, e.g. after renewals failed for a whole LockTimeout, ctx is
+// cancelled with models.ErrJobLockLost. The returned channel is closed once
+// renewals have stopped.
+func (q *JobQueue) keepLocked(ctx context.Context, cancel context.CancelCauseFunc, log zerolog.Logger, job *models.Job) <-chan struct{} {
+	done := make(chan struct{})
+	interval := q.opts.LockTimeout / 3
+	if interval <= 0 {
+		close(done)
+		return done
+	}
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			err := q.jobRepo.RenewJob(ctx, job, time.Now().UTC())
+			if errors.Is(err, models.ErrJobLockLost) {
+				cancel(err)
+				return
+			}
+			if err != nil && ctx.Err() == nil {
+				log.Error().Err(err).Msg("failed to renew job lock")
+			}
+		}
+	}()
+	return done
+}
+
+// backoff returns the delay before retrying a job that failed attempt
+// times: BackoffBase, doubled after every attempt, capped at BackoffMax.
+func (q *JobQueue) backoff(attempt int) time.Duration {
+	delay := q.opts.BackoffBase
+	for i := 1; i < attempt && delay < q.opts.BackoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, q.opts.BackoffMax)
+}
+
+// runJob calls handler, turning a panic into an error so one bad job does
+// not take the worker down.
+func runJob(ctx context.Context, handler JobHandler, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
@@ -0,0 +1,294 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/princecee/lema-ai/config"
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/internal/db/repositories"
+	"github.com/princecee/lema-ai/internal/services"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type JobQueueTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	jobRepo *repositories.JobRepository
+}
+
+func (s *JobQueueTestSuite) SetupSuite() {
+	cfg := config.NewConfig("test", "silent")
+	cfg.DSN = "file:jobs?mode=memory&cache=shared"
+	db := database.GetDBConn(cfg.DSN, cfg.REPLICA_DSNS, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
+
+	err := db.AutoMigrate(&models.Job{})
+	if err != nil {
+		s.Fail(err.Error())
+	}
+
+	s.db = db
+	s.jobRepo = repositories.NewJobRepository(db)
+}
+
+// SetupTest empties the queue so jobs left by one test are not claimed by
+// the workers of the next.
+func (s *JobQueueTestSuite) SetupTest() {
+	s.Require().NoError(s.db.Where("1 = 1").Delete(&models.Job{}).Error)
+}
+
+func (s *JobQueueTestSuite) TearDownSuite() {
+	s.NoError(database.Close(s.db))
+}
+
+func (s *JobQueueTestSuite) newJobQueue(workers, maxAttempts int) *services.JobQueue {
+	return services.NewJobQueue(s.jobRepo, services.JobOptions{
+		Workers:      workers,
+		PollInterval: 10 * time.Millisecond,
+		LockTimeout:  time.Minute,
+		MaxAttempts:  maxAttempts,
+		BackoffBase:  time.Millisecond,
+		BackoffMax:   5 * time.Millisecond,
+	}, zerolog.Nop())
+}
+
+func (s *JobQueueTestSuite) start(q *services.JobQueue) {
+	q.Start(context.Background())
+	s.T().Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.NoError(q.Shutdown(ctx))
+	})
+}
+
+// waitForStatus waits until a job reaches status and returns it.
+func (s *JobQueueTestSuite) waitForStatus(jobId, status string) *models.Job {
+	var job *models.Job
+	s.Require().Eventually(func() bool {
+		var err error
+		job, err = s.jobRepo.GetJob(context.Background(), jobId)
+		return err == nil && job.Status == status
+	}, 5*time.Second, 5*time.Millisecond)
+	return job
+}
+
+func (s *JobQueueTestSuite) TestRunJob() {
+	q := s.newJobQueue(1, 3)
+	payloads := make(chan string, 1)
+	q.Register("greet", func(ctx context.Context, job *models.Job) error {
+		var payload struct{ Name string }
+		if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+			return err
+		}
+		payloads <- payload.Name
+		return nil
+	})
+	s.start(q)
+
+	job, err := q.Enqueue(context.Background(), "greet", map[string]string{"name": "jane"})
+	s.Require().NoError(err)
+
+	s.Equal("jane", <-payloads)
+	job = s.waitForStatus(job.ID, models.JobStatusSucceeded)
+	s.Equal(1, job.Attempts)
+	s.NotNil(job.CompletedAt)
+	s.Nil(job.LockedAt)
+}
+
+func (s *JobQueueTestSuite) TestRetryWithBackoff() {
+	q := s.newJobQueue(1, 5)
+	var attempts atomic.Int32
+	q.Register("flaky", func(ctx context.Context, job *models.Job) error {
+		if attempts.Add(1) < 3 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+	s.start(q)
+
+	job, err := q.Enqueue(context.Background(), "flaky", nil)
+	s.Require().NoError(err)
+
+	job = s.waitForStatus(job.ID, models.JobStatusSucceeded)
+	s.Equal(3, job.Attempts)
+	s.Equal("temporary failure", job.LastError)
+	s.True(job.RunAt.After(job.CreatedAt))
+}
+
+func (s *JobQueueTestSuite) TestDeadLetter() {
+	q := s.newJobQueue(1, 2)
+	q.Register("broken", func(ctx context.Context, job *models.Job) error {
+		panic("boom")
+	})
+	s.start(q)
+
+	broken, err := q.Enqueue(context.Background(), "broken", nil)
+	s.Require().NoError(err)
+	unknown, err := q.Enqueue(context.Background(), "unknown", nil)
+	s.Require().NoError(err)
+
+	job := s.waitForStatus(broken.ID, models.JobStatusDead)
+	s.Equal(2, job.Attempts)
+	s.Equal("job panicked: boom", job.LastError)
+
+	job = s.waitForStatus(unknown.ID, models.JobStatusDead)
+	s.Equal(1, job.Attempts)
+	s.Contains(job.LastError, "no handler")
+}
+
+func (s *JobQueueTestSuite) TestScheduledJob() {
+	q := s.newJobQueue(1, 1)
+	q.Register("later", func(ctx context.Context, job *models.Job) error { return nil })
+	s.start(q)
+
+	runAt := time.Now().Add(100 * time.Millisecond)
+	job, err := q.EnqueueAt(context.Background(), "later", nil, runAt)
+	s.Require().NoError(err)
+
+	job = s.waitForStatus(job.ID, models.JobStatusSucceeded)
+	s.False(job.CompletedAt.Before(runAt.UTC()))
+}
+
+func (s *JobQueueTestSuite) TestReclaimExpiredLock() {
+	lockedAt := time.Now().UTC().Add(-2 * time.Minute)
+	job := &models.Job{
+		ID: uuid.NewString(), Kind: "orphan", Status: models.JobStatusRunning,
+		Attempts: 1, MaxAttempts: 3, RunAt: lockedAt, LockedAt: &lockedAt,
+	}
+	s.Require().NoError(s.jobRepo.CreateJob(context.Background(), job))
+
+	q := s.newJobQueue(1, 3)
+	q.Register("orphan", func(ctx context.Context, job *models.Job) error { return nil })
+	s.start(q)
+
+	job = s.waitForStatus(job.ID, models.JobStatusSucceeded)
+	s.Equal(2, job.Attempts)
+}
+
+func (s *JobQueueTestSuite) TestRenewLockOfLongJob() {
+	q := services.NewJobQueue(s.jobRepo, services.JobOptions{
+		Workers:      2,
+		PollInterval: 10 * time.Millisecond,
+		LockTimeout:  60 * time.Millisecond,
+		MaxAttempts:  3,
+	}, zerolog.Nop())
+	var runs atomic.Int32
+	q.Register("long", func(ctx context.Context, job *models.Job) error {
+		runs.Add(1)
+		time.Sleep(300 * time.Millisecond)
+		return nil
+	})
+	s.start(q)
+
+	job, err := q.Enqueue(context.Background(), "long", nil)
+	s.Require().NoError(err)
+
+	job = s.waitForStatus(job.ID, models.JobStatusSucceeded)
+	s.Equal(1, job.Attempts)
+	s.Equal(int32(1), runs.Load())
+}
+
+func (s *JobQueueTestSuite) TestLostLockIsNotOverwritten() {
+	ctx := context.Background()
+	q := s.newJobQueue(1, 3)
+	job, err := q.Enqueue(ctx, "orphan", nil)
+	s.Require().NoError(err)
+
+	now := time.Now().UTC()
+	first, err := s.jobRepo.ClaimJob(ctx, now, time.Minute)
+	s.Require().NoError(err)
+	s.Require().NotNil(first)
+	second, err := s.jobRepo.ClaimJob(ctx, now.Add(2*time.Minute), time.Minute)
+	s.Require().NoError(err)
+	s.Require().NotNil(second)
+
+	s.ErrorIs(s.jobRepo.CompleteJob(ctx, first, now), models.ErrJobLockLost)
+	s.ErrorIs(s.jobRepo.RenewJob(ctx, first, now), models.ErrJobLockLost)
+
+	job, err = s.jobRepo.GetJob(ctx, job.ID)
+	s.Require().NoError(err)
+	s.Equal(models.JobStatusRunning, job.Status)
+	s.Equal(2, job.Attempts)
+	s.NoError(s.jobRepo.CompleteJob(ctx, second, now))
+}
+
+func (s *JobQueueTestSuite) TestEachJobRunsOnce() {
+	q := s.newJobQueue(4, 1)
+	var mu sync.Mutex
+	runs := map[string]int{}
+	q.Register("count", func(ctx context.Context, job *models.Job) error {
+		mu.Lock()
+		defer mu.Unlock()
+		runs[job.ID]++
+		return nil
+	})
+
+	var ids []string
+	for range 20 {
+		job, err := q.Enqueue(context.Background(), "count", nil)
+		s.Require().NoError(err)
+		ids = append(ids, job.ID)
+	}
+	s.start(q)
+
+	for _, id := range ids {
+		s.waitForStatus(id, models.JobStatusSucceeded)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, id := range ids {
+		s.Equal(1, runs[id])
+	}
+}
+
+func (s *JobQueueTestSuite) TestShutdownReleasesRunningJobs() {
+	q := s.newJobQueue(1, 1)
+	started := make(chan struct{})
+	q.Register("slow", func(ctx context.Context, job *models.Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	q.Start(context.Background())
+
+	job, err := q.Enqueue(context.Background(), "slow", nil)
+	s.Require().NoError(err)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.Require().NoError(q.Shutdown(ctx))
+
+	job, err = s.jobRepo.GetJob(context.Background(), job.ID)
+	s.Require().NoError(err)
+	s.Equal(models.JobStatusQueued, job.Status)
+	s.Equal(0, job.Attempts)
+}
+
+func (s *JobQueueTestSuite) TestZeroPollInterval() {
+	q := services.NewJobQueue(s.jobRepo, services.JobOptions{}, zerolog.Nop())
+	done := make(chan struct{})
+	q.Register("noop", func(ctx context.Context, job *models.Job) error {
+		close(done)
+		return nil
+	})
+	s.start(q)
+
+	_, err := q.Enqueue(context.Background(), "noop", nil)
+	s.Require().NoError(err)
+	<-done
+}
+
+func TestJobQueue(t *testing.T) {
+	suite.Run(t, new(JobQueueTestSuite))
+}
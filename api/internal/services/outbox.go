@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/pkg/broker"
+	"github.com/rs/zerolog"
+)
+
+type OutboxRepository interface {
+	GetPendingEvents(ctx context.Context, limit int) ([]*models.OutboxEvent, error)
+	MarkDispatched(ctx context.Context, eventId string, now time.Time) error
+	RecordFailure(ctx context.Context, eventId string, lastError string) error
+	DeleteDispatchedEvents(ctx context.Context, before time.Time) error
+}
+
+// Sink publishes outbox events to a destination. Delivery is at least
+// once: an event is published again when any sink failed it, or when the
+// process stops before the event is marked dispatched.
+type Sink interface {
+	Publish(ctx context.Context, e *models.OutboxEvent) error
+}
+
+// eventMessage is the JSON encoding of an outbox event sent to sinks. ID
+// stays the same when an event is published again, so consumers can
+// deduplicate.
+type eventMessage struct {
+	ID        string          `json:"id"`
+	Event     string          `json:"event"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+func encodeEvent(e *models.OutboxEvent) ([]byte, error) {
+	return json.Marshal(eventMessage{ID: e.ID, Event: e.Event, CreatedAt: e.CreatedAt, Data: json.RawMessage(e.Payload)})
+}
+
+// LogSink writes events to a logger.
+type LogSink struct {
+	logger zerolog.Logger
+}
+
+func NewLogSink(l zerolog.Logger) *LogSink {
+	return &LogSink{l}
+}
+
+func (s *LogSink) Publish(ctx context.Context, e *models.OutboxEvent) error {
+	s.logger.Info().Str("event_id", e.ID).Str("event", e.Event).RawJSON("data", []byte(e.Payload)).Msg("event published")
+	return nil
+}
+
+// BrokerSink publishes events to a message broker, using the event name as
+// the subject.
+type BrokerSink struct {
+	broker broker.Broker
+}
+
+func NewBrokerSink(b broker.Broker) *BrokerSink {
+	return &BrokerSink{b}
+}
+
+func (s *BrokerSink) Publish(ctx context.Context, e *models.OutboxEvent) error {
+	data, err := encodeEvent(e)
+	if err != nil {
+		return err
+	}
+	return s.broker.Publish(ctx, e.Event, data)
+}
+
+// OutboxOptions configures the outbox relay.
+type OutboxOptions struct {
+	// PollInterval is how often the outbox is read. It defaults to
+	// defaultPollInterval.
+	PollInterval time.Duration
+	// BatchSize is the number of events read at a time.
+	BatchSize int
+	// Retention is how long dispatched events are kept.
+	Retention time.Duration
+}
+
+// OutboxRelay publishes the events of the outbox to sinks, oldest first,
+// and marks them dispatched. An event that fails holds back later ones so
+// consumers see events in order; it is retried on the next poll.
+type OutboxRelay struct {
+	outboxRepo OutboxRepository
+	sinks      map[string]Sink
+	opts       OutboxOptions
+	logger     zerolog.Logger
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// NewOutboxRelay returns a relay publishing to sinks, keyed by a name used
+// in logs and errors.
+func NewOutboxRelay(outboxRepo OutboxRepository, sinks map[string]Sink, opts OutboxOptions, l zerolog.Logger) *OutboxRelay {
+	opts.BatchSize = max(opts.BatchSize, 1)
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+	return &OutboxRelay{
+		outboxRepo: outboxRepo,
+		sinks:      sinks,
+		opts:       opts,
+		logger:     l,
+	}
+}
+
+// Start launches the relay loop.
+func (r *OutboxRelay) Start(ctx context.Context) {
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(r.opts.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			// Keep reading while full batches come back.
+			for ctx.Err() == nil && r.relay(ctx) {
+			}
+			r.cleanup(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Shutdown stops the relay loop. Events left in the outbox are published
+// after the next start.
+func (r *OutboxRelay) Shutdown(ctx context.Context) error {
+	if r.cancel == nil {
+		return nil
+	}
+	r.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// relay publishes one batch of events. It reports whether a full batch was
+// published, i.e. whether more events may be waiting.
+func (r *OutboxRelay) relay(ctx context.Context) bool {
+	events, err := r.outboxRepo.GetPendingEvents(ctx, r.opts.BatchSize)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.logger.Error().Err(err).Msg("failed to read outbox")
+		}
+		return false
+	}
+
+	// Bookkeeping must land even when the relay is being stopped.
+	saveCtx := context.WithoutCancel(ctx)
+	for _, e := range events {
+		if err := r.publish(ctx, e); err != nil {
+			if ctx.Err() != nil {
+				return false
+			}
+			r.logger.Warn().Err(err).Str("event_id", e.ID).Str("event", e.Event).Msg("failed to publish event")
+			if err := r.outboxRepo.RecordFailure(saveCtx, e.ID, err.Error()); err != nil {
+				r.logger.Error().Err(err).Str("event_id", e.ID).Msg("failed to record outbox failure")
+			}
+			return false
+		}
+
+		if err := r.outboxRepo.MarkDispatched(saveCtx, e.ID, time.Now().UTC()); err != nil {
+			r.logger.Error().Err(err).Str("event_id", e.ID).Msg("failed to mark event dispatched")
+			return false
+		}
+	}
+	return len(events) == r.opts.BatchSize
+}
+
+func (r *OutboxRelay) publish(ctx context.Context, e *models.OutboxEvent) error {
+	for name, sink := range r.sinks {
+		if err := sink.Publish(ctx, e); err != nil {
+			return fmt.Errorf("%s sink: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (r *OutboxRelay) cleanup(ctx context.Context) {
+	if r.opts.Retention <= 0 {
+		return
+	}
+	if err := r.outboxRepo.DeleteDispatchedEvents(ctx, time.Now().UTC().Add(-r.opts.Retention)); err != nil && ctx.Err() == nil {
+		r.logger.Error().Err(err).Msg("failed to delete dispatched events")
+	}
+}
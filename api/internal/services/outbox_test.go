@@ -0,0 +1,188 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/princecee/lema-ai/config"
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/internal/db/repositories"
+	"github.com/princecee/lema-ai/internal/services"
+	"github.com/princecee/lema-ai/pkg/broker"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// flakySink fails the first failures events it is given and records the
+// ones it accepts.
+type flakySink struct {
+	mu        sync.Mutex
+	failures  int
+	published []string
+}
+
+func (s *flakySink) Publish(ctx context.Context, e *models.OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("sink unavailable")
+	}
+	s.published = append(s.published, e.ID)
+	return nil
+}
+
+func (s *flakySink) events() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.published...)
+}
+
+type OutboxRelayTestSuite struct {
+	suite.Suite
+	db         *gorm.DB
+	outboxRepo *repositories.OutboxRepository
+	userRepo   *repositories.UserRepository
+	users      int
+}
+
+func (s *OutboxRelayTestSuite) SetupSuite() {
+	cfg := config.NewConfig("test", "silent")
+	cfg.DSN = "file:outbox?mode=memory&cache=shared"
+	db := database.GetDBConn(cfg.DSN, cfg.REPLICA_DSNS, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
+
+	err := db.AutoMigrate(&models.User{}, &models.Address{}, &models.OutboxEvent{})
+	if err != nil {
+		s.Fail(err.Error())
+	}
+
+	s.db = db
+	s.outboxRepo = repositories.NewOutboxRepository(db)
+	s.userRepo = repositories.NewUserRepository(db)
+}
+
+// SetupTest empties the outbox so events left by one test are not relayed
+// by the next.
+func (s *OutboxRelayTestSuite) SetupTest() {
+	s.Require().NoError(s.db.Where("1 = 1").Delete(&models.OutboxEvent{}).Error)
+}
+
+func (s *OutboxRelayTestSuite) TearDownSuite() {
+	s.NoError(database.Close(s.db))
+}
+
+func (s *OutboxRelayTestSuite) startRelay(sinks map[string]services.Sink) *services.OutboxRelay {
+	relay := services.NewOutboxRelay(s.outboxRepo, sinks, services.OutboxOptions{
+		PollInterval: 10 * time.Millisecond,
+		BatchSize:    10,
+	}, zerolog.Nop())
+	relay.Start(context.Background())
+	s.T().Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.NoError(relay.Shutdown(ctx))
+	})
+	return relay
+}
+
+// newUser returns a user whose unique fields differ from every other user
+// of the suite.
+func (s *OutboxRelayTestSuite) newUser() *models.User {
+	s.users++
+	id := uuid.NewString()
+	return &models.User{
+		ID:       id,
+		Name:     "Jane Doe",
+		Username: id,
+		Email:    id + "@example.com",
+		Phone:    fmt.Sprintf("+1555%07d", s.users),
+		Address:  models.Address{ID: uuid.NewString(), Street: "1 Main St", City: "Springfield", State: "IL", Zipcode: "62701"},
+	}
+}
+
+func (s *OutboxRelayTestSuite) createUser() *models.User {
+	user := s.newUser()
+	s.Require().NoError(s.userRepo.CreateUser(context.Background(), user))
+	return user
+}
+
+func (s *OutboxRelayTestSuite) TestBrokerSink() {
+	b := broker.NewMemoryBroker()
+	defer b.Close()
+	messages, unsubscribe := b.Subscribe(models.EventUserCreated, 1)
+	defer unsubscribe()
+
+	s.startRelay(map[string]services.Sink{"broker": services.NewBrokerSink(b)})
+	user := s.createUser()
+
+	var msg broker.Message
+	select {
+	case msg = <-messages:
+	case <-time.After(5 * time.Second):
+		s.FailNow("event not published")
+	}
+	s.Equal(models.EventUserCreated, msg.Subject)
+
+	var event struct {
+		ID    string      `json:"id"`
+		Event string      `json:"event"`
+		Data  models.User `json:"data"`
+	}
+	s.Require().NoError(json.Unmarshal(msg.Data, &event))
+	s.Equal(models.EventUserCreated, event.Event)
+	s.Equal(user.ID, event.Data.ID)
+
+	s.Eventually(func() bool {
+		var e models.OutboxEvent
+		err := s.db.Where("id = ?", event.ID).First(&e).Error
+		return err == nil && e.DispatchedAt != nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func (s *OutboxRelayTestSuite) TestFailingSink() {
+	first := s.createUser()
+	s.createUser()
+
+	var events []*models.OutboxEvent
+	s.Require().NoError(s.db.Order("created_at").Find(&events).Error)
+	s.Require().Len(events, 2)
+
+	// The first event fails twice; the second must not overtake it.
+	sink := &flakySink{failures: 2}
+	s.startRelay(map[string]services.Sink{"flaky": sink})
+
+	s.Require().Eventually(func() bool { return len(sink.events()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	s.Equal([]string{events[0].ID, events[1].ID}, sink.events())
+
+	var e models.OutboxEvent
+	s.Require().NoError(s.db.Where("id = ?", events[0].ID).First(&e).Error)
+	s.Equal(2, e.Attempts)
+	s.Empty(e.LastError)
+	s.Contains(e.Payload, first.ID)
+}
+
+func (s *OutboxRelayTestSuite) TestRolledBackTransaction() {
+	err := database.NewTransactor(s.db).Transaction(context.Background(), func(ctx context.Context) error {
+		if err := s.userRepo.CreateUser(ctx, s.newUser()); err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+	s.EqualError(err, "rollback")
+
+	var count int64
+	s.Require().NoError(s.db.Model(&models.OutboxEvent{}).Count(&count).Error)
+	s.Zero(count)
+}
+
+func TestOutboxRelay(t *testing.T) {
+	suite.Run(t, new(OutboxRelayTestSuite))
+}
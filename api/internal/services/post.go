@@ -13,8 +13,11 @@ import (
 
 type PostRepository interface {
 	CreatePost(ctx context.Context, p *models.Post) error
+	UpdatePost(ctx context.Context, p *models.Post) error
 	GetPost(ctx context.Context, postId string) (*models.Post, error)
 	GetPosts(ctx context.Context, userId string) ([]*models.Post, error)
+	GetPostsByUsers(ctx context.Context, userIds []string, limit, offset int) ([]*models.Post, error)
+	StreamPosts(ctx context.Context, userId string, fn func(*models.Post) error) error
 	DeletePost(ctx context.Context, postId string) error
 }
 
@@ -44,6 +47,18 @@ func (s *PostService) CreatePost(p *models.Post) error {
 	return nil
 }
 
+func (s *PostService) UpdatePost(p *models.Post) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := s.postRepo.UpdatePost(ctx, p)
+	if err != nil {
+		return apperror.ErrInternalServer
+	}
+
+	return nil
+}
+
 func (s *PostService) GetPost(postId string) (*models.Post, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -61,8 +76,10 @@ func (s *PostService) GetPost(postId string) (*models.Post, error) {
 	return post, nil
 }
 
-func (s *PostService) GetPosts(userId string) ([]*models.Post, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// GetPosts returns the posts of a user. It takes the request context,
+// which may ask for reads from the primary, see database.ReadPrimary.
+func (s *PostService) GetPosts(ctx context.Context, userId string) ([]*models.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	posts, err := s.postRepo.GetPosts(ctx, userId)
@@ -79,6 +96,41 @@ func (s *PostService) GetPosts(userId string) ([]*models.Post, error) {
 	return posts, nil
 }
 
+// GetPostsByUsers returns a page of posts for each of several users, keyed
+// by user ID. Every user gets an entry, empty if they have no posts in the
+// page. Like GetPosts, it takes the request context.
+func (s *PostService) GetPostsByUsers(ctx context.Context, userIds []string, limit, offset int) (map[string][]*models.Post, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	posts, err := s.postRepo.GetPostsByUsers(ctx, userIds, limit, offset)
+	if err != nil {
+		return nil, apperror.ErrInternalServer
+	}
+
+	byUser := make(map[string][]*models.Post, len(userIds))
+	for _, userId := range userIds {
+		byUser[userId] = []*models.Post{}
+	}
+	for _, p := range posts {
+		byUser[p.UserID] = append(byUser[p.UserID], p)
+	}
+
+	return byUser, nil
+}
+
+// StreamPosts calls fn for each of a user's posts. The request context is
+// not given a timeout, since streaming lasts as long as the client keeps
+// reading.
+func (s *PostService) StreamPosts(ctx context.Context, userId string, fn func(*models.Post) error) error {
+	err := s.postRepo.StreamPosts(ctx, userId, fn)
+	if err != nil {
+		return apperror.ErrInternalServer
+	}
+
+	return nil
+}
+
 func (s *PostService) DeletePost(postId string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
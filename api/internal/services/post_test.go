@@ -26,9 +26,9 @@ type PostServiceTestSuite struct {
 func (s *PostServiceTestSuite) SetupSuite() {
 	cfg := config.NewConfig("test", "silent")
 	cfg.DSN = "file::memory:?cache=shared"
-	db := database.GetDBConn(cfg.DSN, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
+	db := database.GetDBConn(cfg.DSN, cfg.REPLICA_DSNS, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
 
-	err := db.AutoMigrate(&models.User{}, &models.Address{}, &models.Post{})
+	err := db.AutoMigrate(&models.User{}, &models.Address{}, &models.Post{}, &models.OutboxEvent{})
 	if err != nil {
 		s.Fail(err.Error())
 	}
@@ -66,12 +66,7 @@ func (s *PostServiceTestSuite) SetupSuite() {
 }
 
 func (s *PostServiceTestSuite) TearDownSuite() {
-	sqlDB, err := s.db.DB()
-	if err != nil {
-		s.Fail(err.Error())
-	}
-
-	sqlDB.Close()
+	s.NoError(database.Close(s.db))
 }
 
 func (s *PostServiceTestSuite) TestPostService() {
@@ -97,7 +92,7 @@ func (s *PostServiceTestSuite) TestPostService() {
 	})
 
 	t.Run("Get posts", func(t *testing.T) {
-		posts, err := s.postService.GetPosts(s.users[0].ID)
+		posts, err := s.postService.GetPosts(context.Background(), s.users[0].ID)
 
 		s.NoError(err)
 		s.NotEmpty(posts)
@@ -106,6 +101,18 @@ func (s *PostServiceTestSuite) TestPostService() {
 		postId = posts[0].ID
 	})
 
+	t.Run("Get posts by users", func(t *testing.T) {
+		missing := uuid.NewString()
+		posts, err := s.postService.GetPostsByUsers(context.Background(), []string{s.users[0].ID, s.users[1].ID, missing}, 3, 0)
+
+		s.NoError(err)
+		s.Len(posts, 3)
+		s.Len(posts[s.users[0].ID], 3)
+		s.Len(posts[s.users[1].ID], 3)
+		s.NotNil(posts[missing])
+		s.Empty(posts[missing])
+	})
+
 	t.Run("Get post by ID", func(t *testing.T) {
 		post, err := s.postService.GetPost(postId)
 		s.NoError(err)
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/princecee/lema-ai/internal/db/models"
+)
+
+// EventStream fans outbox events out to subscribers in this process, making
+// it a Sink. The latest events are kept in a ring buffer so a subscriber
+// that reconnects can resume where it left off.
+type EventStream struct {
+	mu     sync.Mutex
+	size   int
+	events []*models.OutboxEvent
+	ids    map[string]struct{}
+	subs   map[chan *models.OutboxEvent]struct{}
+	closed bool
+	done   chan struct{}
+}
+
+// NewEventStream returns a stream keeping the latest size events.
+func NewEventStream(size int) *EventStream {
+	return &EventStream{
+		size: max(size, 1),
+		ids:  map[string]struct{}{},
+		subs: map[chan *models.OutboxEvent]struct{}{},
+		done: make(chan struct{}),
+	}
+}
+
+// Publish sends an event to every subscriber. An event published again by
+// the relay is ignored while it is still buffered. A subscriber whose
+// channel is full is dropped rather than holding back the others; its
+// channel is closed so it can resubscribe from the last event it got.
+func (s *EventStream) Publish(ctx context.Context, e *models.OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	if _, ok := s.ids[e.ID]; ok {
+		return nil
+	}
+
+	if len(s.events) == s.size {
+		delete(s.ids, s.events[0].ID)
+		s.events = s.events[1:]
+	}
+	s.events = append(s.events, e)
+	s.ids[e.ID] = struct{}{}
+
+	for ch := range s.subs {
+		select {
+		case ch <- e:
+		default:
+			delete(s.subs, ch)
+			close(ch)
+		}
+	}
+	return nil
+}
+
+// Subscribe returns the buffered events published after lastEventId and a
+// channel of the events published from now on, holding up to buffer
+// events. All buffered events are returned when lastEventId is no longer
+// buffered, and none when it is empty. The channel is closed when the
+// subscriber is dropped or the stream is closed; cancel unsubscribes.
+func (s *EventStream) Subscribe(lastEventId string, buffer int) ([]*models.OutboxEvent, <-chan *models.OutboxEvent, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := make(chan *models.OutboxEvent, buffer)
+	if s.closed {
+		close(ch)
+		return nil, ch, func() {}
+	}
+	s.subs[ch] = struct{}{}
+
+	var missed []*models.OutboxEvent
+	if lastEventId != "" {
+		missed = s.events
+		for i, e := range s.events {
+			if e.ID == lastEventId {
+				missed = s.events[i+1:]
+				break
+			}
+		}
+		// Publish may reuse the array behind s.events.
+		missed = append([]*models.OutboxEvent(nil), missed...)
+	}
+
+	cancel := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subs[ch]; ok {
+			delete(s.subs, ch)
+			close(ch)
+		}
+	}
+	return missed, ch, cancel
+}
+
+// Close closes the channels of all subscribers so long-lived responses
+// end, and stops accepting new ones.
+func (s *EventStream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.done)
+	for ch := range s.subs {
+		delete(s.subs, ch)
+		close(ch)
+	}
+}
+
+// Done is closed when the stream is closed. It tells a subscriber whose
+// channel was closed that it should not subscribe again.
+func (s *EventStream) Done() <-chan struct{} {
+	return s.done
+}
@@ -0,0 +1,83 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func publishEvents(t *testing.T, stream *services.EventStream, ids ...string) {
+	for _, id := range ids {
+		require.NoError(t, stream.Publish(context.Background(), &models.OutboxEvent{ID: id, Event: models.EventPostCreated}))
+	}
+}
+
+func eventIds(events []*models.OutboxEvent) []string {
+	var ids []string
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func TestEventStreamResume(t *testing.T) {
+	stream := services.NewEventStream(3)
+	publishEvents(t, stream, "1", "2", "3", "4")
+
+	missed, _, cancel := stream.Subscribe("", 1)
+	defer cancel()
+	assert.Empty(t, missed)
+
+	missed, _, cancel = stream.Subscribe("3", 1)
+	defer cancel()
+	assert.Equal(t, []string{"4"}, eventIds(missed))
+
+	// "1" fell out of the buffer, so everything buffered is replayed.
+	missed, _, cancel = stream.Subscribe("1", 1)
+	defer cancel()
+	assert.Equal(t, []string{"2", "3", "4"}, eventIds(missed))
+}
+
+func TestEventStreamFanOut(t *testing.T) {
+	stream := services.NewEventStream(10)
+	_, fast, cancelFast := stream.Subscribe("", 10)
+	defer cancelFast()
+	_, slow, cancelSlow := stream.Subscribe("", 1)
+	defer cancelSlow()
+
+	// The second publish of "1" is ignored; "2" overflows the slow
+	// subscriber, which is dropped.
+	publishEvents(t, stream, "1", "1", "2")
+
+	var got []string
+	for range 2 {
+		got = append(got, (<-fast).ID)
+	}
+	assert.Equal(t, []string{"1", "2"}, got)
+
+	e, ok := <-slow
+	assert.True(t, ok)
+	assert.Equal(t, "1", e.ID)
+	_, ok = <-slow
+	assert.False(t, ok)
+}
+
+func TestEventStreamClose(t *testing.T) {
+	stream := services.NewEventStream(10)
+	_, events, cancel := stream.Subscribe("", 1)
+	defer cancel()
+
+	stream.Close()
+	_, ok := <-events
+	assert.False(t, ok)
+
+	_, events, _ = stream.Subscribe("", 1)
+	_, ok = <-events
+	assert.False(t, ok)
+
+	publishEvents(t, stream, "1")
+}
@@ -12,9 +12,12 @@ import (
 )
 
 type UserRepository interface {
+	CreateUser(ctx context.Context, u *models.User) error
+	UpdateUser(ctx context.Context, u *models.User) error
 	GetUser(ctx context.Context, userId string) (*models.User, error)
 	GetUsers(ctx context.Context, opts pagination.PaginationQuery) (*pagination.GetUsersResult, error)
 	GetUserCount(ctx context.Context) (int64, error)
+	StreamUsers(ctx context.Context, opts pagination.PaginationQuery, fn func(*models.User) error) error
 }
 
 type UserService struct {
@@ -25,6 +28,41 @@ func NewUserService(userRepo UserRepository) *UserService {
 	return &UserService{userRepo}
 }
 
+func (s *UserService) CreateUser(u *models.User) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := s.userRepo.CreateUser(ctx, u)
+	if err != nil {
+		return userWriteError(err)
+	}
+
+	return nil
+}
+
+func (s *UserService) UpdateUser(u *models.User) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := s.userRepo.UpdateUser(ctx, u)
+	if err != nil {
+		return userWriteError(err)
+	}
+
+	return nil
+}
+
+// userWriteError maps a failed user write to an application error. Email,
+// username and phone are unique, so duplicates are reported as conflicts.
+func userWriteError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return apperror.ErrConflict.WithMessage("A user with this email, username or phone already exists")
+	default:
+		return apperror.ErrInternalServer
+	}
+}
+
 func (s *UserService) GetUser(userId string) (*models.User, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -42,8 +80,10 @@ func (s *UserService) GetUser(userId string) (*models.User, error) {
 	return user, nil
 }
 
-func (s *UserService) GetUsers(page, limit int) (*pagination.GetUsersResult, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// GetUsers returns a page of users. It takes the request context, which
+// may ask for reads from the primary, see database.ReadPrimary.
+func (s *UserService) GetUsers(ctx context.Context, page, limit int) (*pagination.GetUsersResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	users, err := s.userRepo.GetUsers(ctx, pagination.PaginationQuery{
@@ -65,8 +105,25 @@ func (s *UserService) GetUsers(page, limit int) (*pagination.GetUsersResult, err
 	return users, nil
 }
 
-func (s *UserService) GetUserCount() (int64, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// StreamUsers calls fn for each user of a page. The request context is not
+// given a timeout, since streaming lasts as long as the client keeps
+// reading.
+func (s *UserService) StreamUsers(ctx context.Context, page, limit int, fn func(*models.User) error) error {
+	err := s.userRepo.StreamUsers(ctx, pagination.PaginationQuery{
+		Page:  &page,
+		Limit: &limit,
+	}, fn)
+	if err != nil {
+		return apperror.ErrInternalServer
+	}
+
+	return nil
+}
+
+// GetUserCount counts the users. Like GetUsers it takes the request
+// context.
+func (s *UserService) GetUserCount(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	count, err := s.userRepo.GetUserCount(ctx)
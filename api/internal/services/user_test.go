@@ -25,9 +25,9 @@ type UserServiceTestSuite struct {
 func (s *UserServiceTestSuite) SetupSuite() {
 	cfg := config.NewConfig("test", "silent")
 	cfg.DSN = "file::memory:?cache=shared"
-	db := database.GetDBConn(cfg.DSN, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
+	db := database.GetDBConn(cfg.DSN, cfg.REPLICA_DSNS, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
 
-	err := db.AutoMigrate(&models.User{}, &models.Address{})
+	err := db.AutoMigrate(&models.User{}, &models.Address{}, &models.OutboxEvent{})
 	if err != nil {
 		s.Fail(err.Error())
 	}
@@ -62,12 +62,7 @@ func (s *UserServiceTestSuite) SetupSuite() {
 }
 
 func (s *UserServiceTestSuite) TearDownSuite() {
-	sqlDB, err := s.db.DB()
-	if err != nil {
-		s.Fail(err.Error())
-	}
-
-	sqlDB.Close()
+	s.NoError(database.Close(s.db))
 }
 
 func (s *UserServiceTestSuite) TestUserService() {
@@ -75,7 +70,7 @@ func (s *UserServiceTestSuite) TestUserService() {
 	var users []*models.User
 
 	t.Run("Get users", func(t *testing.T) {
-		response, err := s.userService.GetUsers(1, 20)
+		response, err := s.userService.GetUsers(context.Background(), 1, 20)
 
 		s.NoError(err)
 		s.Equal(20, len(response.Users))
@@ -99,7 +94,7 @@ func (s *UserServiceTestSuite) TestUserService() {
 	})
 
 	t.Run("Get user count", func(t *testing.T) {
-		count, err := s.userService.GetUserCount()
+		count, err := s.userService.GetUserCount(context.Background())
 		s.NoError(err)
 		s.Equal(count, int64(20))
 	})
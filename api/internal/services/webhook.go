@@ -0,0 +1,389 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/princecee/lema-ai/internal/db/models"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+)
+
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, w *models.Webhook) error
+	GetWebhook(ctx context.Context, webhookId string) (*models.Webhook, error)
+	GetWebhooks(ctx context.Context) ([]*models.Webhook, error)
+	GetWebhooksByEvent(ctx context.Context, event string) ([]*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookId string) error
+	CreateDelivery(ctx context.Context, d *models.WebhookDelivery) (bool, error)
+	GetDelivery(ctx context.Context, webhookId, deliveryId string) (*models.WebhookDelivery, error)
+	GetDeliveries(ctx context.Context, webhookId string, limit int) ([]*models.WebhookDelivery, error)
+	UpdateDeliveryStatus(ctx context.Context, deliveryId, status string, deliveredAt *time.Time) error
+	CreateAttempt(ctx context.Context, a *models.WebhookAttempt) error
+}
+
+// WebhookJobKind is the kind of the jobs that send webhook deliveries.
+const WebhookJobKind = "webhook"
+
+type webhookJobPayload struct {
+	WebhookID  string `json:"webhook_id"`
+	DeliveryID string `json:"delivery_id"`
+}
+
+// Headers of a delivery request. The signature is computed by SignWebhook.
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// deliveriesLimit is the number of deliveries returned by GetDeliveries.
+const deliveriesLimit = 100
+
+// maxResponseLog is the number of bytes of a response body logged with an
+// attempt.
+const maxResponseLog = 1024
+
+// WebhookOptions configures how deliveries are sent.
+type WebhookOptions struct {
+	// Timeout bounds each delivery request.
+	Timeout time.Duration
+	// AllowPrivateNetworks lets webhooks reach loopback, private and
+	// link-local addresses, which are refused by default.
+	AllowPrivateNetworks bool
+}
+
+// WebhookService manages webhook subscriptions and sends events to them.
+// Each delivery is a job handled by RunDeliveryJob, so failed deliveries
+// are retried with the backoff of the job queue.
+type WebhookService struct {
+	transactor  Transactor
+	webhookRepo WebhookRepository
+	jobs        JobEnqueuer
+	client      *http.Client
+	logger      zerolog.Logger
+}
+
+func NewWebhookService(transactor Transactor, webhookRepo WebhookRepository, jobs JobEnqueuer, opts WebhookOptions, l zerolog.Logger) *WebhookService {
+	return &WebhookService{
+		transactor:  transactor,
+		webhookRepo: webhookRepo,
+		jobs:        jobs,
+		client: &http.Client{
+			Timeout:   opts.Timeout,
+			Transport: webhookTransport(opts.AllowPrivateNetworks),
+			// A redirect is reported as a failed attempt rather than
+			// followed to a URL nobody subscribed.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		logger: l,
+	}
+}
+
+// webhookTransport returns the transport of deliveries. Unless
+// allowPrivate is set, it refuses to connect to addresses that are not
+// public. The check runs on the address dialed, after DNS resolution, so a
+// name resolving to a private address is refused too. No proxy is used,
+// as the check would then apply to the proxy.
+func webhookTransport(allowPrivate bool) *http.Transport {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		dialer.Control = publicAddressOnly
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// errNonPublicAddress is returned when a webhook resolves to an address
+// that is not public.
+var errNonPublicAddress = errors.New("webhook address is not public")
+
+// cgnatPrefix is the shared address space of carrier-grade NAT, which
+// netip does not count as private.
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+func publicAddressOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || cgnatPrefix.Contains(addr) {
+		return fmt.Errorf("%w: %s", errNonPublicAddress, addr)
+	}
+	return nil
+}
+
+// CreateWebhook stores a webhook, generating its secret when none is set.
+func (s *WebhookService) CreateWebhook(ctx context.Context, w *models.Webhook) error {
+	if w.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return apperror.ErrInternalServer
+		}
+		w.Secret = hex.EncodeToString(secret)
+	}
+	w.ID = uuid.NewString()
+	w.CreatedAt = time.Now().UTC()
+
+	if err := s.webhookRepo.CreateWebhook(ctx, w); err != nil {
+		return apperror.ErrInternalServer
+	}
+
+	return nil
+}
+
+func (s *WebhookService) GetWebhook(ctx context.Context, webhookId string) (*models.Webhook, error) {
+	w, err := s.webhookRepo.GetWebhook(ctx, webhookId)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, apperror.ErrNotFound
+		default:
+			return nil, apperror.ErrInternalServer
+		}
+	}
+
+	return w, nil
+}
+
+func (s *WebhookService) GetWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	webhooks, err := s.webhookRepo.GetWebhooks(ctx)
+	if err != nil {
+		return nil, apperror.ErrInternalServer
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook deletes a webhook and its delivery log. Deliveries still
+// queued are dropped.
+func (s *WebhookService) DeleteWebhook(ctx context.Context, webhookId string) error {
+	err := s.webhookRepo.DeleteWebhook(ctx, webhookId)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return apperror.ErrNotFound
+		default:
+			return apperror.ErrInternalServer
+		}
+	}
+
+	return nil
+}
+
+// GetDeliveries returns the latest deliveries of a webhook with their
+// attempts.
+func (s *WebhookService) GetDeliveries(ctx context.Context, webhookId string) ([]*models.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(ctx, webhookId); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.webhookRepo.GetDeliveries(ctx, webhookId, deliveriesLimit)
+	if err != nil {
+		return nil, apperror.ErrInternalServer
+	}
+
+	return deliveries, nil
+}
+
+func (s *WebhookService) GetDelivery(ctx context.Context, webhookId, deliveryId string) (*models.WebhookDelivery, error) {
+	d, err := s.webhookRepo.GetDelivery(ctx, webhookId, deliveryId)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, apperror.ErrNotFound
+		default:
+			return nil, apperror.ErrInternalServer
+		}
+	}
+
+	return d, nil
+}
+
+// Redeliver sends a delivery again with its original payload. Its attempts
+// are appended to the same log.
+func (s *WebhookService) Redeliver(ctx context.Context, webhookId, deliveryId string) (*models.WebhookDelivery, error) {
+	d, err := s.GetDelivery(ctx, webhookId, deliveryId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.transactor.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.webhookRepo.UpdateDeliveryStatus(ctx, d.ID, models.WebhookDeliveryPending, nil); err != nil {
+			return err
+		}
+		_, err := s.jobs.Enqueue(ctx, WebhookJobKind, webhookJobPayload{WebhookID: webhookId, DeliveryID: d.ID})
+		return err
+	})
+	if err != nil {
+		return nil, apperror.ErrInternalServer
+	}
+
+	d.Status = models.WebhookDeliveryPending
+	d.DeliveredAt = nil
+	return d, nil
+}
+
+// Publish queues a delivery of an outbox event to every webhook subscribed
+// to it, making WebhookService a Sink. Publishing an event again does not
+// duplicate its deliveries.
+func (s *WebhookService) Publish(ctx context.Context, e *models.OutboxEvent) error {
+	webhooks, err := s.webhookRepo.GetWebhooksByEvent(ctx, e.Event)
+	if err != nil {
+		return err
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	payload, err := encodeEvent(e)
+	if err != nil {
+		return err
+	}
+
+	return s.transactor.Transaction(ctx, func(ctx context.Context) error {
+		for _, w := range webhooks {
+			d := &models.WebhookDelivery{
+				ID:        uuid.NewString(),
+				WebhookID: w.ID,
+				EventID:   e.ID,
+				Event:     e.Event,
+				Payload:   payload,
+				Status:    models.WebhookDeliveryPending,
+				CreatedAt: time.Now().UTC(),
+			}
+			created, err := s.webhookRepo.CreateDelivery(ctx, d)
+			if err != nil {
+				return err
+			}
+			if !created {
+				continue
+			}
+			if _, err := s.jobs.Enqueue(ctx, WebhookJobKind, webhookJobPayload{WebhookID: w.ID, DeliveryID: d.ID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RunDeliveryJob sends a delivery and logs the attempt. The delivery is
+// marked failed once the job queue gives up on it.
+func (s *WebhookService) RunDeliveryJob(ctx context.Context, job *models.Job) error {
+	var payload webhookJobPayload
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return err
+	}
+
+	w, err := s.webhookRepo.GetWebhook(ctx, payload.WebhookID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The webhook was deleted after the event.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	d, err := s.webhookRepo.GetDelivery(ctx, payload.WebhookID, payload.DeliveryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// The log must land even when the job is being interrupted.
+	saveCtx := context.WithoutCancel(ctx)
+	attempt := s.send(ctx, w, d)
+	if err := s.webhookRepo.CreateAttempt(saveCtx, attempt); err != nil {
+		s.logger.Error().Err(err).Str("delivery_id", d.ID).Msg("failed to log webhook attempt")
+	}
+
+	if attempt.Error == "" {
+		now := time.Now().UTC()
+		return s.webhookRepo.UpdateDeliveryStatus(saveCtx, d.ID, models.WebhookDeliverySucceeded, &now)
+	}
+
+	if ctx.Err() == nil && job.Attempts >= job.MaxAttempts {
+		if err := s.webhookRepo.UpdateDeliveryStatus(saveCtx, d.ID, models.WebhookDeliveryFailed, nil); err != nil {
+			s.logger.Error().Err(err).Str("delivery_id", d.ID).Msg("failed to save webhook delivery")
+		}
+	}
+	return errors.New(attempt.Error)
+}
+
+// send posts a delivery to its webhook. Any response outside 2xx is a
+// failed attempt.
+func (s *WebhookService) send(ctx context.Context, w *models.Webhook, d *models.WebhookDelivery) *models.WebhookAttempt {
+	attempt := &models.WebhookAttempt{
+		ID:         uuid.NewString(),
+		DeliveryID: d.ID,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, d.Event)
+	req.Header.Set(WebhookDeliveryHeader, d.ID)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(w.Secret, timestamp, d.Payload))
+
+	resp, err := s.client.Do(req)
+	attempt.DurationMs = time.Since(attempt.CreatedAt).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseLog))
+	attempt.StatusCode = resp.StatusCode
+	attempt.Response = string(body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("webhook responded with status %d", resp.StatusCode)
+	}
+	return attempt
+}
+
+// SignWebhook returns the signature header of a delivery: the hex-encoded
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook's secret.
+// Receivers should compute it the same way, compare in constant time and
+// reject stale timestamps.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,212 @@
+package services_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/princecee/lema-ai/config"
+	database "github.com/princecee/lema-ai/internal/db"
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/internal/db/repositories"
+	"github.com/princecee/lema-ai/internal/services"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type WebhookServiceTestSuite struct {
+	suite.Suite
+	db             *gorm.DB
+	webhookRepo    *repositories.WebhookRepository
+	webhookService *services.WebhookService
+	jobQueue       *services.JobQueue
+	relay          *services.OutboxRelay
+}
+
+func (s *WebhookServiceTestSuite) SetupSuite() {
+	cfg := config.NewConfig("test", "silent")
+	cfg.DSN = "file:webhook_service?mode=memory&cache=shared"
+	db := database.GetDBConn(cfg.DSN, cfg.REPLICA_DSNS, cfg.MAX_IDLE_CONNS, cfg.MAX_OPEN_CONNS, cfg.CONN_MAX_LIFETIME, cfg.LOG_LEVEL)
+
+	err := database.Migrate(db)
+	if err != nil {
+		s.Fail(err.Error())
+	}
+
+	s.db = db
+	s.webhookRepo = repositories.NewWebhookRepository(db)
+	s.jobQueue = services.NewJobQueue(repositories.NewJobRepository(db), services.JobOptions{
+		Workers:      1,
+		PollInterval: 10 * time.Millisecond,
+		LockTimeout:  time.Minute,
+		MaxAttempts:  2,
+		BackoffBase:  time.Millisecond,
+		BackoffMax:   time.Millisecond,
+	}, zerolog.Nop())
+	s.webhookService = services.NewWebhookService(database.NewTransactor(db), s.webhookRepo, s.jobQueue, services.WebhookOptions{
+		Timeout:              time.Second,
+		AllowPrivateNetworks: true,
+	}, zerolog.Nop())
+	s.jobQueue.Register(services.WebhookJobKind, s.webhookService.RunDeliveryJob)
+	s.jobQueue.Start(context.Background())
+
+	s.relay = services.NewOutboxRelay(repositories.NewOutboxRepository(db), map[string]services.Sink{"webhook": s.webhookService}, services.OutboxOptions{
+		PollInterval: 10 * time.Millisecond,
+		BatchSize:    100,
+	}, zerolog.Nop())
+	s.relay.Start(context.Background())
+}
+
+func (s *WebhookServiceTestSuite) TearDownSuite() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.NoError(s.relay.Shutdown(ctx))
+	s.NoError(s.jobQueue.Shutdown(ctx))
+
+	s.NoError(database.Close(s.db))
+}
+
+// waitForDelivery waits until the only delivery of a webhook reaches
+// status and returns it.
+func (s *WebhookServiceTestSuite) waitForDelivery(webhookId, status string) *models.WebhookDelivery {
+	var delivery *models.WebhookDelivery
+	s.Require().Eventually(func() bool {
+		deliveries, err := s.webhookService.GetDeliveries(context.Background(), webhookId)
+		if err != nil || len(deliveries) != 1 {
+			return false
+		}
+		delivery = deliveries[0]
+		return delivery.Status == status
+	}, 5*time.Second, 10*time.Millisecond)
+	return delivery
+}
+
+func (s *WebhookServiceTestSuite) TestUserEvents() {
+	var requests atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer receiver.Close()
+
+	webhook := &models.Webhook{URL: receiver.URL, Events: []string{models.EventUserCreated, models.EventUserUpdated}}
+	s.Require().NoError(s.webhookService.CreateWebhook(context.Background(), webhook))
+	s.Len(webhook.Secret, 64)
+
+	userService := services.NewUserService(repositories.NewUserRepository(s.db))
+	user := &models.User{
+		ID:       uuid.NewString(),
+		Name:     "Jane Doe",
+		Username: "jane",
+		Email:    "jane@example.com",
+		Phone:    "+15550000000",
+		Address:  models.Address{ID: uuid.NewString(), Street: "1 Main St", City: "Springfield", State: "IL", Zipcode: "62701"},
+	}
+	s.Require().NoError(userService.CreateUser(user))
+
+	delivery := s.waitForDelivery(webhook.ID, models.WebhookDeliverySucceeded)
+	s.Equal(models.EventUserCreated, delivery.Event)
+	s.Equal(int32(1), requests.Load())
+}
+
+func (s *WebhookServiceTestSuite) TestFailedDelivery() {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	webhook := &models.Webhook{URL: receiver.URL, Events: []string{models.EventPostCreated}}
+	s.Require().NoError(s.webhookService.CreateWebhook(context.Background(), webhook))
+
+	s.Require().NoError(s.webhookService.Publish(context.Background(), &models.OutboxEvent{
+		ID:        uuid.NewString(),
+		Event:     models.EventPostCreated,
+		Payload:   "{}",
+		CreatedAt: time.Now().UTC(),
+	}))
+
+	delivery := s.waitForDelivery(webhook.ID, models.WebhookDeliveryFailed)
+	s.Require().Len(delivery.Attempts, 2)
+	for _, attempt := range delivery.Attempts {
+		s.Equal(http.StatusInternalServerError, attempt.StatusCode)
+		s.Equal("webhook responded with status 500", attempt.Error)
+	}
+}
+
+func (s *WebhookServiceTestSuite) TestRepublishedEvent() {
+	var requests atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer receiver.Close()
+
+	webhook := &models.Webhook{URL: receiver.URL, Events: []string{models.EventPostDeleted}}
+	s.Require().NoError(s.webhookService.CreateWebhook(context.Background(), webhook))
+
+	// The relay publishes an event again when it fails to mark it
+	// dispatched; the webhook still gets a single delivery.
+	event := &models.OutboxEvent{
+		ID:        uuid.NewString(),
+		Event:     models.EventPostDeleted,
+		Payload:   "{}",
+		CreatedAt: time.Now().UTC(),
+	}
+	s.Require().NoError(s.webhookService.Publish(context.Background(), event))
+	s.Require().NoError(s.webhookService.Publish(context.Background(), event))
+
+	s.waitForDelivery(webhook.ID, models.WebhookDeliverySucceeded)
+	s.Equal(int32(1), requests.Load())
+}
+
+func (s *WebhookServiceTestSuite) TestDeletedWebhook() {
+	// Deliveries queued before their webhook was deleted are dropped.
+	job := &models.Job{
+		Kind:        services.WebhookJobKind,
+		Payload:     `{"webhook_id":"` + uuid.NewString() + `","delivery_id":"` + uuid.NewString() + `"}`,
+		Attempts:    1,
+		MaxAttempts: 2,
+	}
+	s.NoError(s.webhookService.RunDeliveryJob(context.Background(), job))
+}
+
+func (s *WebhookServiceTestSuite) TestPrivateAddress() {
+	var requests atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer receiver.Close()
+
+	// Webhooks may not reach loopback addresses by default. The delivery is
+	// run directly, as the queue of the suite allows them.
+	webhookService := services.NewWebhookService(nil, s.webhookRepo, nil, services.WebhookOptions{Timeout: time.Second}, zerolog.Nop())
+	webhook := &models.Webhook{ID: uuid.NewString(), URL: receiver.URL, Events: []string{models.EventPostCreated}, Secret: "secret"}
+	s.Require().NoError(s.webhookRepo.CreateWebhook(context.Background(), webhook))
+	delivery := &models.WebhookDelivery{
+		ID:        uuid.NewString(),
+		WebhookID: webhook.ID,
+		EventID:   uuid.NewString(),
+		Event:     models.EventPostCreated,
+		Payload:   []byte("{}"),
+		Status:    models.WebhookDeliveryPending,
+		CreatedAt: time.Now().UTC(),
+	}
+	_, err := s.webhookRepo.CreateDelivery(context.Background(), delivery)
+	s.Require().NoError(err)
+
+	err = webhookService.RunDeliveryJob(context.Background(), &models.Job{
+		Kind:        services.WebhookJobKind,
+		Payload:     `{"webhook_id":"` + webhook.ID + `","delivery_id":"` + delivery.ID + `"}`,
+		Attempts:    1,
+		MaxAttempts: 1,
+	})
+	s.ErrorContains(err, "webhook address is not public")
+	s.Equal(int32(0), requests.Load())
+}
+
+func TestWebhookService(t *testing.T) {
+	suite.Run(t, new(WebhookServiceTestSuite))
+}
@@ -0,0 +1,37 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+const BackendMemory = "memory"
+
+var ErrClosed = errors.New("broker: closed")
+
+// Broker publishes messages to a message system, e.g. to NATS subjects or
+// Kafka topics. Adapters for such systems implement it next to
+// MemoryBroker.
+type Broker interface {
+	// Publish sends data to subject. It returns once the message system
+	// has accepted the message.
+	Publish(ctx context.Context, subject string, data []byte) error
+	Close() error
+}
+
+// Message is a message delivered to a subscriber of a MemoryBroker.
+type Message struct {
+	Subject string
+	Data    []byte
+}
+
+// New returns the broker for the configured backend.
+func New(backend string) (Broker, error) {
+	switch backend {
+	case "", BackendMemory:
+		return NewMemoryBroker(), nil
+	default:
+		return nil, fmt.Errorf("broker: unknown backend %q", backend)
+	}
+}
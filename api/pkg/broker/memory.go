@@ -0,0 +1,90 @@
+package broker
+
+import (
+	"context"
+	"sync"
+)
+
+type subscription struct {
+	subject string
+	ch      chan Message
+}
+
+// MemoryBroker is an in-process Broker for tests and local development.
+// Messages are only seen by subscribers of the same process.
+type MemoryBroker struct {
+	mu     sync.Mutex
+	subs   map[int]subscription
+	next   int
+	closed bool
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{subs: map[int]subscription{}}
+}
+
+// Publish hands data to every subscriber of subject. A subscriber whose
+// buffer is full misses the message, as with a NATS core subscription.
+func (b *MemoryBroker) Publish(ctx context.Context, subject string, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+
+	for _, sub := range b.subs {
+		if sub.subject != "" && sub.subject != subject {
+			continue
+		}
+		select {
+		case sub.ch <- Message{Subject: subject, Data: data}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a channel receiving the messages published to subject,
+// or to every subject when subject is empty, and a function ending the
+// subscription.
+func (b *MemoryBroker) Subscribe(subject string, buffer int) (<-chan Message, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.next
+	b.next++
+	ch := make(chan Message, buffer)
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	b.subs[id] = subscription{subject, ch}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if sub, ok := b.subs[id]; ok {
+			delete(b.subs, id)
+			close(sub.ch)
+		}
+	}
+}
+
+// Close ends every subscription.
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.closed {
+		b.closed = true
+		for id, sub := range b.subs {
+			delete(b.subs, id)
+			close(sub.ch)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package broker_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/princecee/lema-ai/pkg/broker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryBroker(t *testing.T) {
+	b := broker.NewMemoryBroker()
+	ctx := context.Background()
+
+	posts, unsubscribe := b.Subscribe("post.created", 10)
+	all, _ := b.Subscribe("", 10)
+
+	require.NoError(t, b.Publish(ctx, "post.created", []byte("1")))
+	require.NoError(t, b.Publish(ctx, "user.created", []byte("2")))
+
+	assert.Equal(t, broker.Message{Subject: "post.created", Data: []byte("1")}, <-posts)
+	assert.Equal(t, "post.created", (<-all).Subject)
+	assert.Equal(t, "user.created", (<-all).Subject)
+	assert.Empty(t, posts)
+
+	unsubscribe()
+	_, open := <-posts
+	assert.False(t, open)
+
+	require.NoError(t, b.Close())
+	_, open = <-all
+	assert.False(t, open)
+	assert.ErrorIs(t, b.Publish(ctx, "post.created", nil), broker.ErrClosed)
+}
+
+func TestNew(t *testing.T) {
+	b, err := broker.New(broker.BackendMemory)
+	require.NoError(t, err)
+	assert.IsType(t, &broker.MemoryBroker{}, b)
+
+	_, err = broker.New("carrier-pigeon")
+	assert.Error(t, err)
+}
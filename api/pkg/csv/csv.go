@@ -0,0 +1,280 @@
+package csv
+
+import (
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	apperror "github.com/princecee/lema-ai/pkg/error"
+)
+
+// Column is a flattened struct field. Nested struct fields are named with
+// their JSON path, e.g. "address.street".
+type Column struct {
+	Name  string
+	Index []int
+}
+
+// Columns flattens the exported fields of a struct type using their json
+// tags. Slices and maps are skipped since they have no single cell value.
+// Types implementing encoding.TextMarshaler, such as time.Time, are a
+// single column.
+func Columns(t reflect.Type) []Column {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return columns(t, "", nil)
+}
+
+func columns(t reflect.Type, prefix string, index []int) []Column {
+	var cols []Column
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldIndex := append(append([]int{}, index...), i)
+		ft := field.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		switch {
+		case ft.Implements(textMarshalerType):
+			cols = append(cols, Column{Name: prefix + name, Index: fieldIndex})
+		case ft.Kind() == reflect.Struct:
+			cols = append(cols, columns(ft, prefix+name+".", fieldIndex)...)
+		case ft.Kind() == reflect.Slice, ft.Kind() == reflect.Array, ft.Kind() == reflect.Map:
+		default:
+			cols = append(cols, Column{Name: prefix + name, Index: fieldIndex})
+		}
+	}
+	return cols
+}
+
+var (
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// Header returns the names of cols.
+func Header(cols []Column) []string {
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = c.Name
+	}
+	return header
+}
+
+// Record formats the columns of row as CSV cells.
+func Record(cols []Column, row any) []string {
+	v := reflect.ValueOf(row)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+
+	record := make([]string, len(cols))
+	for i, c := range cols {
+		field, err := v.FieldByIndexErr(c.Index)
+		if err != nil {
+			continue
+		}
+		for field.Kind() == reflect.Pointer {
+			if field.IsNil() {
+				break
+			}
+			field = field.Elem()
+		}
+		if field.Kind() == reflect.Pointer {
+			continue
+		}
+		if m, ok := field.Interface().(encoding.TextMarshaler); ok {
+			text, err := m.MarshalText()
+			if err != nil {
+				continue
+			}
+			record[i] = escapeFormula(string(text))
+			continue
+		}
+		record[i] = escapeFormula(fmt.Sprint(field.Interface()))
+	}
+	return record
+}
+
+// escapeFormula prefixes cells that spreadsheets would evaluate as formulas
+// with a single quote. Signed numbers such as E.164 phone numbers are left
+// alone.
+func escapeFormula(cell string) string {
+	if cell == "" {
+		return cell
+	}
+
+	switch cell[0] {
+	case '=', '@', '\t', '\r':
+		return "'" + cell
+	case '+', '-':
+		if _, err := strconv.ParseFloat(cell, 64); err != nil {
+			return "'" + cell
+		}
+	}
+	return cell
+}
+
+// unescapeFormula reverses escapeFormula.
+func unescapeFormula(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && escapeFormula(cell[1:]) == cell {
+		return cell[1:]
+	}
+	return cell
+}
+
+// Reader decodes CSV rows into structs. The first row is a header naming
+// the columns, as written by Record. Columns the row type does not have are
+// ignored so exported files can be imported again.
+type Reader struct {
+	r       *csv.Reader
+	columns []*Column
+}
+
+// NewReader reads the header row of r and maps it onto the columns of
+// rowType. A header naming a column twice is rejected.
+func NewReader(r io.Reader, rowType reflect.Type) (*Reader, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, apperror.ErrBadRequest.WithMessage("Request body must not be empty")
+		}
+		return nil, apperror.ErrBadRequest.WithMessage("Invalid CSV header")
+	}
+
+	byName := map[string]Column{}
+	for _, c := range Columns(rowType) {
+		byName[c.Name] = c
+	}
+
+	seen := map[string]bool{}
+	reader := &Reader{r: cr, columns: make([]*Column, len(header))}
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if seen[name] {
+			return nil, apperror.ErrBadRequest.WithMessage("Invalid CSV header")
+		}
+		seen[name] = true
+
+		if c, ok := byName[name]; ok {
+			reader.columns[i] = &c
+		}
+	}
+	return reader, nil
+}
+
+// Read decodes the next row into dst, which must point to a value of the
+// row type. It returns the row's line number, and io.EOF after the last row.
+// Malformed rows are reported as *apperror.AppError values; reading can
+// continue with the next row.
+func (r *Reader) Read(dst any) (int, error) {
+	record, err := r.r.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return parseErr.StartLine, apperror.ErrBadRequest.WithMessage("Malformed CSV row")
+		}
+		return 0, err
+	}
+	line, _ := r.r.FieldPos(0)
+
+	v := reflect.ValueOf(dst).Elem()
+	for i, cell := range record {
+		if i >= len(r.columns) || r.columns[i] == nil || cell == "" {
+			continue
+		}
+
+		c := r.columns[i]
+		field := fieldByIndexAlloc(v, c.Index)
+		if err := setCell(field, unescapeFormula(cell)); err != nil {
+			message := "{0} must be of type {1}"
+			return line, apperror.ErrBadRequest.WithMessage(message, c.Name, field.Type().String()).
+				WithViolations(apperror.NewViolation(c.Name, "type", message, c.Name, field.Type().String()))
+		}
+	}
+	return line, nil
+}
+
+// fieldByIndexAlloc is like reflect.Value.FieldByIndex but allocates nil
+// struct pointers on the path.
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v
+}
+
+func setCell(field reflect.Value, cell string) error {
+	if field.Kind() == reflect.Pointer {
+		value := reflect.New(field.Type().Elem())
+		if err := setCell(value.Elem(), cell); err != nil {
+			return err
+		}
+		field.Set(value)
+		return nil
+	}
+
+	if field.Addr().Type().Implements(textUnmarshalerType) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(cell))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(cell)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(cell, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(cell, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(cell, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	default:
+		return fmt.Errorf("csv: unsupported field type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package csv_test
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/pkg/csv"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumns(t *testing.T) {
+	assert.Equal(t, []string{
+		"id", "name", "email", "username", "phone",
+		"address.id", "address.street", "address.city", "address.state", "address.zipcode", "address.user_id",
+	}, csv.Header(csv.Columns(reflect.TypeOf(models.User{}))))
+}
+
+func TestRecord(t *testing.T) {
+	columns := csv.Columns(reflect.TypeOf(models.Post{}))
+
+	tests := []struct {
+		name  string
+		title string
+		want  string
+	}{
+		{"plain text", "Hello, world", "Hello, world"},
+		{"formula", "=SUM(A1:A2)", "'=SUM(A1:A2)"},
+		{"at sign", "@cmd", "'@cmd"},
+		{"signed number", "-5", "-5"},
+		{"phone number", "+15551234567", "+15551234567"},
+		{"signed text", "+1 bad", "'+1 bad"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := csv.Record(columns, &models.Post{ID: "1", Title: tt.title})
+			assert.Equal(t, []string{"1", "", tt.want, "", ""}, record)
+		})
+	}
+}
+
+type row struct {
+	Name    string  `json:"name"`
+	Age     int     `json:"age"`
+	Nick    *string `json:"nick"`
+	Address *struct {
+		City string `json:"city"`
+	} `json:"address"`
+}
+
+func TestReader(t *testing.T) {
+	body := "name,age,nick,address.city,unknown\n" +
+		"Jane,30,,Paris,x\n" +
+		"'=Joe,abc,jj,,x\n" +
+		"\"Ann, Jr\",41,ann,Lyon\n" +
+		"Bob,20,bob,Rome,x\n"
+
+	r, err := csv.NewReader(strings.NewReader(body), reflect.TypeOf(row{}))
+	require.NoError(t, err)
+
+	var jane row
+	line, err := r.Read(&jane)
+	require.NoError(t, err)
+	assert.Equal(t, 2, line)
+	assert.Equal(t, "Jane", jane.Name)
+	assert.Equal(t, 30, jane.Age)
+	assert.Nil(t, jane.Nick)
+	assert.Equal(t, "Paris", jane.Address.City)
+
+	var joe row
+	line, err = r.Read(&joe)
+	assert.Equal(t, 3, line)
+	assert.True(t, errors.Is(err, apperror.ErrBadRequest))
+	assert.Equal(t, "=Joe", joe.Name)
+
+	var ann row
+	line, err = r.Read(&ann)
+	assert.Equal(t, 4, line)
+	assert.EqualError(t, err, "Malformed CSV row")
+
+	var bob row
+	line, err = r.Read(&bob)
+	require.NoError(t, err)
+	assert.Equal(t, 5, line)
+	assert.Equal(t, "bob", *bob.Nick)
+
+	_, err = r.Read(&row{})
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestReaderEmptyBody(t *testing.T) {
+	_, err := csv.NewReader(strings.NewReader(""), reflect.TypeOf(row{}))
+	assert.EqualError(t, err, "Request body must not be empty")
+}
+
+func TestReaderDuplicateColumn(t *testing.T) {
+	_, err := csv.NewReader(strings.NewReader("name,name\na,b\n"), reflect.TypeOf(row{}))
+	assert.EqualError(t, err, "Invalid CSV header")
+}
+
+func TestTextMarshalerColumns(t *testing.T) {
+	type event struct {
+		Name    string     `json:"name"`
+		At      time.Time  `json:"at"`
+		EndedAt *time.Time `json:"ended_at"`
+	}
+
+	columns := csv.Columns(reflect.TypeOf(event{}))
+	assert.Equal(t, []string{"name", "at", "ended_at"}, csv.Header(columns))
+
+	at := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	record := csv.Record(columns, &event{Name: "launch", At: at})
+	assert.Equal(t, []string{"launch", "2024-05-01T12:30:00Z", ""}, record)
+
+	r, err := csv.NewReader(strings.NewReader("name,at,ended_at\nlaunch,2024-05-01T12:30:00Z,2024-05-01T13:00:00Z\n"), reflect.TypeOf(event{}))
+	require.NoError(t, err)
+
+	var e event
+	_, err = r.Read(&e)
+	require.NoError(t, err)
+	assert.True(t, at.Equal(e.At))
+	require.NotNil(t, e.EndedAt)
+	assert.True(t, at.Add(30*time.Minute).Equal(*e.EndedAt))
+}
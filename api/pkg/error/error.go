@@ -3,21 +3,153 @@ package error
 import (
 	"errors"
 	"net/http"
+
+	"github.com/princecee/lema-ai/pkg/i18n"
+)
+
+// Code is a stable, machine-readable error identifier. Clients should
+// branch on codes rather than on messages.
+type Code string
+
+const (
+	CodeNotFound         Code = "not_found"
+	CodeBadRequest       Code = "bad_request"
+	CodeInvalidParameter Code = "invalid_parameter"
+	CodeInvalidJSON      Code = "invalid_json"
+	CodeValidation       Code = "validation_failed"
+	CodeInternal         Code = "internal_error"
+	CodeTooManyRequests  Code = "too_many_requests"
+	CodeConflict         Code = "conflict"
+	CodeUnprocessable    Code = "unprocessable_entity"
+	CodeMethodNotAllowed Code = "method_not_allowed"
+	CodePayloadTooLarge  Code = "payload_too_large"
+	CodeUnsupportedMedia Code = "unsupported_media_type"
+	CodeUnauthorized     Code = "unauthorized"
+	CodeForbidden        Code = "forbidden"
+
+	CodeIdempotencyKeyReused Code = "idempotency_key_reused"
+	CodeRequestInProgress    Code = "request_in_progress"
 )
 
+// Violation describes why a single request field was rejected. Field uses
+// the name the client sent, e.g. the JSON or query parameter name.
+type Violation struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+
+	key    string
+	params []string
+}
+
+// AppError is an error that can be reported to clients.
+type AppError struct {
+	Code       Code
+	Status     int
+	Message    string
+	Violations []Violation
+
+	key    string
+	params []string
+}
+
+func New(code Code, status int, message string) *AppError {
+	return &AppError{Code: code, Status: status, Message: message, key: message}
+}
+
+func (e *AppError) Error() string {
+	return e.Message
+}
+
+// Is reports errors with the same code as equal, so errors derived with
+// WithMessage or WithViolations still match their sentinel.
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && t.Code == e.Code
+}
+
+// WithMessage replaces the message. The message is a translation key whose
+// "{0}", "{1}", ... placeholders are filled from params, see Localize.
+func (e *AppError) WithMessage(message string, params ...string) *AppError {
+	c := *e
+	c.Message = i18n.Render(message, params...)
+	c.key = message
+	c.params = params
+	return &c
+}
+
+func (e *AppError) WithViolations(violations ...Violation) *AppError {
+	c := *e
+	c.Violations = append(append([]Violation{}, e.Violations...), violations...)
+	return &c
+}
+
+// Localize returns a copy of the error with its message and violation
+// messages translated into locale.
+func (e *AppError) Localize(locale string) *AppError {
+	c := *e
+	if c.key != "" {
+		c.Message = i18n.T(locale, c.key, c.params...)
+	}
+	c.Violations = make([]Violation, len(e.Violations))
+	for i, v := range e.Violations {
+		if v.key != "" {
+			v.Message = i18n.T(locale, v.key, v.params...)
+		}
+		c.Violations[i] = v
+	}
+	return &c
+}
+
 var (
-	ErrNotFound       = errors.New("not found")
-	ErrBadRequest     = errors.New("bad request")
-	ErrInternalServer = errors.New("internal server error")
+	ErrNotFound         = New(CodeNotFound, http.StatusNotFound, "not found")
+	ErrBadRequest       = New(CodeBadRequest, http.StatusBadRequest, "bad request")
+	ErrInvalidParameter = New(CodeInvalidParameter, http.StatusBadRequest, "invalid parameter")
+	ErrInvalidJSON      = New(CodeInvalidJSON, http.StatusBadRequest, "invalid JSON body")
+	ErrValidation       = New(CodeValidation, http.StatusBadRequest, "bad request")
+	ErrInternalServer   = New(CodeInternal, http.StatusInternalServerError, "internal server error")
+	ErrTooManyRequests  = New(CodeTooManyRequests, http.StatusTooManyRequests, "too many requests")
+	ErrConflict         = New(CodeConflict, http.StatusConflict, "conflict")
+	ErrUnprocessable    = New(CodeUnprocessable, http.StatusUnprocessableEntity, "unprocessable entity")
+	ErrMethodNotAllowed = New(CodeMethodNotAllowed, http.StatusMethodNotAllowed, "method not allowed")
+	ErrPayloadTooLarge  = New(CodePayloadTooLarge, http.StatusRequestEntityTooLarge, "request body too large")
+	ErrUnsupportedMedia = New(CodeUnsupportedMedia, http.StatusUnsupportedMediaType, "unsupported media type")
+	ErrUnauthorized     = New(CodeUnauthorized, http.StatusUnauthorized, "unauthorized")
+	ErrForbidden        = New(CodeForbidden, http.StatusForbidden, "forbidden")
+
+	ErrIdempotencyKeyReused = New(CodeIdempotencyKeyReused, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request")
+	ErrRequestInProgress    = New(CodeRequestInProgress, http.StatusConflict, "A request with this Idempotency-Key is still being processed")
 )
 
-func GetErrorStatusCode(err error) int {
-	switch {
-	case errors.Is(err, ErrNotFound):
-		return http.StatusNotFound
-	case errors.Is(err, ErrBadRequest):
-		return http.StatusBadRequest
-	default:
-		return http.StatusInternalServerError
+// NewViolation builds a violation whose message is a translation key, like
+// the message of WithMessage.
+func NewViolation(field, code, message string, params ...string) Violation {
+	return Violation{
+		Field:   field,
+		Code:    code,
+		Message: i18n.Render(message, params...),
+		key:     message,
+		params:  params,
 	}
 }
+
+// InvalidParameter reports a malformed path or query parameter.
+func InvalidParameter(field, message string, params ...string) *AppError {
+	return ErrInvalidParameter.WithMessage(message, params...).
+		WithViolations(NewViolation(field, "invalid", message, params...))
+}
+
+// FromError converts any error into an *AppError. Errors that are not
+// already application errors are reported as internal server errors so
+// their details do not leak to clients.
+func FromError(err error) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return ErrInternalServer
+}
+
+func GetErrorStatusCode(err error) int {
+	return FromError(err).Status
+}
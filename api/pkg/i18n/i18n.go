@@ -0,0 +1,98 @@
+package i18n
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	ut "github.com/go-playground/universal-translator"
+	"golang.org/x/text/language"
+)
+
+const (
+	English = "en"
+	French  = "fr"
+	Spanish = "es"
+
+	DefaultLocale = English
+)
+
+// Locales lists every supported locale, default first.
+var Locales = []string{English, French, Spanish}
+
+var (
+	universal = ut.New(en.New(), en.New(), fr.New(), es.New())
+	matcher   = language.NewMatcher([]language.Tag{language.English, language.French, language.Spanish})
+)
+
+func init() {
+	for locale, messages := range catalog {
+		trans := Translator(locale)
+		for key, text := range messages {
+			if err := trans.Add(key, text, false); err != nil {
+				panic(err)
+			}
+		}
+	}
+}
+
+// Translator returns the translator for locale, falling back to the
+// default locale.
+func Translator(locale string) ut.Translator {
+	trans, found := universal.GetTranslator(locale)
+	if !found {
+		trans, _ = universal.GetTranslator(DefaultLocale)
+	}
+	return trans
+}
+
+// ParseAcceptLanguage picks the best supported locale for an
+// Accept-Language header value.
+func ParseAcceptLanguage(header string) string {
+	tags, _, err := language.ParseAcceptLanguage(header)
+	if err != nil || len(tags) == 0 {
+		return DefaultLocale
+	}
+
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return DefaultLocale
+	}
+	return Locales[index]
+}
+
+// T translates a message into locale. Messages are keyed by their English
+// text, with "{0}", "{1}", ... placeholders filled from params. Messages
+// without a translation are rendered in English.
+func T(locale, message string, params ...string) string {
+	if locale != DefaultLocale {
+		if text, err := Translator(locale).T(message, params...); err == nil {
+			return text
+		}
+	}
+	return Render(message, params...)
+}
+
+// Render fills the "{0}", "{1}", ... placeholders of message with params.
+func Render(message string, params ...string) string {
+	for i, param := range params {
+		message = strings.ReplaceAll(message, "{"+strconv.Itoa(i)+"}", param)
+	}
+	return message
+}
+
+type localeCtxKey struct{}
+
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeCtxKey{}, locale)
+}
+
+func LocaleFromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeCtxKey{}).(string); ok {
+		return locale
+	}
+	return DefaultLocale
+}
@@ -0,0 +1,51 @@
+package i18n_test
+
+import (
+	"testing"
+
+	"github.com/princecee/lema-ai/pkg/i18n"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", i18n.English},
+		{"fr", i18n.French},
+		{"fr-CA,fr;q=0.9", i18n.French},
+		{"es-MX", i18n.Spanish},
+		{"de, es;q=0.5", i18n.Spanish},
+		{"de", i18n.English},
+		{"en-GB, fr;q=0.9", i18n.English},
+		{"not a language;;", i18n.English},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			assert.Equal(t, tt.want, i18n.ParseAcceptLanguage(tt.header))
+		})
+	}
+}
+
+func TestT(t *testing.T) {
+	tests := []struct {
+		locale  string
+		message string
+		params  []string
+		want    string
+	}{
+		{i18n.English, "{0} {1} not found", []string{"GET", "/x"}, "GET /x not found"},
+		{i18n.French, "{0} {1} not found", []string{"GET", "/x"}, "GET /x introuvable"},
+		{i18n.Spanish, "not found", nil, "no encontrado"},
+		{i18n.French, "no translation for this", nil, "no translation for this"},
+		{"de", "not found", nil, "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale+"/"+tt.message, func(t *testing.T) {
+			assert.Equal(t, tt.want, i18n.T(tt.locale, tt.message, tt.params...))
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package i18n
+
+// catalog holds translations of client-facing messages, keyed by their
+// English text.
+var catalog = map[string]map[string]string{
+	French: {
+		"not found":                "introuvable",
+		"bad request":              "requête invalide",
+		"invalid parameter":        "paramètre invalide",
+		"invalid JSON body":        "corps JSON invalide",
+		"internal server error":    "erreur interne du serveur",
+		"too many requests":        "trop de requêtes",
+		"conflict":                 "conflit",
+		"unprocessable entity":     "entité non traitable",
+		"method not allowed":       "méthode non autorisée",
+		"request body too large":   "corps de requête trop volumineux",
+		"unsupported media type":   "type de média non pris en charge",
+		"unauthorized":             "non authentifié",
+		"forbidden":                "interdit",
+		"{0} {1} not found":        "{0} {1} introuvable",
+		"{0} {1} not allowed":      "{0} {1} non autorisé",
+		"Invalid user ID":          "Identifiant d'utilisateur invalide",
+		"Invalid post ID":          "Identifiant de publication invalide",
+		"invalid page number":      "numéro de page invalide",
+		"invalid limit number":     "limite invalide",
+		"invalid offset number":    "décalage invalide",
+		"Invalid request body":     "Corps de requête invalide",
+		"Unknown field {0}":        "Champ inconnu {0}",
+		"{0} must be of type {1}":  "{0} doit être de type {1}",
+		"Content-Type must be {0}": "Content-Type doit être {0}",
+
+		"A user with this email, username or phone already exists": "Un utilisateur avec cet e-mail, ce nom d'utilisateur ou ce téléphone existe déjà",
+
+		"A user with this email already exists": "Un utilisateur avec cet e-mail existe déjà",
+		"A post with this ID already exists":    "Une publication avec cet identifiant existe déjà",
+		"User {0} does not exist":               "L'utilisateur {0} n'existe pas",
+		"Invalid CSV header":                    "En-tête CSV invalide",
+		"Malformed CSV row":                     "Ligne CSV mal formée",
+		"{0} must be true or false":             "{0} doit valoir true ou false",
+
+		"Invalid export ID":               "Identifiant d'export invalide",
+		"Export {0} is not ready":         "L'export {0} n'est pas prêt",
+		"{0} only applies to {1} exports": "{0} ne s'applique qu'aux exports de {1}",
+
+		"Invalid webhook ID":  "Identifiant de webhook invalide",
+		"Invalid delivery ID": "Identifiant de livraison invalide",
+
+		"Missing or invalid API key":       "Clé d'API manquante ou invalide",
+		"API key role {0} may not do this": "Le rôle de clé d'API {0} ne permet pas de faire ceci",
+		"Unknown topic {0}":                "Sujet inconnu {0}",
+		"Unknown frame type {0}":           "Type de trame inconnu {0}",
+
+		"Request body must not be empty":                               "Le corps de la requête ne doit pas être vide",
+		"Request body contains malformed JSON":                         "Le corps de la requête contient du JSON mal formé",
+		"Request body contains malformed JSON at position {0}":         "Le corps de la requête contient du JSON mal formé à la position {0}",
+		"Request body must be a JSON {0}":                              "Le corps de la requête doit être un {0} JSON",
+		"Request body must only contain a single JSON document":        "Le corps de la requête ne doit contenir qu'un seul document JSON",
+		"Request body must not be larger than {0} bytes":               "Le corps de la requête ne doit pas dépasser {0} octets",
+		"{0} must be at most {1} characters":                           "{0} doit contenir au plus {1} caractères",
+		"Idempotency-Key was already used with a different request":    "Idempotency-Key a déjà été utilisée avec une requête différente",
+		"A request with this Idempotency-Key is still being processed": "Une requête avec cette Idempotency-Key est toujours en cours de traitement",
+	},
+	Spanish: {
+		"not found":                "no encontrado",
+		"bad request":              "solicitud incorrecta",
+		"invalid parameter":        "parámetro no válido",
+		"invalid JSON body":        "cuerpo JSON no válido",
+		"internal server error":    "error interno del servidor",
+		"too many requests":        "demasiadas solicitudes",
+		"conflict":                 "conflicto",
+		"unprocessable entity":     "entidad no procesable",
+		"method not allowed":       "método no permitido",
+		"request body too large":   "cuerpo de la solicitud demasiado grande",
+		"unsupported media type":   "tipo de medio no admitido",
+		"unauthorized":             "no autenticado",
+		"forbidden":                "prohibido",
+		"{0} {1} not found":        "{0} {1} no encontrado",
+		"{0} {1} not allowed":      "{0} {1} no permitido",
+		"Invalid user ID":          "ID de usuario no válido",
+		"Invalid post ID":          "ID de publicación no válido",
+		"invalid page number":      "número de página no válido",
+		"invalid limit number":     "límite no válido",
+		"invalid offset number":    "desplazamiento no válido",
+		"Invalid request body":     "Cuerpo de la solicitud no válido",
+		"Unknown field {0}":        "Campo desconocido {0}",
+		"{0} must be of type {1}":  "{0} debe ser de tipo {1}",
+		"Content-Type must be {0}": "Content-Type debe ser {0}",
+
+		"A user with this email, username or phone already exists": "Ya existe un usuario con este correo electrónico, nombre de usuario o teléfono",
+
+		"A user with this email already exists": "Ya existe un usuario con este correo electrónico",
+		"A post with this ID already exists":    "Ya existe una publicación con este ID",
+		"User {0} does not exist":               "El usuario {0} no existe",
+		"Invalid CSV header":                    "Encabezado CSV no válido",
+		"Malformed CSV row":                     "Fila CSV mal formada",
+		"{0} must be true or false":             "{0} debe ser true o false",
+
+		"Invalid export ID":               "ID de exportación no válido",
+		"Export {0} is not ready":         "La exportación {0} no está lista",
+		"{0} only applies to {1} exports": "{0} solo se aplica a exportaciones de {1}",
+
+		"Invalid webhook ID":  "ID de webhook no válido",
+		"Invalid delivery ID": "ID de entrega no válido",
+
+		"Missing or invalid API key":       "Clave de API ausente o no válida",
+		"API key role {0} may not do this": "El rol de clave de API {0} no permite hacer esto",
+		"Unknown topic {0}":                "Tema desconocido {0}",
+		"Unknown frame type {0}":           "Tipo de trama desconocido {0}",
+
+		"Request body must not be empty":                               "El cuerpo de la solicitud no debe estar vacío",
+		"Request body contains malformed JSON":                         "El cuerpo de la solicitud contiene JSON mal formado",
+		"Request body contains malformed JSON at position {0}":         "El cuerpo de la solicitud contiene JSON mal formado en la posición {0}",
+		"Request body must be a JSON {0}":                              "El cuerpo de la solicitud debe ser un {0} JSON",
+		"Request body must only contain a single JSON document":        "El cuerpo de la solicitud solo debe contener un documento JSON",
+		"Request body must not be larger than {0} bytes":               "El cuerpo de la solicitud no debe superar los {0} bytes",
+		"{0} must be at most {1} characters":                           "{0} debe tener como máximo {1} caracteres",
+		"Idempotency-Key was already used with a different request":    "Idempotency-Key ya se usó con una solicitud diferente",
+		"A request with this Idempotency-Key is still being processed": "Una solicitud con esta Idempotency-Key aún se está procesando",
+	},
+}
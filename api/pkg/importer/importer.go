@@ -0,0 +1,105 @@
+package importer
+
+import (
+	"context"
+	"sort"
+
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/i18n"
+)
+
+type Options struct {
+	// DryRun validates and applies every row in a transaction that is
+	// rolled back, so the report shows what an import would do.
+	DryRun bool
+	// Upsert updates existing records instead of skipping them.
+	Upsert bool
+}
+
+type Status string
+
+const (
+	StatusCreated Status = "created"
+	StatusUpdated Status = "updated"
+	StatusSkipped Status = "skipped"
+	StatusFailed  Status = "failed"
+)
+
+// Row is a decoded row and the line it was read from.
+type Row[T any] struct {
+	Line  int
+	Value T
+}
+
+// Result reports what happened to a single row.
+type Result struct {
+	Row     int                  `json:"row"`
+	Status  Status               `json:"status"`
+	ID      string               `json:"id,omitempty"`
+	Message string               `json:"message,omitempty"`
+	Errors  []apperror.Violation `json:"errors,omitempty"`
+}
+
+type Report struct {
+	DryRun  bool     `json:"dry_run"`
+	Created int      `json:"created"`
+	Updated int      `json:"updated"`
+	Skipped int      `json:"skipped"`
+	Failed  int      `json:"failed"`
+	Rows    []Result `json:"rows"`
+}
+
+// Session holds the state of one import across its batches.
+type Session struct {
+	Options
+	report Report
+	seen   map[string]string
+}
+
+func NewSession(opts Options) *Session {
+	return &Session{
+		Options: opts,
+		report:  Report{DryRun: opts.DryRun, Rows: []Result{}},
+		seen:    map[string]string{},
+	}
+}
+
+func (s *Session) Add(result Result) {
+	switch result.Status {
+	case StatusCreated:
+		s.report.Created++
+	case StatusUpdated:
+		s.report.Updated++
+	case StatusSkipped:
+		s.report.Skipped++
+	case StatusFailed:
+		s.report.Failed++
+	}
+	s.report.Rows = append(s.report.Rows, result)
+}
+
+// Fail records row as failed with err, translated into the locale stored
+// in ctx.
+func (s *Session) Fail(ctx context.Context, row int, err error) {
+	appErr := apperror.FromError(err).Localize(i18n.LocaleFromContext(ctx))
+	s.Add(Result{Row: row, Status: StatusFailed, Message: appErr.Message, Errors: appErr.Violations})
+}
+
+// Seen returns the ID recorded for a natural key, such as an email address,
+// by an earlier row of the import.
+func (s *Session) Seen(key string) (string, bool) {
+	id, ok := s.seen[key]
+	return id, ok
+}
+
+func (s *Session) MarkSeen(key, id string) {
+	s.seen[key] = id
+}
+
+// Report returns the results so far, ordered by row.
+func (s *Session) Report() *Report {
+	sort.SliceStable(s.report.Rows, func(i, j int) bool {
+		return s.report.Rows[i].Row < s.report.Rows[j].Row
+	})
+	return &s.report
+}
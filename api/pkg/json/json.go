@@ -1,8 +1,17 @@
 package json
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	apperror "github.com/princecee/lema-ai/pkg/error"
 )
 
 func ReadJSON(r io.ReadCloser, dst any) error {
@@ -15,6 +24,126 @@ func ReadJSON(r io.ReadCloser, dst any) error {
 	return nil
 }
 
+// ReadJSONStrict decodes a request body that must hold exactly one JSON
+// document whose fields are all known to dst. Decoding failures are
+// returned as *apperror.AppError values that are safe to show to clients.
+func ReadJSONStrict(r io.ReadCloser, dst any) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return decodeError(err)
+		}
+		return apperror.ErrInvalidJSON.WithMessage("Request body must only contain a single JSON document")
+	}
+
+	return nil
+}
+
+// RowReader reads newline-delimited JSON documents, one per row. Unknown
+// fields are ignored so exported files can be imported again.
+type RowReader struct {
+	r    *bufio.Reader
+	line int
+}
+
+func NewRowReader(r io.Reader) *RowReader {
+	return &RowReader{r: bufio.NewReader(r)}
+}
+
+// Read decodes the next non-empty line into dst and returns its line
+// number, or io.EOF after the last line. Malformed rows are reported as
+// *apperror.AppError values; reading can continue with the next row.
+func (rr *RowReader) Read(dst any) (int, error) {
+	for {
+		data, err := rr.r.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			return rr.line, err
+		}
+		rr.line++
+
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+
+		if err := json.Unmarshal(data, dst); err != nil {
+			return rr.line, decodeError(err)
+		}
+		return rr.line, nil
+	}
+}
+
 func WriteJSON(data any) ([]byte, error) {
 	return json.Marshal(data)
 }
+
+func decodeError(err error) error {
+	var (
+		syntaxErr     *json.SyntaxError
+		typeErr       *json.UnmarshalTypeError
+		maxBytesErr   *http.MaxBytesError
+		invalidUnmErr *json.InvalidUnmarshalError
+	)
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		limit := strconv.FormatInt(maxBytesErr.Limit, 10)
+		return apperror.ErrPayloadTooLarge.WithMessage("Request body must not be larger than {0} bytes", limit)
+
+	case errors.As(err, &syntaxErr):
+		offset := strconv.FormatInt(syntaxErr.Offset, 10)
+		return apperror.ErrInvalidJSON.WithMessage("Request body contains malformed JSON at position {0}", offset)
+
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return apperror.ErrInvalidJSON.WithMessage("Request body contains malformed JSON")
+
+	case errors.Is(err, io.EOF):
+		return apperror.ErrInvalidJSON.WithMessage("Request body must not be empty")
+
+	case errors.As(err, &typeErr):
+		field, kind := typeErr.Field, jsonType(typeErr.Type.Kind())
+		if field == "" {
+			return apperror.ErrInvalidJSON.WithMessage("Request body must be a JSON {0}", kind)
+		}
+
+		const message = "{0} must be of type {1}"
+		return apperror.ErrInvalidJSON.WithMessage(message, field, kind).
+			WithViolations(apperror.NewViolation(field, "type", message, field, kind))
+
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for unknown fields.
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		const message = "Unknown field {0}"
+		return apperror.ErrInvalidJSON.WithMessage(message, field).
+			WithViolations(apperror.NewViolation(field, "unknown_field", message, field))
+
+	case errors.As(err, &invalidUnmErr):
+		return apperror.ErrInternalServer
+
+	default:
+		return apperror.ErrInvalidJSON
+	}
+}
+
+func jsonType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	default:
+		return kind.String()
+	}
+}
@@ -0,0 +1,106 @@
+package json_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/json"
+)
+
+type payload struct {
+	Title   string `json:"title"`
+	Address struct {
+		Zipcode string `json:"zipcode"`
+	} `json:"address"`
+}
+
+func TestReadJSONStrict(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		code    apperror.Code
+		message string
+		field   string
+	}{
+		{name: "valid", body: `{"title": "a", "address": {"zipcode": "10001"}}`},
+		{name: "empty", body: ``, code: apperror.CodeInvalidJSON, message: "Request body must not be empty"},
+		{name: "malformed", body: `{"title": "a",}`, code: apperror.CodeInvalidJSON, message: "Request body contains malformed JSON at position 15"},
+		{name: "truncated", body: `{"title": "a"`, code: apperror.CodeInvalidJSON, message: "Request body contains malformed JSON"},
+		{name: "unknown field", body: `{"name": "a"}`, code: apperror.CodeInvalidJSON, message: "Unknown field name", field: "name"},
+		{name: "type mismatch", body: `{"title": ["a"]}`, code: apperror.CodeInvalidJSON, message: "title must be of type string", field: "title"},
+		{name: "nested type mismatch", body: `{"address": {"zipcode": 10001}}`, code: apperror.CodeInvalidJSON, message: "address.zipcode must be of type string", field: "address.zipcode"},
+		{name: "wrong document type", body: `[]`, code: apperror.CodeInvalidJSON, message: "Request body must be a JSON object"},
+		{name: "trailing data", body: `{"title": "a"} garbage`, code: apperror.CodeInvalidJSON, message: "Request body must only contain a single JSON document"},
+		{name: "multiple documents", body: `{"title": "a"}{"title": "b"}`, code: apperror.CodeInvalidJSON, message: "Request body must only contain a single JSON document"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := json.ReadJSONStrict(io.NopCloser(strings.NewReader(tt.body)), &payload{})
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+
+			var appErr *apperror.AppError
+			if !errors.As(err, &appErr) {
+				t.Fatalf("expected an *apperror.AppError, got %v", err)
+			}
+			if appErr.Code != tt.code {
+				t.Errorf("expected code %s, got %s", tt.code, appErr.Code)
+			}
+			if appErr.Message != tt.message {
+				t.Errorf("expected message %q, got %q", tt.message, appErr.Message)
+			}
+			if tt.field != "" && (len(appErr.Violations) != 1 || appErr.Violations[0].Field != tt.field) {
+				t.Errorf("expected a violation for %s, got %v", tt.field, appErr.Violations)
+			}
+		})
+	}
+}
+
+func TestReadJSONStrictMaxBytes(t *testing.T) {
+	body := http.MaxBytesReader(httptest.NewRecorder(), io.NopCloser(strings.NewReader(`{"title": "`+strings.Repeat("a", 64)+`"}`)), 16)
+
+	err := json.ReadJSONStrict(body, &payload{})
+	if apperror.GetErrorStatusCode(err) != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %v", err)
+	}
+}
+
+func TestRowReader(t *testing.T) {
+	body := `{"title": "a", "id": "ignored"}` + "\n" +
+		"\n" +
+		`{"title": 1}` + "\n" +
+		`{"title": "c"}`
+
+	rr := json.NewRowReader(strings.NewReader(body))
+
+	var first payload
+	line, err := rr.Read(&first)
+	if err != nil || line != 1 || first.Title != "a" {
+		t.Fatalf("first row: line %d, %+v, %v", line, first, err)
+	}
+
+	line, err = rr.Read(&payload{})
+	if line != 3 || !errors.Is(err, apperror.ErrInvalidJSON) {
+		t.Fatalf("second row: line %d, %v", line, err)
+	}
+
+	var third payload
+	line, err = rr.Read(&third)
+	if err != nil || line != 4 || third.Title != "c" {
+		t.Fatalf("third row: line %d, %+v, %v", line, third, err)
+	}
+
+	if _, err := rr.Read(&payload{}); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+}
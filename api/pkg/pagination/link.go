@@ -0,0 +1,34 @@
+package pagination
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// LinkHeader builds an RFC 8288 Link header with first, prev, next and last
+// page links for u, keeping its other query parameters.
+func LinkHeader(u *url.URL, page, limit int, totalPages int64) string {
+	link := func(page int64, rel string) string {
+		query := u.Query()
+		query.Set("page", strconv.FormatInt(page, 10))
+		query.Set("limit", strconv.Itoa(limit))
+
+		target := *u
+		target.RawQuery = query.Encode()
+		return fmt.Sprintf(`<%s>; rel="%s"`, target.String(), rel)
+	}
+
+	last := max(totalPages, 1)
+	links := []string{link(1, "first")}
+	if page > 1 {
+		links = append(links, link(min(int64(page-1), last), "prev"))
+	}
+	if int64(page) < totalPages {
+		links = append(links, link(int64(page+1), "next"))
+	}
+	links = append(links, link(last, "last"))
+
+	return strings.Join(links, ", ")
+}
@@ -0,0 +1,50 @@
+package pagination_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/princecee/lema-ai/pkg/pagination"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkHeader(t *testing.T) {
+	u, _ := url.Parse("/api/v1/users?page=2&limit=5&sort=name")
+
+	tests := []struct {
+		name       string
+		page       int
+		totalPages int64
+		want       string
+	}{
+		{
+			name: "middle page", page: 2, totalPages: 4,
+			want: `</api/v1/users?limit=5&page=1&sort=name>; rel="first", ` +
+				`</api/v1/users?limit=5&page=1&sort=name>; rel="prev", ` +
+				`</api/v1/users?limit=5&page=3&sort=name>; rel="next", ` +
+				`</api/v1/users?limit=5&page=4&sort=name>; rel="last"`,
+		},
+		{
+			name: "only page", page: 1, totalPages: 1,
+			want: `</api/v1/users?limit=5&page=1&sort=name>; rel="first", ` +
+				`</api/v1/users?limit=5&page=1&sort=name>; rel="last"`,
+		},
+		{
+			name: "no results", page: 1, totalPages: 0,
+			want: `</api/v1/users?limit=5&page=1&sort=name>; rel="first", ` +
+				`</api/v1/users?limit=5&page=1&sort=name>; rel="last"`,
+		},
+		{
+			name: "past the last page", page: 9, totalPages: 4,
+			want: `</api/v1/users?limit=5&page=1&sort=name>; rel="first", ` +
+				`</api/v1/users?limit=5&page=4&sort=name>; rel="prev", ` +
+				`</api/v1/users?limit=5&page=4&sort=name>; rel="last"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, pagination.LinkHeader(u, tt.page, 5, tt.totalPages))
+		})
+	}
+}
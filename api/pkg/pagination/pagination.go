@@ -8,6 +8,11 @@ import (
 	"github.com/princecee/lema-ai/internal/db/models"
 )
 
+var (
+	ErrInvalidPage  = errors.New("invalid page number")
+	ErrInvalidLimit = errors.New("invalid limit number")
+)
+
 type PaginationQuery struct {
 	Page  *int
 	Limit *int
@@ -35,7 +40,7 @@ func FormatPaginationQuery(page, limit string) (int, int, error) {
 	if page != "" {
 		_page, err := strconv.Atoi(page)
 		if err != nil {
-			return 0, 0, errors.New("invalid page number")
+			return 0, 0, ErrInvalidPage
 		}
 		p = _page
 	}
@@ -43,7 +48,7 @@ func FormatPaginationQuery(page, limit string) (int, int, error) {
 	if limit != "" {
 		_limit, err := strconv.Atoi(limit)
 		if err != nil {
-			return 0, 0, errors.New("invalid limit number")
+			return 0, 0, ErrInvalidLimit
 		}
 		l = _limit
 	}
@@ -0,0 +1,79 @@
+package response
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/i18n"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details document.
+type Problem struct {
+	Type     string               `json:"type"`
+	Title    string               `json:"title"`
+	Status   int                  `json:"status"`
+	Detail   string               `json:"detail,omitempty"`
+	Instance string               `json:"instance,omitempty"`
+	Code     apperror.Code        `json:"code"`
+	Errors   []apperror.Violation `json:"errors,omitempty"`
+}
+
+func NewProblem(r *http.Request, err *apperror.AppError) Problem {
+	return Problem{
+		Type:     "urn:lema:problem:" + string(err.Code),
+		Title:    http.StatusText(err.Status),
+		Status:   err.Status,
+		Detail:   err.Message,
+		Instance: r.URL.Path,
+		Code:     err.Code,
+		Errors:   err.Violations,
+	}
+}
+
+// SendError reports err to the client. Clients that accept
+// application/problem+json get an RFC 7807 document; everyone else gets the
+// legacy Response envelope, with any field violations in data. Messages are
+// translated into the locale stored in the request context.
+func SendError(w http.ResponseWriter, r *http.Request, err error) {
+	appErr := apperror.FromError(err).Localize(i18n.LocaleFromContext(r.Context()))
+
+	if AcceptsProblem(r) {
+		w.Header().Set("Content-Type", ProblemContentType)
+		w.WriteHeader(appErr.Status)
+		_ = json.NewEncoder(w).Encode(NewProblem(r, appErr))
+		return
+	}
+
+	resp := Response[any]{Message: appErr.Message}
+	if appErr.Code == apperror.CodeValidation && len(appErr.Violations) > 0 {
+		data := make(map[string]any, len(appErr.Violations))
+		for _, v := range appErr.Violations {
+			data[v.Field] = v.Message
+		}
+		resp.Data = data
+	}
+	SendErrorResponse(w, resp, appErr.Status)
+}
+
+// AcceptsProblem reports whether the Accept header lists
+// application/problem+json.
+func AcceptsProblem(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil || mediaType != ProblemContentType {
+				continue
+			}
+			if q, ok := params["q"]; ok && q == "0" {
+				continue
+			}
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package response
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const EventStreamContentType = "text/event-stream"
+
+// EventWriter writes server-sent events, flushing each one to the client.
+type EventWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+}
+
+// NewEventWriter starts a text/event-stream response.
+func NewEventWriter(w http.ResponseWriter) *EventWriter {
+	w.Header().Set("Content-Type", EventStreamContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	// Stop nginx from buffering the stream.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	ew := &EventWriter{w: w, flusher: flusher}
+	ew.flush()
+	return ew
+}
+
+// WriteEvent writes an event. Lines of data are sent as separate data
+// fields, which clients join back with newlines.
+func (ew *EventWriter) WriteEvent(id, event string, data []byte) error {
+	var buf bytes.Buffer
+	if id != "" {
+		fmt.Fprintf(&buf, "id: %s\n", id)
+	}
+	if event != "" {
+		fmt.Fprintf(&buf, "event: %s\n", event)
+	}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		fmt.Fprintf(&buf, "data: %s\n", line)
+	}
+	buf.WriteByte('\n')
+
+	if _, err := ew.w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	ew.flush()
+	return nil
+}
+
+// WriteComment writes a comment, which clients ignore. It keeps idle
+// connections from being closed by proxies.
+func (ew *EventWriter) WriteComment(text string) error {
+	if _, err := fmt.Fprintf(ew.w, ": %s\n\n", text); err != nil {
+		return err
+	}
+	ew.flush()
+	return nil
+}
+
+func (ew *EventWriter) flush() {
+	if ew.flusher != nil {
+		ew.flusher.Flush()
+	}
+}
@@ -0,0 +1,28 @@
+package response_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/princecee/lema-ai/pkg/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventWriter(t *testing.T) {
+	w := httptest.NewRecorder()
+	ew := response.NewEventWriter(w)
+
+	require.NoError(t, ew.WriteEvent("1", "post.created", []byte(`{"id":"1"}`)))
+	require.NoError(t, ew.WriteComment("heartbeat"))
+	require.NoError(t, ew.WriteEvent("", "", []byte("a\nb")))
+
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+	assert.True(t, w.Flushed)
+	assert.Equal(t,
+		"id: 1\nevent: post.created\ndata: {\"id\":\"1\"}\n\n"+
+			": heartbeat\n\n"+
+			"data: a\ndata: b\n\n",
+		w.Body.String())
+}
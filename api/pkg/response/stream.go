@@ -0,0 +1,121 @@
+package response
+
+import (
+	stdcsv "encoding/csv"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/princecee/lema-ai/pkg/csv"
+)
+
+const (
+	JSONContentType   = "application/json"
+	CSVContentType    = "text/csv"
+	NDJSONContentType = "application/x-ndjson"
+)
+
+// Negotiate picks the listing format for the Accept header: CSV, NDJSON or,
+// by default, JSON. Ties are broken in favour of JSON.
+func Negotiate(r *http.Request) string {
+	best, bestQ := JSONContentType, -1.0
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+
+			q := 1.0
+			if v, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(v, 64); err != nil {
+					continue
+				}
+			}
+			if q <= 0 {
+				continue
+			}
+
+			switch mediaType {
+			case JSONContentType, ProblemContentType, "application/*", "*/*":
+				mediaType = JSONContentType
+			case CSVContentType, NDJSONContentType:
+			default:
+				continue
+			}
+
+			if q > bestQ || (q == bestQ && mediaType == JSONContentType) {
+				best, bestQ = mediaType, q
+			}
+		}
+	}
+	return best
+}
+
+// RowWriter streams rows of a listing to the client.
+type RowWriter interface {
+	WriteRow(row any) error
+	// Flush writes any buffered rows to the client.
+	Flush() error
+}
+
+// NewRowWriter starts a streamed response in contentType, which must be
+// CSVContentType or NDJSONContentType. For CSV, the header row is derived
+// from rowType, see csv.Columns.
+func NewRowWriter(w http.ResponseWriter, contentType string, rowType reflect.Type, headers map[string]string) RowWriter {
+	for k, v := range headers {
+		w.Header().Set(k, v)
+	}
+	w.Header().Set("Content-Type", contentType+"; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	if contentType == CSVContentType {
+		cw := &csvRowWriter{w: stdcsv.NewWriter(w), flusher: flusher, columns: csv.Columns(rowType)}
+		cw.err = cw.w.Write(csv.Header(cw.columns))
+		return cw
+	}
+
+	return &ndjsonRowWriter{enc: json.NewEncoder(w), flusher: flusher}
+}
+
+type ndjsonRowWriter struct {
+	enc     *json.Encoder
+	flusher http.Flusher
+}
+
+func (nw *ndjsonRowWriter) WriteRow(row any) error {
+	return nw.enc.Encode(row)
+}
+
+func (nw *ndjsonRowWriter) Flush() error {
+	if nw.flusher != nil {
+		nw.flusher.Flush()
+	}
+	return nil
+}
+
+type csvRowWriter struct {
+	w       *stdcsv.Writer
+	flusher http.Flusher
+	columns []csv.Column
+	err     error
+}
+
+func (cw *csvRowWriter) WriteRow(row any) error {
+	if cw.err != nil {
+		return cw.err
+	}
+	return cw.w.Write(csv.Record(cw.columns, row))
+}
+
+func (cw *csvRowWriter) Flush() error {
+	cw.w.Flush()
+	if cw.flusher != nil {
+		cw.flusher.Flush()
+	}
+	return cw.w.Error()
+}
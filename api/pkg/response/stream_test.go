@@ -0,0 +1,80 @@
+package response_test
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/princecee/lema-ai/internal/db/models"
+	"github.com/princecee/lema-ai/pkg/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", response.JSONContentType},
+		{"application/json", response.JSONContentType},
+		{"text/csv", response.CSVContentType},
+		{"application/x-ndjson", response.NDJSONContentType},
+		{"text/html", response.JSONContentType},
+		{"*/*", response.JSONContentType},
+		{"text/csv, application/json", response.JSONContentType},
+		{"text/csv, application/json;q=0.5", response.CSVContentType},
+		{"application/x-ndjson;q=0.9, text/csv;q=0.8", response.NDJSONContentType},
+		{"text/csv;q=0", response.JSONContentType},
+		{"application/problem+json, text/csv", response.JSONContentType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			assert.Equal(t, tt.want, response.Negotiate(r))
+		})
+	}
+}
+
+func TestCSVRowWriter(t *testing.T) {
+	w := httptest.NewRecorder()
+	rw := response.NewRowWriter(w, response.CSVContentType, reflect.TypeOf(models.Post{}), map[string]string{"X-Total-Count": "2"})
+
+	require.NoError(t, rw.WriteRow(&models.Post{ID: "1", UserID: "u", Title: "Hello, world", Body: "=SUM(A1:A2)"}))
+	require.NoError(t, rw.WriteRow(models.Post{ID: "2", UserID: "u", Title: "-5", Body: "+1 bad"}))
+	require.NoError(t, rw.Flush())
+
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "2", w.Header().Get("X-Total-Count"))
+
+	records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"id", "user_id", "title", "body", "created_at"},
+		{"1", "u", "Hello, world", "'=SUM(A1:A2)", ""},
+		{"2", "u", "-5", "'+1 bad", ""},
+	}, records)
+}
+
+func TestNDJSONRowWriter(t *testing.T) {
+	w := httptest.NewRecorder()
+	rw := response.NewRowWriter(w, response.NDJSONContentType, reflect.TypeOf(models.Post{}), nil)
+
+	require.NoError(t, rw.WriteRow(models.Post{ID: "1"}))
+	require.NoError(t, rw.WriteRow(models.Post{ID: "2"}))
+	require.NoError(t, rw.Flush())
+
+	assert.Equal(t, "application/x-ndjson; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.True(t, w.Flushed)
+	assert.Equal(t,
+		`{"id":"1","user_id":"","title":"","body":"","created_at":""}`+"\n"+
+			`{"id":"2","user_id":"","title":"","body":"","created_at":""}`+"\n",
+		w.Body.String())
+}
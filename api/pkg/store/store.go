@@ -0,0 +1,52 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+var ErrNotFound = errors.New("store: key not found")
+
+// Store holds counters and cache entries shared by every API replica.
+// Keys with a zero ttl never expire.
+type Store interface {
+	// IncrBy adds delta to the counter at key and returns the new value.
+	// The ttl is only applied when the counter is created.
+	IncrBy(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error)
+	// GetInt returns the counter at key, or 0 if it does not exist.
+	GetInt(ctx context.Context, key string) (int64, error)
+
+	// Get returns the value at key or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// SetNX sets key only if it does not exist yet and reports whether it did.
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	Delete(ctx context.Context, keys ...string) error
+
+	Close() error
+}
+
+// New returns the store for the configured backend.
+func New(backend, redisURL string) (Store, error) {
+	switch backend {
+	case "", BackendMemory:
+		return NewMemoryStore(), nil
+	case BackendRedis:
+		opts, err := redis.ParseURL(redisURL)
+		if err != nil {
+			return nil, fmt.Errorf("store: invalid redis url: %w", err)
+		}
+		return NewRedisStore(redis.NewClient(opts)), nil
+	default:
+		return nil, fmt.Errorf("store: unknown backend %q", backend)
+	}
+}
@@ -0,0 +1,132 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/princecee/lema-ai/pkg/store"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+)
+
+type StoreTestSuite struct {
+	suite.Suite
+	store store.Store
+	// advance moves the backend's clock forward to expire keys.
+	advance func(d time.Duration)
+}
+
+func (s *StoreTestSuite) TearDownTest() {
+	s.store.Close()
+}
+
+func (s *StoreTestSuite) TestStore() {
+	t := s.T()
+	ctx := context.Background()
+
+	t.Run("Counters", func(t *testing.T) {
+		v, err := s.store.GetInt(ctx, "counter")
+		s.NoError(err)
+		s.Equal(int64(0), v)
+
+		v, err = s.store.IncrBy(ctx, "counter", 2, time.Minute)
+		s.NoError(err)
+		s.Equal(int64(2), v)
+
+		v, err = s.store.IncrBy(ctx, "counter", 3, time.Minute)
+		s.NoError(err)
+		s.Equal(int64(5), v)
+
+		v, err = s.store.GetInt(ctx, "counter")
+		s.NoError(err)
+		s.Equal(int64(5), v)
+	})
+
+	t.Run("Counters expire from creation", func(t *testing.T) {
+		_, err := s.store.IncrBy(ctx, "window", 1, 200*time.Millisecond)
+		s.NoError(err)
+
+		s.advance(120 * time.Millisecond)
+		_, err = s.store.IncrBy(ctx, "window", 1, 200*time.Millisecond)
+		s.NoError(err)
+
+		s.advance(120 * time.Millisecond)
+		v, err := s.store.GetInt(ctx, "window")
+		s.NoError(err)
+		s.Equal(int64(0), v)
+	})
+
+	t.Run("Cache entries", func(t *testing.T) {
+		_, err := s.store.Get(ctx, "missing")
+		s.ErrorIs(err, store.ErrNotFound)
+
+		s.NoError(s.store.Set(ctx, "key", []byte("value"), time.Minute))
+		v, err := s.store.Get(ctx, "key")
+		s.NoError(err)
+		s.Equal([]byte("value"), v)
+
+		s.NoError(s.store.Delete(ctx, "key"))
+		_, err = s.store.Get(ctx, "key")
+		s.ErrorIs(err, store.ErrNotFound)
+	})
+
+	t.Run("Cache entries expire", func(t *testing.T) {
+		s.NoError(s.store.Set(ctx, "short", []byte("value"), 50*time.Millisecond))
+		s.advance(100 * time.Millisecond)
+
+		_, err := s.store.Get(ctx, "short")
+		s.ErrorIs(err, store.ErrNotFound)
+	})
+
+	t.Run("SetNX only sets missing keys", func(t *testing.T) {
+		ok, err := s.store.SetNX(ctx, "lock", []byte("first"), time.Minute)
+		s.NoError(err)
+		s.True(ok)
+
+		ok, err = s.store.SetNX(ctx, "lock", []byte("second"), time.Minute)
+		s.NoError(err)
+		s.False(ok)
+
+		v, err := s.store.Get(ctx, "lock")
+		s.NoError(err)
+		s.Equal([]byte("first"), v)
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	suite.Run(t, &StoreTestSuite{
+		store:   store.NewMemoryStore(),
+		advance: time.Sleep,
+	})
+}
+
+func TestRedisStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	suite.Run(t, &StoreTestSuite{
+		store:   store.NewRedisStore(client),
+		advance: mr.FastForward,
+	})
+}
+
+func TestNew(t *testing.T) {
+	st, err := store.New(store.BackendMemory, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.Close()
+
+	mr := miniredis.RunT(t)
+	st, err = store.New(store.BackendRedis, "redis://"+mr.Addr()+"/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.Close()
+
+	if _, err := store.New("memcached", ""); err == nil {
+		t.Fatal("expected an error for an unknown backend")
+	}
+}
@@ -0,0 +1,99 @@
+package validator
+
+import (
+	"reflect"
+	"strings"
+)
+
+// modifiers rewrite string fields before validation. They are listed in a
+// field's "mod" tag, e.g. `mod:"trim,lower"`, and applied in order.
+var modifiers = map[string]func(string) string{
+	"trim":  strings.TrimSpace,
+	"lower": strings.ToLower,
+	"e164":  NormalizePhone,
+}
+
+// Normalize applies the "mod" tags of the struct data points to, including
+// nested structs and pointers. Values that are not pointers to structs are
+// left untouched.
+func Normalize(data any) {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return
+	}
+	normalizeValue(v.Elem())
+}
+
+func normalizeValue(v reflect.Value) {
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		for value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				break
+			}
+			value = value.Elem()
+		}
+
+		switch value.Kind() {
+		case reflect.Struct:
+			normalizeValue(value)
+		case reflect.String:
+			tag := field.Tag.Get("mod")
+			if tag == "" || !value.CanSet() {
+				continue
+			}
+			s := value.String()
+			for _, name := range strings.Split(tag, ",") {
+				if mod, ok := modifiers[name]; ok {
+					s = mod(s)
+				}
+			}
+			value.SetString(s)
+		}
+	}
+}
+
+// NormalizePhone rewrites a phone number in E.164 format, e.g.
+// "(555) 123-4567" becomes "+15551234567". Numbers without a country code
+// are assumed to be North American. Input that cannot be normalized is
+// returned trimmed so the "e164" tag can reject it.
+func NormalizePhone(phone string) string {
+	phone = strings.TrimSpace(phone)
+	international := strings.HasPrefix(phone, "+")
+	if !international && strings.HasPrefix(phone, "00") {
+		international = true
+		phone = phone[2:]
+	}
+
+	var digits strings.Builder
+	for _, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' || r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return phone
+		}
+	}
+
+	number := digits.String()
+	switch {
+	case international:
+		return "+" + number
+	case len(number) == 10:
+		return "+1" + number
+	case len(number) == 11 && strings.HasPrefix(number, "1"):
+		return "+" + number
+	default:
+		return phone
+	}
+}
@@ -0,0 +1,162 @@
+package validator
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"github.com/princecee/lema-ai/pkg/i18n"
+)
+
+var (
+	usZipcodeRegex   = regexp.MustCompile(`^\d{5}(-\d{4})?$`)
+	domainLabelRegex = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?$`)
+	tldRegex         = regexp.MustCompile(`^[a-zA-Z]{2,63}$`)
+)
+
+var (
+	bannedWordsMu sync.RWMutex
+	bannedWords   = map[string]struct{}{}
+)
+
+// SetBannedWords replaces the words rejected by the "nobannedwords" tag.
+// Words are matched case-insensitively against whole words.
+func SetBannedWords(words []string) {
+	set := make(map[string]struct{}, len(words))
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			set[word] = struct{}{}
+		}
+	}
+
+	bannedWordsMu.Lock()
+	bannedWords = set
+	bannedWordsMu.Unlock()
+}
+
+// rules are the custom validation tags registered next to the built-in
+// ones.
+var rules = map[string]validator.Func{
+	"notblank":          notBlank,
+	"nobannedwords":     noBannedWords,
+	"deliverable_email": deliverableEmail,
+	"us_zipcode":        usZipcode,
+}
+
+// notBlank rejects strings that are empty or only whitespace.
+func notBlank(fl validator.FieldLevel) bool {
+	return strings.TrimSpace(fl.Field().String()) != ""
+}
+
+func noBannedWords(fl validator.FieldLevel) bool {
+	bannedWordsMu.RLock()
+	defer bannedWordsMu.RUnlock()
+
+	if len(bannedWords) == 0 {
+		return true
+	}
+
+	words := strings.FieldsFunc(fl.Field().String(), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, word := range words {
+		if _, banned := bannedWords[strings.ToLower(word)]; banned {
+			return false
+		}
+	}
+	return true
+}
+
+// deliverableEmail accepts a bare address whose domain could receive mail:
+// no display name, no IP literal, and a dotted domain ending in an
+// alphabetic top-level domain.
+func deliverableEmail(fl validator.FieldLevel) bool {
+	value := fl.Field().String()
+	if len(value) > 254 {
+		return false
+	}
+
+	addr, err := mail.ParseAddress(value)
+	if err != nil || addr.Name != "" || addr.Address != value {
+		return false
+	}
+
+	local, domain, found := strings.Cut(value, "@")
+	if !found || len(local) == 0 || len(local) > 64 || len(domain) > 253 {
+		return false
+	}
+
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if len(label) > 63 || !domainLabelRegex.MatchString(label) {
+			return false
+		}
+	}
+	return tldRegex.MatchString(labels[len(labels)-1])
+}
+
+// usZipcode accepts five digit ZIP codes and ZIP+4 codes.
+func usZipcode(fl validator.FieldLevel) bool {
+	return usZipcodeRegex.MatchString(fl.Field().String())
+}
+
+// ruleMessages translates the custom tags, and built-in tags that a
+// locale's bundle lacks.
+var ruleMessages = map[string]map[string]string{
+	i18n.English: {
+		"notblank":          "{0} must not be blank",
+		"nobannedwords":     "{0} contains a banned word",
+		"deliverable_email": "{0} must be a deliverable email address",
+		"us_zipcode":        "{0} must be a US ZIP code such as 12345 or 12345-6789",
+		"http_url":          "{0} must be an HTTP or HTTPS URL",
+	},
+	i18n.French: {
+		"notblank":          "{0} ne doit pas être vide",
+		"nobannedwords":     "{0} contient un mot interdit",
+		"deliverable_email": "{0} doit être une adresse e-mail pouvant recevoir du courrier",
+		"us_zipcode":        "{0} doit être un code postal américain, par exemple 12345 ou 12345-6789",
+		"e164":              "{0} doit être un numéro de téléphone valide au format E.164",
+		"http_url":          "{0} doit être une URL HTTP ou HTTPS",
+	},
+	i18n.Spanish: {
+		"notblank":          "{0} no debe estar en blanco",
+		"nobannedwords":     "{0} contiene una palabra prohibida",
+		"deliverable_email": "{0} debe ser una dirección de correo electrónico que pueda recibir correo",
+		"us_zipcode":        "{0} debe ser un código postal de EE. UU., por ejemplo 12345 o 12345-6789",
+		"http_url":          "{0} debe ser una URL HTTP o HTTPS",
+	},
+}
+
+func registerRules(validate *validator.Validate) {
+	for tag, fn := range rules {
+		mustRegister(validate.RegisterValidation(tag, fn))
+	}
+
+	for locale, messages := range ruleMessages {
+		trans := i18n.Translator(locale)
+		for tag, message := range messages {
+			mustRegister(validate.RegisterTranslation(tag, trans, registerMessage(tag, message), translateMessage))
+		}
+	}
+}
+
+func registerMessage(tag, message string) validator.RegisterTranslationsFunc {
+	return func(trans ut.Translator) error {
+		return trans.Add(tag, message, false)
+	}
+}
+
+func translateMessage(trans ut.Translator, fe validator.FieldError) string {
+	message, err := trans.T(fe.Tag(), fe.Field())
+	if err != nil {
+		return fe.Error()
+	}
+	return message
+}
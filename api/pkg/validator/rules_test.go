@@ -0,0 +1,295 @@
+package validator_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/i18n"
+	"github.com/princecee/lema-ai/pkg/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// violations validates data and returns its violations by field.
+func violations(t *testing.T, ctx context.Context, data any) map[string]apperror.Violation {
+	t.Helper()
+
+	err := validator.ValidateData(ctx, data)
+	if err == nil {
+		return nil
+	}
+
+	var appErr *apperror.AppError
+	require.True(t, errors.As(err, &appErr))
+	require.Equal(t, apperror.CodeValidation, appErr.Code)
+
+	byField := map[string]apperror.Violation{}
+	for _, v := range appErr.Violations {
+		byField[v.Field] = v
+	}
+	return byField
+}
+
+func TestNotBlank(t *testing.T) {
+	type data struct {
+		Title string `json:"title" validate:"notblank"`
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		valid bool
+	}{
+		{"text", "hello", true},
+		{"padded text", "  hello  ", true},
+		{"empty", "", false},
+		{"spaces", "   ", false},
+		{"tabs and newlines", "\t\n\r", false},
+		{"unicode space", "  ", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := violations(t, context.Background(), &data{Title: tt.value})
+			if tt.valid {
+				assert.Empty(t, got)
+				return
+			}
+			require.Contains(t, got, "title")
+			assert.Equal(t, "notblank", got["title"].Code)
+			assert.Equal(t, "title must not be blank", got["title"].Message)
+		})
+	}
+}
+
+func TestTrimModifier(t *testing.T) {
+	type data struct {
+		Title string  `json:"title" mod:"trim" validate:"required,max=5"`
+		Email *string `json:"email" mod:"trim,lower"`
+	}
+
+	tests := []struct {
+		name      string
+		title     string
+		email     string
+		wantTitle string
+		wantEmail string
+		valid     bool
+	}{
+		{"unchanged", "hello", "a@b.co", "hello", "a@b.co", true},
+		{"surrounding whitespace", "  hello\n", " A@B.CO ", "hello", "a@b.co", true},
+		{"whitespace only", "   ", "a@b.co", "", "a@b.co", false},
+		{"too long after trimming", " hello! ", "a@b.co", "hello!", "a@b.co", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email := tt.email
+			d := &data{Title: tt.title, Email: &email}
+			got := violations(t, context.Background(), d)
+
+			assert.Equal(t, tt.wantTitle, d.Title)
+			assert.Equal(t, tt.wantEmail, *d.Email)
+			assert.Equal(t, tt.valid, len(got) == 0)
+		})
+	}
+}
+
+func TestMaxLength(t *testing.T) {
+	type data struct {
+		Title string `json:"title" validate:"max=10"`
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		valid bool
+	}{
+		{"empty", "", true},
+		{"at limit", strings.Repeat("a", 10), true},
+		{"multi-byte at limit", strings.Repeat("é", 10), true},
+		{"over limit", strings.Repeat("a", 11), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := violations(t, context.Background(), &data{Title: tt.value})
+			if tt.valid {
+				assert.Empty(t, got)
+				return
+			}
+			assert.Equal(t, "max", got["title"].Code)
+		})
+	}
+}
+
+func TestNoBannedWords(t *testing.T) {
+	type data struct {
+		Body string `json:"body" validate:"nobannedwords"`
+	}
+
+	validator.SetBannedWords([]string{"spam", " Scam "})
+	t.Cleanup(func() { validator.SetBannedWords(nil) })
+
+	tests := []struct {
+		name  string
+		value string
+		valid bool
+	}{
+		{"clean", "a perfectly fine post", true},
+		{"substring of a word", "spammer and scammed are fine", true},
+		{"banned word", "buy spam now", false},
+		{"different case", "This is a SCAM", false},
+		{"surrounded by punctuation", "no (spam), please", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := violations(t, context.Background(), &data{Body: tt.value})
+			if tt.valid {
+				assert.Empty(t, got)
+				return
+			}
+			assert.Equal(t, "nobannedwords", got["body"].Code)
+		})
+	}
+
+	t.Run("no banned words configured", func(t *testing.T) {
+		validator.SetBannedWords(nil)
+		assert.Empty(t, violations(t, context.Background(), &data{Body: "spam"}))
+	})
+}
+
+func TestPhoneNormalization(t *testing.T) {
+	type data struct {
+		Phone string `json:"phone" mod:"e164" validate:"e164"`
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+		valid bool
+	}{
+		{"already E.164", "+15551234567", "+15551234567", true},
+		{"formatted national number", "(555) 123-4567", "+15551234567", true},
+		{"dotted national number", "555.123.4567", "+15551234567", true},
+		{"with trunk prefix", "1-555-123-4567", "+15551234567", true},
+		{"international with spaces", "+44 20 7946 0958", "+442079460958", true},
+		{"international with 00 prefix", "0044 20 7946 0958", "+442079460958", true},
+		{"too short", "12345", "12345", false},
+		{"extension", "555-123-4567 x89", "555-123-4567 x89", false},
+		{"letters", "call me", "call me", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &data{Phone: tt.value}
+			got := violations(t, context.Background(), d)
+
+			assert.Equal(t, tt.want, d.Phone)
+			if tt.valid {
+				assert.Empty(t, got)
+				return
+			}
+			assert.Equal(t, "e164", got["phone"].Code)
+		})
+	}
+}
+
+func TestDeliverableEmail(t *testing.T) {
+	type data struct {
+		Email string `json:"email" validate:"deliverable_email"`
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		valid bool
+	}{
+		{"simple", "jane@example.com", true},
+		{"subdomain and plus tag", "jane.doe+news@mail.example.co.uk", true},
+		{"hyphenated domain", "jane@my-domain.io", true},
+		{"missing at", "jane.example.com", false},
+		{"display name", "Jane <jane@example.com>", false},
+		{"dotless domain", "jane@localhost", false},
+		{"IP literal", "jane@[127.0.0.1]", false},
+		{"numeric top-level domain", "jane@example.123", false},
+		{"label starting with hyphen", "jane@-example.com", false},
+		{"empty label", "jane@example..com", false},
+		{"local part too long", strings.Repeat("a", 65) + "@example.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := violations(t, context.Background(), &data{Email: tt.value})
+			if tt.valid {
+				assert.Empty(t, got)
+				return
+			}
+			assert.Equal(t, "deliverable_email", got["email"].Code)
+		})
+	}
+}
+
+func TestUSZipcode(t *testing.T) {
+	type data struct {
+		Zipcode string `json:"zipcode" validate:"us_zipcode"`
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		valid bool
+	}{
+		{"five digits", "12345", true},
+		{"ZIP+4", "12345-6789", true},
+		{"four digits", "1234", false},
+		{"six digits", "123456", false},
+		{"ZIP+4 without hyphen", "123456789", false},
+		{"letters", "ABCDE", false},
+		{"Canadian postal code", "K1A 0B1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := violations(t, context.Background(), &data{Zipcode: tt.value})
+			if tt.valid {
+				assert.Empty(t, got)
+				return
+			}
+			assert.Equal(t, "us_zipcode", got["zipcode"].Code)
+		})
+	}
+}
+
+func TestRuleMessagesAreLocalized(t *testing.T) {
+	type data struct {
+		Title   string `json:"title" validate:"notblank"`
+		Phone   string `json:"phone" validate:"e164"`
+		Zipcode string `json:"zipcode" validate:"us_zipcode"`
+	}
+
+	tests := []struct {
+		locale string
+		field  string
+		want   string
+	}{
+		{i18n.English, "title", "title must not be blank"},
+		{i18n.French, "title", "title ne doit pas être vide"},
+		{i18n.Spanish, "title", "title no debe estar en blanco"},
+		{i18n.French, "phone", "phone doit être un numéro de téléphone valide au format E.164"},
+		{i18n.Spanish, "zipcode", "zipcode debe ser un código postal de EE. UU., por ejemplo 12345 o 12345-6789"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale+"/"+tt.field, func(t *testing.T) {
+			ctx := i18n.WithLocale(context.Background(), tt.locale)
+			got := violations(t, ctx, &data{Title: " ", Phone: "x", Zipcode: "x"})
+			assert.Equal(t, tt.want, got[tt.field].Message)
+		})
+	}
+}
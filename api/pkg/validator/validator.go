@@ -1,28 +1,89 @@
 package validator
 
 import (
+	"context"
+	"reflect"
+	"strings"
+
 	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	es_translations "github.com/go-playground/validator/v10/translations/es"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
 	"github.com/google/uuid"
+	apperror "github.com/princecee/lema-ai/pkg/error"
+	"github.com/princecee/lema-ai/pkg/i18n"
 )
 
-var v = validator.New(validator.WithRequiredStructEnabled())
+var v = newValidator()
 
-func ValidateData(data any) map[string]any {
-	err := v.Struct(data)
-	if err != nil {
-		if _, ok := err.(*validator.InvalidValidationError); ok {
-			return map[string]any{"error": err.Error()}
-		}
+func newValidator() *validator.Validate {
+	validate := validator.New(validator.WithRequiredStructEnabled())
 
-		errors := make(map[string]any)
-		for _, err := range err.(validator.ValidationErrors) {
-			errors[err.Field()] = err.Error()
+	// Report fields by the name clients send rather than the Go field name.
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			return ""
+		case "":
+			return field.Name
+		default:
+			return name
 		}
+	})
+
+	mustRegister(en_translations.RegisterDefaultTranslations(validate, i18n.Translator(i18n.English)))
+	mustRegister(fr_translations.RegisterDefaultTranslations(validate, i18n.Translator(i18n.French)))
+	mustRegister(es_translations.RegisterDefaultTranslations(validate, i18n.Translator(i18n.Spanish)))
+	registerRules(validate)
+
+	return validate
+}
+
+func mustRegister(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+// ValidateData normalizes data according to its "mod" tags when data is a
+// pointer, validates it against its struct tags and returns an
+// *apperror.AppError listing every violation, or nil if data is valid.
+// Violation messages are translated into the locale stored in ctx.
+func ValidateData(ctx context.Context, data any) error {
+	Normalize(data)
+
+	err := v.Struct(data)
+	if err == nil {
+		return nil
+	}
 
-		return errors
+	validationErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return apperror.ErrInternalServer
 	}
 
-	return nil
+	trans := i18n.Translator(i18n.LocaleFromContext(ctx))
+	violations := make([]apperror.Violation, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		violations = append(violations, apperror.Violation{
+			Field:   fieldPath(fe),
+			Code:    fe.Tag(),
+			Message: fe.Translate(trans),
+		})
+	}
+
+	return apperror.ErrValidation.WithViolations(violations...)
+}
+
+// fieldPath drops the root struct name from the namespace, e.g.
+// "createPostData.title" becomes "title".
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
 }
 
 func IsValidUUID(id string) bool {